@@ -0,0 +1,74 @@
+package apikey
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Scope — право, выдаваемое API-ключу.
+type Scope string
+
+const (
+	ScopeWorkoutsRead  Scope = "workouts:read"
+	ScopeWorkoutsWrite Scope = "workouts:write"
+	ScopeClientsRead   Scope = "clients:read"
+	ScopeClientsWrite  Scope = "clients:write"
+)
+
+// ValidScopes — список допустимых прав.
+var ValidScopes = []Scope{
+	ScopeWorkoutsRead,
+	ScopeWorkoutsWrite,
+	ScopeClientsRead,
+	ScopeClientsWrite,
+}
+
+// IsValidScope проверяет, что строка — одно из известных прав.
+func IsValidScope(s string) bool {
+	for _, v := range ValidScopes {
+		if Scope(s) == v {
+			return true
+		}
+	}
+	return false
+}
+
+// APIKey — персональный ключ тренера для скриптов и интеграций.
+// Сам ключ имеет вид td_<prefix>_<secret>; в БД хранится только
+// публичный префикс (для поиска) и хеш всего ключа.
+type APIKey struct {
+	ID     uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	UserID uuid.UUID `gorm:"type:uuid;not null;index"`
+
+	Name    string `gorm:"not null"`
+	Prefix  string `gorm:"size:16;not null;uniqueIndex"`
+	KeyHash string `gorm:"size:64;not null"`
+	Scopes  string `gorm:"type:text;not null"` // права через пробел
+
+	ExpiresAt  *time.Time
+	LastUsedAt *time.Time
+
+	CreatedAt time.Time
+}
+
+// ScopeList возвращает права ключа списком.
+func (k APIKey) ScopeList() []string {
+	return strings.Fields(k.Scopes)
+}
+
+// HasScope проверяет, выдано ли ключу право s.
+func (k APIKey) HasScope(s Scope) bool {
+	for _, v := range k.ScopeList() {
+		if Scope(v) == s {
+			return true
+		}
+	}
+	return false
+}
+
+// IsExpired — истёк ли срок действия ключа.
+func (k APIKey) IsExpired(now time.Time) bool {
+	return k.ExpiresAt != nil && now.After(*k.ExpiresAt)
+}
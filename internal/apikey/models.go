@@ -0,0 +1,25 @@
+package apikey
+
+// CreateAPIKeyRequest — тело запроса для выпуска ключа.
+type CreateAPIKeyRequest struct {
+	Name      string   `json:"name"`
+	Scopes    []string `json:"scopes"`
+	ExpiresAt *string  `json:"expires_at"` // RFC 3339, null — бессрочный
+}
+
+// APIKeyResponse — описание ключа без секрета.
+type APIKeyResponse struct {
+	ID         string   `json:"id"`
+	Name       string   `json:"name"`
+	Prefix     string   `json:"prefix"`
+	Scopes     []string `json:"scopes"`
+	ExpiresAt  *string  `json:"expires_at"`
+	LastUsedAt *string  `json:"last_used_at"`
+	CreatedAt  string   `json:"created_at"`
+}
+
+// CreateAPIKeyResponse — ответ при выпуске ключа; сам ключ показывается один раз.
+type CreateAPIKeyResponse struct {
+	APIKeyResponse
+	Key string `json:"key"`
+}
@@ -1,22 +1,40 @@
 package app
 
 import (
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
 	"traindesk/internal/config"
 
 	"github.com/gin-gonic/gin"
 
 	"traindesk/internal/db"
 	"traindesk/internal/email"
+	"traindesk/internal/jwtkeys"
+	"traindesk/internal/throttle"
 )
 
 type App struct {
 	router *gin.Engine
 	db     *db.DB
 	mailer *email.Sender
+	keys   *jwtkeys.KeyRing
+
+	// accountLimiter считает неудачи по аккаунту, ipLimiter — по IP-адресу.
+	accountLimiter *throttle.Limiter
+	ipLimiter      *throttle.Limiter
 }
 
 func NewApp() (*App, error) {
 	r := gin.Default()
+	// Без списка прокси gin верит X-Forwarded-For от кого угодно,
+	// и лимит по IP обходится подменой заголовка.
+	if err := r.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		return nil, fmt.Errorf("invalid TRUSTED_PROXIES: %w", err)
+	}
 
 	database, err := db.NewDB()
 	if err != nil {
@@ -25,10 +43,29 @@ func NewApp() (*App, error) {
 
 	mailer := email.NewSender()
 
+	keys, err := jwtkeys.Load(cfg.JWTKeysDir, cfg.JWTActiveKID, []byte(cfg.JWTSecret))
+	if err != nil {
+		return nil, err
+	}
+
+	var store throttle.Store
+	switch cfg.ThrottleStore {
+	case "postgres":
+		store = throttle.NewPostgresStore(database.DB)
+	case "memory":
+		store = throttle.NewMemoryStore()
+	default:
+		return nil, fmt.Errorf("unknown THROTTLE_STORE %q", cfg.ThrottleStore)
+	}
+
 	a := &App{
 		router: r,
 		db:     database,
 		mailer: mailer,
+		keys:   keys,
+
+		accountLimiter: throttle.NewLimiter(store, accountThrottlePolicy),
+		ipLimiter:      throttle.NewLimiter(store, ipThrottlePolicy),
 	}
 
 	a.registerRoutes()
@@ -37,6 +74,24 @@ func NewApp() (*App, error) {
 }
 
 func (a *App) Run() error {
+	go a.reloadKeysOnSignal()
+	go a.runMaintenance()
+
 	cfg := config.Load()
 	return a.router.Run(":" + cfg.HTTPPort)
 }
+
+// reloadKeysOnSignal перечитывает ключи подписи JWT по SIGHUP,
+// чтобы ротация не требовала рестарта.
+func (a *App) reloadKeysOnSignal() {
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, syscall.SIGHUP)
+
+	for range ch {
+		if err := a.keys.Reload(); err != nil {
+			log.Printf("failed to reload jwt keys: %v", err)
+			continue
+		}
+		log.Println("jwt keys reloaded")
+	}
+}
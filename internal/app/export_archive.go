@@ -0,0 +1,303 @@
+package app
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"traindesk/internal/client"
+	"traindesk/internal/user"
+	"traindesk/internal/workout"
+)
+
+// buildExportArchive собирает ZIP со всеми данными тренера:
+// профиль, клиенты, тренировки с упражнениями, шаблоны тренировок, связи
+// тренировка–клиент и личные результаты участников в JSON и CSV.
+func (a *App) buildExportArchive(userID uuid.UUID) ([]byte, error) {
+	var u user.User
+	if err := a.db.Where("id = ?", userID).First(&u).Error; err != nil {
+		return nil, err
+	}
+
+	var clientsDB []client.Client
+	if err := a.db.Where("user_id = ?", userID).Order("last_name, first_name").Find(&clientsDB).Error; err != nil {
+		return nil, err
+	}
+
+	var workoutsDB []workout.Workout
+	if err := a.db.Where("user_id = ?", userID).Order("starts_at").Find(&workoutsDB).Error; err != nil {
+		return nil, err
+	}
+
+	workoutIDs := make([]uuid.UUID, 0, len(workoutsDB))
+	for _, w := range workoutsDB {
+		workoutIDs = append(workoutIDs, w.ID)
+	}
+
+	var links []workout.WorkoutClient
+	if len(workoutIDs) > 0 {
+		if err := a.db.Where("workout_id IN ?", workoutIDs).Find(&links).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	blocks := make(map[uuid.UUID][]workout.ExerciseBlockResponse)
+	if len(workoutIDs) > 0 {
+		var err error
+		if blocks, err = a.loadWorkoutBlocks(workoutIDs); err != nil {
+			return nil, err
+		}
+	}
+
+	clientIDs := make([]uuid.UUID, 0, len(clientsDB))
+	for _, cl := range clientsDB {
+		clientIDs = append(clientIDs, cl.ID)
+	}
+	tagsMap, err := a.loadClientTags(clientIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+
+	if err := writeZipJSON(zw, "profile.json", toProfileResponse(u)); err != nil {
+		return nil, err
+	}
+
+	clientsResp := make([]client.ClientResponse, 0, len(clientsDB))
+	for _, cl := range clientsDB {
+		clientsResp = append(clientsResp, toClientResponse(cl, tagsMap[cl.ID], true))
+	}
+	if err := writeZipJSON(zw, "clients.json", clientsResp); err != nil {
+		return nil, err
+	}
+	clientRows := [][]string{{
+		"id", "first_name", "last_name", "phone", "email", "birth_date", "gender",
+		"emergency_contact_name", "emergency_contact_phone", "injuries", "contraindications",
+		"goals", "notes", "tags", "archived_at", "created_at", "updated_at",
+	}}
+	for _, cl := range clientsDB {
+		birthDate := ""
+		if cl.BirthDate != nil {
+			birthDate = cl.BirthDate.Format("2006-01-02")
+		}
+		archivedAt := ""
+		if cl.ArchivedAt != nil {
+			archivedAt = cl.ArchivedAt.Format(time.RFC3339)
+		}
+		clientRows = append(clientRows, []string{
+			cl.ID.String(),
+			cl.FirstName,
+			cl.LastName,
+			cl.Phone,
+			cl.Email,
+			birthDate,
+			string(cl.Gender),
+			cl.EmergencyContactName,
+			cl.EmergencyContactPhone,
+			cl.Injuries,
+			cl.Contraindications,
+			cl.Goals,
+			cl.Notes,
+			strings.Join(tagsMap[cl.ID], ";"),
+			archivedAt,
+			cl.CreatedAt.Format(time.RFC3339),
+			cl.UpdatedAt.Format(time.RFC3339),
+		})
+	}
+	if err := writeZipCSV(zw, "clients.csv", clientRows); err != nil {
+		return nil, err
+	}
+
+	linksMap := make(map[uuid.UUID][]string)
+	for _, l := range links {
+		linksMap[l.WorkoutID] = append(linksMap[l.WorkoutID], l.ClientID.String())
+	}
+	workoutsResp := make([]workout.WorkoutResponse, 0, len(workoutsDB))
+	for _, w := range workoutsDB {
+		resp := toWorkoutResponse(w, linksMap[w.ID])
+		resp.Exercises = blocks[w.ID]
+		workoutsResp = append(workoutsResp, resp)
+	}
+	if err := writeZipJSON(zw, "workouts.json", workoutsResp); err != nil {
+		return nil, err
+	}
+
+	var templates []workout.Template
+	if err := a.db.Where("user_id = ?", userID).Order("name, id").Find(&templates).Error; err != nil {
+		return nil, err
+	}
+	templatesResp, err := a.templateResponses(templates)
+	if err != nil {
+		return nil, err
+	}
+	if err := writeZipJSON(zw, "workout_templates.json", templatesResp); err != nil {
+		return nil, err
+	}
+	workoutRows := [][]string{{
+		"id", "date", "starts_at", "ends_at", "all_day", "time_zone",
+		"duration_min", "type", "notes", "room", "series_id", "detached", "created_at", "updated_at",
+	}}
+	for _, w := range workoutsDB {
+		workoutRows = append(workoutRows, []string{
+			w.ID.String(),
+			w.LocalDate(),
+			w.StartsAt.UTC().Format(time.RFC3339),
+			w.EndsAt.UTC().Format(time.RFC3339),
+			strconv.FormatBool(w.AllDay),
+			w.TimeZone,
+			strconv.Itoa(w.DurationMin),
+			string(w.Type),
+			w.Notes,
+			w.Room,
+			formatOptUUID(w.SeriesID),
+			strconv.FormatBool(w.Detached),
+			w.CreatedAt.Format(time.RFC3339),
+			w.UpdatedAt.Format(time.RFC3339),
+		})
+	}
+	if err := writeZipCSV(zw, "workouts.csv", workoutRows); err != nil {
+		return nil, err
+	}
+
+	setRows := [][]string{{
+		"workout_id", "exercise_position", "exercise_id", "exercise_name", "set_position",
+		"reps", "weight_kg", "duration_sec", "distance_m", "rest_sec", "rpe",
+	}}
+	for _, w := range workoutsDB {
+		for i, b := range blocks[w.ID] {
+			for j, st := range b.Sets {
+				setRows = append(setRows, []string{
+					w.ID.String(),
+					strconv.Itoa(i + 1),
+					b.ExerciseID,
+					b.ExerciseName,
+					strconv.Itoa(j + 1),
+					formatOptInt(st.Reps),
+					formatOptFloat(st.WeightKg),
+					formatOptInt(st.DurationSec),
+					formatOptFloat(st.DistanceM),
+					formatOptInt(st.RestSec),
+					formatOptFloat(st.RPE),
+				})
+			}
+		}
+	}
+	if err := writeZipCSV(zw, "workout_sets.csv", setRows); err != nil {
+		return nil, err
+	}
+
+	linkRows := [][]string{{
+		"workout_id", "client_id", "attendance", "attendance_reason",
+		"booked_at", "attendance_marked_at", "rpe", "comment",
+	}}
+	for _, l := range links {
+		linkRows = append(linkRows, []string{
+			l.WorkoutID.String(),
+			l.ClientID.String(),
+			string(l.Attendance),
+			l.AttendanceReason,
+			formatOptTime(l.BookedAt),
+			formatOptTime(l.AttendanceMarkedAt),
+			formatOptFloat(l.RPE),
+			l.Comment,
+		})
+	}
+	if err := writeZipCSV(zw, "workout_clients.csv", linkRows); err != nil {
+		return nil, err
+	}
+
+	var results []workout.ClientSetResult
+	if len(workoutIDs) > 0 {
+		if err := a.db.Where("workout_id IN ?", workoutIDs).
+			Order("workout_id, client_id, block_position, set_position").
+			Find(&results).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	resultRows := [][]string{{
+		"workout_id", "client_id", "exercise_position", "exercise_id", "set_position",
+		"reps", "weight_kg", "duration_sec", "distance_m",
+	}}
+	for _, r := range results {
+		resultRows = append(resultRows, []string{
+			r.WorkoutID.String(),
+			r.ClientID.String(),
+			strconv.Itoa(r.BlockPosition + 1),
+			r.ExerciseID.String(),
+			strconv.Itoa(r.SetPosition + 1),
+			formatOptInt(r.Reps),
+			formatOptFloat(r.WeightKg),
+			formatOptInt(r.DurationSec),
+			formatOptFloat(r.DistanceM),
+		})
+	}
+	if err := writeZipCSV(zw, "client_set_results.csv", resultRows); err != nil {
+		return nil, err
+	}
+
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func writeZipJSON(zw *zip.Writer, name string, v interface{}) error {
+	f, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func writeZipCSV(zw *zip.Writer, name string, rows [][]string) error {
+	f, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+
+	w := csv.NewWriter(f)
+	if err := w.WriteAll(rows); err != nil {
+		return err
+	}
+	return w.Error()
+}
+
+func formatOptInt(v *int) string {
+	if v == nil {
+		return ""
+	}
+	return strconv.Itoa(*v)
+}
+
+func formatOptFloat(v *float64) string {
+	if v == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*v, 'f', -1, 64)
+}
+
+func formatOptTime(v *time.Time) string {
+	if v == nil {
+		return ""
+	}
+	return v.UTC().Format(time.RFC3339)
+}
+
+func formatOptUUID(v *uuid.UUID) string {
+	if v == nil {
+		return ""
+	}
+	return v.String()
+}
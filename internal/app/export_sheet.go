@@ -0,0 +1,106 @@
+package app
+
+import (
+	"encoding/csv"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"traindesk/internal/xlsx"
+)
+
+// sheetWriter — построчная запись таблицы выгрузки в CSV или XLSX.
+type sheetWriter interface {
+	WriteRow(cells []xlsx.Cell) error
+	Close() error
+}
+
+// csvSheet пишет таблицу в CSV. Файл начинается с BOM, чтобы Excel
+// распознал UTF-8 и кириллица открывалась без «кракозябр».
+type csvSheet struct {
+	w *csv.Writer
+}
+
+func newCSVSheet(w io.Writer) (*csvSheet, error) {
+	if _, err := io.WriteString(w, "\ufeff"); err != nil {
+		return nil, err
+	}
+	return &csvSheet{w: csv.NewWriter(w)}, nil
+}
+
+func (s *csvSheet) WriteRow(cells []xlsx.Cell) error {
+	rec := make([]string, len(cells))
+	for i, cell := range cells {
+		rec[i] = csvCell(cell)
+	}
+	return s.w.Write(rec)
+}
+
+func (s *csvSheet) Close() error {
+	s.w.Flush()
+	return s.w.Error()
+}
+
+// csvCell — текст ячейки. Строки, которые табличный редактор принял бы
+// за формулу, экранируются апострофом.
+func csvCell(cell xlsx.Cell) string {
+	switch cell.Kind {
+	case xlsx.KindString:
+		if cell.String != "" && strings.ContainsRune("=+-@\t\r", rune(cell.String[0])) {
+			return "'" + cell.String
+		}
+		return cell.String
+	case xlsx.KindNumber:
+		return strconv.FormatFloat(cell.Number, 'f', -1, 64)
+	case xlsx.KindDate:
+		return cell.Time.Format("2006-01-02")
+	case xlsx.KindDateTime:
+		return cell.Time.Format("2006-01-02 15:04")
+	}
+	return ""
+}
+
+// exportFormats — форматы выгрузки таблиц.
+var exportFormats = []string{"csv", "xlsx"}
+
+// parseExportFormat читает параметр format (по умолчанию csv); при ошибке сам отвечает.
+func parseExportFormat(c *gin.Context) (string, bool) {
+	format := c.DefaultQuery("format", "csv")
+	for _, f := range exportFormats {
+		if format == f {
+			return format, true
+		}
+	}
+	c.JSON(http.StatusBadRequest, gin.H{
+		"error":           "invalid format",
+		"allowed_formats": exportFormats,
+	})
+	return "", false
+}
+
+// startSheet отправляет заголовки ответа и начинает таблицу с именем name
+// (оно же — имя файла и листа). После этого ответить JSON уже нельзя.
+func startSheet(c *gin.Context, format, name string) (sheetWriter, error) {
+	filename := name + "." + format
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	c.Header("Cache-Control", "no-store")
+	if format == "xlsx" {
+		c.Header("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+		c.Status(http.StatusOK)
+		return xlsx.NewWriter(c.Writer, name)
+	}
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Status(http.StatusOK)
+	return newCSVSheet(c.Writer)
+}
+
+// optStr — текстовая ячейка или пустая, если строки нет.
+func optStr(s string) xlsx.Cell {
+	if s == "" {
+		return xlsx.Empty
+	}
+	return xlsx.Str(s)
+}
@@ -0,0 +1,246 @@
+package app
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+
+	"traindesk/internal/apikey"
+	"traindesk/internal/user"
+)
+
+const (
+	dataExportTTL        = 7 * 24 * time.Hour
+	accountDeletionGrace = 30 * 24 * time.Hour
+)
+
+func toDataExportResponse(e user.DataExport) user.DataExportResponse {
+	resp := user.DataExportResponse{
+		ID:        e.ID.String(),
+		Status:    e.Status,
+		Error:     e.Error,
+		CreatedAt: e.CreatedAt.Format(time.RFC3339),
+		ExpiresAt: e.ExpiresAt.Format(time.RFC3339),
+	}
+	if e.CompletedAt != nil {
+		s := e.CompletedAt.Format(time.RFC3339)
+		resp.CompletedAt = &s
+	}
+	return resp
+}
+
+// handleCreateDataExport — поставить задачу на выгрузку всех данных тренера.
+// Архив собирается в фоне; статус смотрим через GET /me/exports/:id.
+func (a *App) handleCreateDataExport(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var pending int64
+	if err := a.db.Model(&user.DataExport{}).
+		Where("user_id = ? AND status = ?", userID, user.ExportStatusPending).
+		Count(&pending).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to check exports"})
+		return
+	}
+	if pending > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "an export is already in progress"})
+		return
+	}
+
+	e := user.DataExport{
+		ID:        uuid.New(),
+		UserID:    userID,
+		Status:    user.ExportStatusPending,
+		ExpiresAt: time.Now().Add(dataExportTTL),
+	}
+	if err := a.db.Create(&e).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create export"})
+		return
+	}
+
+	go a.runDataExport(e.ID, userID)
+
+	c.JSON(http.StatusAccepted, toDataExportResponse(e))
+}
+
+// runDataExport собирает архив и сохраняет результат в задаче.
+func (a *App) runDataExport(exportID, userID uuid.UUID) {
+	archive, err := a.buildExportArchive(userID)
+
+	updates := map[string]interface{}{"completed_at": time.Now()}
+	if err != nil {
+		log.Printf("data export %s failed: %v", exportID, err)
+		updates["status"] = user.ExportStatusFailed
+		updates["error"] = "failed to build archive"
+	} else {
+		updates["status"] = user.ExportStatusReady
+		updates["archive"] = archive
+	}
+
+	if err := a.db.Model(&user.DataExport{}).Where("id = ?", exportID).Updates(updates).Error; err != nil {
+		log.Printf("failed to save data export %s: %v", exportID, err)
+	}
+}
+
+// handleGetDataExports — список выгрузок текущего тренера.
+func (a *App) handleGetDataExports(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var exports []user.DataExport
+	if err := a.db.Omit("archive").Where("user_id = ?", userID).Order("created_at desc").Find(&exports).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load exports"})
+		return
+	}
+
+	resp := make([]user.DataExportResponse, 0, len(exports))
+	for _, e := range exports {
+		resp = append(resp, toDataExportResponse(e))
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// loadDataExport находит выгрузку текущего тренера по :id; при ошибке сам отвечает.
+func (a *App) loadDataExport(c *gin.Context, withArchive bool) (user.DataExport, bool) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return user.DataExport{}, false
+	}
+
+	exportID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid export id"})
+		return user.DataExport{}, false
+	}
+
+	q := a.db.Where("id = ? AND user_id = ?", exportID, userID)
+	if !withArchive {
+		q = q.Omit("archive")
+	}
+
+	var e user.DataExport
+	if err := q.First(&e).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "export not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load export"})
+		}
+		return user.DataExport{}, false
+	}
+
+	return e, true
+}
+
+// handleGetDataExport — статус одной выгрузки.
+func (a *App) handleGetDataExport(c *gin.Context) {
+	e, ok := a.loadDataExport(c, false)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, toDataExportResponse(e))
+}
+
+// handleDownloadDataExport — скачать готовый ZIP-архив.
+func (a *App) handleDownloadDataExport(c *gin.Context) {
+	e, ok := a.loadDataExport(c, true)
+	if !ok {
+		return
+	}
+
+	if e.Status != user.ExportStatusReady {
+		c.JSON(http.StatusConflict, gin.H{"error": "export is not ready", "status": e.Status})
+		return
+	}
+
+	filename := "traindesk-export-" + e.CreatedAt.Format("2006-01-02") + ".zip"
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	c.Data(http.StatusOK, "application/zip", e.Archive)
+}
+
+// handleScheduleAccountDeletion — запланировать удаление аккаунта.
+// Данные удаляются после льготного периода. Сессии и API-ключи гасятся сразу,
+// но войти и отменить удаление до его наступления можно.
+func (a *App) handleScheduleAccountDeletion(c *gin.Context) {
+	u, ok := a.loadCurrentUser(c)
+	if !ok {
+		return
+	}
+
+	var req user.DeleteAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.Password == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "password is required"})
+		return
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(u.PasswordHash), []byte(req.Password)); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid password"})
+		return
+	}
+
+	// Организацию с другими участниками нельзя оставить без владельца.
+	orgIDs, err := soleOwnedSharedOrgs(a.db.DB, u.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to check organizations"})
+		return
+	}
+	if len(orgIDs) > 0 {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":            "transfer ownership of your organizations before deleting the account",
+			"organization_ids": orgIDs,
+		})
+		return
+	}
+
+	if u.DeletionScheduledAt == nil {
+		at := time.Now().Add(accountDeletionGrace)
+		u.DeletionScheduledAt = &at
+	}
+
+	err = a.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&u).Update("deletion_scheduled_at", u.DeletionScheduledAt).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", u.ID).Delete(&apikey.APIKey{}).Error; err != nil {
+			return err
+		}
+		return revokeUserSessions(tx, u.ID)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to schedule deletion"})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, toProfileResponse(u))
+}
+
+// handleCancelAccountDeletion — отменить запланированное удаление аккаунта.
+func (a *App) handleCancelAccountDeletion(c *gin.Context) {
+	u, ok := a.loadCurrentUser(c)
+	if !ok {
+		return
+	}
+
+	if u.DeletionScheduledAt == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "account deletion is not scheduled"})
+		return
+	}
+
+	if err := a.db.Model(&u).Update("deletion_scheduled_at", nil).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to cancel deletion"})
+		return
+	}
+	u.DeletionScheduledAt = nil
+
+	c.JSON(http.StatusOK, toProfileResponse(u))
+}
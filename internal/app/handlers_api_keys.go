@@ -0,0 +1,208 @@
+package app
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"traindesk/internal/apikey"
+)
+
+const apiKeyPrefix = "td_"
+
+// generateAPIKey возвращает новый ключ и его публичный префикс. Префикс
+// уникален в БД, поэтому он достаточно длинный, чтобы не совпадать случайно.
+func generateAPIKey() (key, prefix string, err error) {
+	var b [8]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", "", err
+	}
+	prefix = hex.EncodeToString(b[:])
+
+	secret, err := generateToken()
+	if err != nil {
+		return "", "", err
+	}
+
+	return apiKeyPrefix + prefix + "_" + secret, prefix, nil
+}
+
+// parseAPIKeyPrefix достаёт префикс из предъявленного ключа.
+func parseAPIKeyPrefix(key string) (string, bool) {
+	if !strings.HasPrefix(key, apiKeyPrefix) {
+		return "", false
+	}
+	prefix, _, ok := strings.Cut(strings.TrimPrefix(key, apiKeyPrefix), "_")
+	return prefix, ok && prefix != ""
+}
+
+func toAPIKeyResponse(k apikey.APIKey) apikey.APIKeyResponse {
+	resp := apikey.APIKeyResponse{
+		ID:        k.ID.String(),
+		Name:      k.Name,
+		Prefix:    apiKeyPrefix + k.Prefix,
+		Scopes:    k.ScopeList(),
+		CreatedAt: k.CreatedAt.Format(time.RFC3339),
+	}
+	if k.ExpiresAt != nil {
+		s := k.ExpiresAt.Format(time.RFC3339)
+		resp.ExpiresAt = &s
+	}
+	if k.LastUsedAt != nil {
+		s := k.LastUsedAt.Format(time.RFC3339)
+		resp.LastUsedAt = &s
+	}
+	return resp
+}
+
+// handleCreateAPIKey — выпустить новый API-ключ с заданными правами.
+func (a *App) handleCreateAPIKey(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var req apikey.CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid JSON"})
+		return
+	}
+
+	if req.Name == "" || len(req.Scopes) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "name and scopes are required"})
+		return
+	}
+
+	for _, s := range req.Scopes {
+		if !apikey.IsValidScope(s) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":          "invalid scope: " + s,
+				"allowed_scopes": apikey.ValidScopes,
+			})
+			return
+		}
+	}
+
+	var expiresAt *time.Time
+	if req.ExpiresAt != nil {
+		t, err := time.Parse(time.RFC3339, *req.ExpiresAt)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid expires_at, expected RFC 3339"})
+			return
+		}
+		if !t.After(time.Now()) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "expires_at must be in the future"})
+			return
+		}
+		expiresAt = &t
+	}
+
+	key, prefix, err := generateAPIKey()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate api key"})
+		return
+	}
+
+	k := apikey.APIKey{
+		ID:        uuid.New(),
+		UserID:    userID,
+		Name:      req.Name,
+		Prefix:    prefix,
+		KeyHash:   hashToken(key),
+		Scopes:    strings.Join(req.Scopes, " "),
+		ExpiresAt: expiresAt,
+	}
+
+	if err := a.db.Create(&k).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create api key"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, apikey.CreateAPIKeyResponse{
+		APIKeyResponse: toAPIKeyResponse(k),
+		Key:            key,
+	})
+}
+
+// handleGetAPIKeys — список API-ключей текущего тренера.
+func (a *App) handleGetAPIKeys(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var keys []apikey.APIKey
+	if err := a.db.Where("user_id = ?", userID).Order("created_at desc").Find(&keys).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load api keys"})
+		return
+	}
+
+	resp := make([]apikey.APIKeyResponse, 0, len(keys))
+	for _, k := range keys {
+		resp = append(resp, toAPIKeyResponse(k))
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// handleDeleteAPIKey — отозвать API-ключ.
+func (a *App) handleDeleteAPIKey(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	keyID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid api key id"})
+		return
+	}
+
+	res := a.db.Where("id = ? AND user_id = ?", keyID, userID).Delete(&apikey.APIKey{})
+	if res.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete api key"})
+		return
+	}
+	if res.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "api key not found"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// authenticateAPIKey проверяет ключ из заголовка "Authorization: ApiKey ...".
+func (a *App) authenticateAPIKey(key string) (apikey.APIKey, bool, error) {
+	prefix, ok := parseAPIKeyPrefix(key)
+	if !ok {
+		return apikey.APIKey{}, false, nil
+	}
+
+	var k apikey.APIKey
+	err := a.db.Where("prefix = ?", prefix).First(&k).Error
+	if err == gorm.ErrRecordNotFound {
+		return apikey.APIKey{}, false, nil
+	}
+	if err != nil {
+		return apikey.APIKey{}, false, err
+	}
+
+	if subtle.ConstantTimeCompare([]byte(hashToken(key)), []byte(k.KeyHash)) != 1 || k.IsExpired(time.Now()) {
+		return apikey.APIKey{}, false, nil
+	}
+
+	// last_used_at обновляем не чаще раза в минуту, чтобы не писать в БД на каждый запрос.
+	now := time.Now()
+	if k.LastUsedAt == nil || now.Sub(*k.LastUsedAt) > time.Minute {
+		a.db.Model(&k).Update("last_used_at", now)
+	}
+
+	return k, true, nil
+}
@@ -0,0 +1,325 @@
+package app
+
+import (
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"traindesk/internal/org"
+	"traindesk/internal/tz"
+	"traindesk/internal/workout"
+)
+
+// attendancePeriodDays — период статистики посещаемости по умолчанию.
+const attendancePeriodDays = 90
+
+// markAttendance ставит статус посещения участникам тренировки.
+// Возврат в booked снимает отметку вместе с причиной.
+func markAttendance(tx *gorm.DB, workoutID uuid.UUID, clientIDs []uuid.UUID, status workout.AttendanceStatus, reason string, markedBy uuid.UUID) error {
+	if len(clientIDs) == 0 {
+		return nil
+	}
+
+	updates := map[string]interface{}{
+		"attendance":           status,
+		"attendance_reason":    reason,
+		"attendance_marked_at": time.Now(),
+		"attendance_marked_by": markedBy,
+	}
+	if status == workout.AttendanceBooked {
+		updates["attendance_reason"] = ""
+		updates["attendance_marked_at"] = nil
+		updates["attendance_marked_by"] = nil
+	}
+
+	return tx.Model(&workout.WorkoutClient{}).
+		Where("workout_id = ? AND client_id IN ?", workoutID, clientIDs).
+		Updates(updates).Error
+}
+
+// handleMarkAttendance — отметить посещение сразу нескольких участников после тренировки.
+// Отметки применяются целиком или не применяются вовсе.
+func (a *App) handleMarkAttendance(c *gin.Context) {
+	m, ok := currentMember(c)
+	if !ok {
+		return
+	}
+
+	w, ok := a.loadWorkout(c, m)
+	if !ok {
+		return
+	}
+	if !canEditWorkout(m, w) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "not allowed to edit this workout"})
+		return
+	}
+
+	var req workout.MarkAttendanceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid JSON"})
+		return
+	}
+	if req.Default == "" && len(req.Marks) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "marks or default is required"})
+		return
+	}
+	if req.Default != "" {
+		if err := workout.ValidateAttendance(req.Default, ""); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":            err.Error(),
+				"allowed_statuses": workout.ValidAttendanceStatuses,
+			})
+			return
+		}
+	}
+
+	var participantIDs []uuid.UUID
+	if err := a.db.Model(&workout.WorkoutClient{}).Where("workout_id = ?", w.ID).
+		Pluck("client_id", &participantIDs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load participants"})
+		return
+	}
+	isParticipant := make(map[uuid.UUID]bool, len(participantIDs))
+	for _, id := range participantIDs {
+		isParticipant[id] = true
+	}
+
+	marked := make(map[uuid.UUID]bool, len(req.Marks))
+	markIDs := make([]uuid.UUID, 0, len(req.Marks))
+	for _, mark := range req.Marks {
+		clientID, err := uuid.Parse(mark.ClientID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid client_id: " + mark.ClientID})
+			return
+		}
+		if !isParticipant[clientID] {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "client is not a participant of this workout: " + mark.ClientID})
+			return
+		}
+		if marked[clientID] {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "duplicate client_id: " + mark.ClientID})
+			return
+		}
+		if err := workout.ValidateAttendance(mark.Status, mark.Reason); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":            err.Error(),
+				"allowed_statuses": workout.ValidAttendanceStatuses,
+			})
+			return
+		}
+		marked[clientID] = true
+		markIDs = append(markIDs, clientID)
+	}
+
+	err := a.db.Transaction(func(tx *gorm.DB) error {
+		for i, mark := range req.Marks {
+			status := workout.AttendanceStatus(mark.Status)
+			if err := markAttendance(tx, w.ID, markIDs[i:i+1], status, mark.Reason, m.UserID); err != nil {
+				return err
+			}
+		}
+
+		if req.Default == "" {
+			return nil
+		}
+		var rest []uuid.UUID
+		for _, id := range participantIDs {
+			if !marked[id] {
+				rest = append(rest, id)
+			}
+		}
+		return markAttendance(tx, w.ID, rest, workout.AttendanceStatus(req.Default), "", m.UserID)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to mark attendance"})
+		return
+	}
+
+	participants, err := a.loadParticipants([]uuid.UUID{w.ID}, nil)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load participants"})
+		return
+	}
+
+	resp := participants[w.ID]
+	if resp == nil {
+		resp = []workout.ParticipantResponse{}
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// parseAttendancePeriod читает from и to (YYYY-MM-DD, включительно).
+// По умолчанию — последние attendancePeriodDays дней по часам пояса loc;
+// при ошибке сам отвечает.
+func parseAttendancePeriod(c *gin.Context, loc *time.Location) (time.Time, time.Time, bool) {
+	now := time.Now().In(loc)
+	to := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	from := to.AddDate(0, 0, -(attendancePeriodDays - 1))
+
+	for _, f := range []struct {
+		param string
+		dst   *time.Time
+	}{
+		{"from", &from},
+		{"to", &to},
+	} {
+		v := c.Query(f.param)
+		if v == "" {
+			continue
+		}
+		d, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "invalid " + f.param + ", expected YYYY-MM-DD",
+			})
+			return time.Time{}, time.Time{}, false
+		}
+		*f.dst = d
+	}
+
+	if from.After(to) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from must not be after to"})
+		return time.Time{}, time.Time{}, false
+	}
+	return from, to, true
+}
+
+// attendanceStats считает посещаемость по клиентам за период среди видимых
+// участнику тренировок. Даты периода понимаются в поясе loc.
+// Если clientID задан — только для этого клиента.
+func (a *App) attendanceStats(m member, from, to time.Time, loc *time.Location, clientID *uuid.UUID) ([]workout.AttendanceStats, error) {
+	var rows []struct {
+		ClientID      uuid.UUID
+		FirstName     string
+		LastName      string
+		Total         int
+		Booked        int
+		Attended      int
+		NoShow        int
+		CancelledLate int
+		Cancelled     int
+	}
+
+	q := a.db.Table("workout_clients").
+		Select(`workout_clients.client_id, clients.first_name, clients.last_name,
+			count(*) AS total,
+			count(*) FILTER (WHERE workout_clients.attendance = ?) AS booked,
+			count(*) FILTER (WHERE workout_clients.attendance = ?) AS attended,
+			count(*) FILTER (WHERE workout_clients.attendance = ?) AS no_show,
+			count(*) FILTER (WHERE workout_clients.attendance = ?) AS cancelled_late,
+			count(*) FILTER (WHERE workout_clients.attendance = ?) AS cancelled`,
+			workout.AttendanceBooked, workout.AttendanceAttended, workout.AttendanceNoShow,
+			workout.AttendanceCancelledLate, workout.AttendanceCancelled).
+		Joins("JOIN workouts ON workouts.id = workout_clients.workout_id").
+		Joins("JOIN clients ON clients.id = workout_clients.client_id")
+	q = whereWorkoutStart(q, ">=", from, loc)
+	q = whereWorkoutStart(q, "<", to.AddDate(0, 0, 1), loc)
+	if clientID != nil {
+		q = q.Where("workout_clients.client_id = ?", *clientID)
+	}
+	q = scopeWorkouts(q, m).
+		Group("workout_clients.client_id, clients.first_name, clients.last_name").
+		Order("clients.last_name, clients.first_name")
+	if err := q.Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	stats := make([]workout.AttendanceStats, 0, len(rows))
+	for _, r := range rows {
+		s := workout.AttendanceStats{
+			ClientID:      r.ClientID.String(),
+			FirstName:     r.FirstName,
+			LastName:      r.LastName,
+			Total:         r.Total,
+			Booked:        r.Booked,
+			Attended:      r.Attended,
+			NoShow:        r.NoShow,
+			CancelledLate: r.CancelledLate,
+			Cancelled:     r.Cancelled,
+		}
+		if counted := r.Attended + r.NoShow + r.CancelledLate; counted > 0 {
+			rate := math.Round(float64(r.Attended)/float64(counted)*1000) / 1000
+			s.Rate = &rate
+		}
+		stats = append(stats, s)
+	}
+	return stats, nil
+}
+
+// handleGetAttendanceReport — посещаемость всех клиентов, у которых были
+// записи за период. Параметры: from и to (YYYY-MM-DD), по умолчанию — 90 дней.
+func (a *App) handleGetAttendanceReport(c *gin.Context) {
+	m, ok := currentMember(c)
+	if !ok {
+		return
+	}
+	if !requirePermission(c, m, org.PermClientsRead) || !requirePermission(c, m, org.PermWorkoutsReadOwn) {
+		return
+	}
+
+	loc := tz.LoadOrUTC(a.userTimeZone(m.UserID))
+	from, to, ok := parseAttendancePeriod(c, loc)
+	if !ok {
+		return
+	}
+
+	stats, err := a.attendanceStats(m, from, to, loc, nil)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to compute attendance"})
+		return
+	}
+
+	c.JSON(http.StatusOK, workout.AttendanceReport{
+		From:    from.Format("2006-01-02"),
+		To:      to.Format("2006-01-02"),
+		Clients: stats,
+	})
+}
+
+// handleGetClientAttendance — посещаемость одного клиента за период.
+func (a *App) handleGetClientAttendance(c *gin.Context) {
+	m, ok := currentMember(c)
+	if !ok {
+		return
+	}
+	if !requirePermission(c, m, org.PermClientsRead) || !requirePermission(c, m, org.PermWorkoutsReadOwn) {
+		return
+	}
+
+	cl, ok := a.loadClient(c, m)
+	if !ok {
+		return
+	}
+
+	loc := tz.LoadOrUTC(a.userTimeZone(m.UserID))
+	from, to, ok := parseAttendancePeriod(c, loc)
+	if !ok {
+		return
+	}
+
+	stats, err := a.attendanceStats(m, from, to, loc, &cl.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to compute attendance"})
+		return
+	}
+
+	resp := workout.ClientAttendance{
+		From: from.Format("2006-01-02"),
+		To:   to.Format("2006-01-02"),
+		AttendanceStats: workout.AttendanceStats{
+			ClientID:  cl.ID.String(),
+			FirstName: cl.FirstName,
+			LastName:  cl.LastName,
+		},
+	}
+	if len(stats) > 0 {
+		resp.AttendanceStats = stats[0]
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
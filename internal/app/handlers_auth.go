@@ -2,22 +2,32 @@ package app
 
 import (
 	"crypto/rand"
+	"crypto/subtle"
 	"fmt"
+	"log"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
 
 	"traindesk/internal/config"
+	"traindesk/internal/org"
+	"traindesk/internal/tz"
 	"traindesk/internal/user"
 )
 
 var cfg = config.Load()
-var jwtSecret = []byte(cfg.JWTSecret)
 
-const jwtTTL = 7 * 24 * time.Hour
+const (
+	verificationTTL            = 24 * time.Hour
+	verificationResendCooldown = time.Minute
+	verificationMaxOutstanding = 5
+	verificationMaxAttempts    = 5
+)
 
 // generateVerifyCode генерирует короткий код подтверждения почты.
 func generateVerificationCode() (string, error) {
@@ -49,6 +59,15 @@ func (a *App) handleRegister(c *gin.Context) {
 		return
 	}
 
+	req.TimeZone = strings.TrimSpace(req.TimeZone)
+	if req.TimeZone == "" {
+		req.TimeZone = tz.Default
+	}
+	if _, err := tz.Load(req.TimeZone); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "time_zone: " + err.Error()})
+		return
+	}
+
 	// Хешируем пароль (bcrypt, cost >= 10).
 	hash, err := bcrypt.GenerateFromPassword([]byte(req.Password), 10)
 	if err != nil {
@@ -64,20 +83,32 @@ func (a *App) handleRegister(c *gin.Context) {
 	}
 
 	u := user.User{
+		ID:            uuid.New(),
 		Email:         req.Email,
 		PasswordHash:  string(hash),
 		TrainerName:   req.TrainerName,
+		TimeZone:      req.TimeZone,
 		EmailVerified: false,
 	}
 
 	verification := user.EmailVerification{
+		ID:        uuid.New(),
 		UserID:    u.ID,
 		Code:      code,
-		ExpiresAt: time.Now().Add(24 * time.Hour),
+		ExpiresAt: time.Now().Add(verificationTTL),
 	}
-	a.db.Create(&verification)
 
-	if err := a.db.Create(&u).Error; err != nil {
+	// Пользователь, его личная организация и код создаются вместе.
+	err = a.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&u).Error; err != nil {
+			return err
+		}
+		if _, err := org.CreatePersonal(tx, u.ID, u.TrainerName); err != nil {
+			return err
+		}
+		return tx.Create(&verification).Error
+	})
+	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error":   "cannot create user (maybe email is already taken)",
 			"details": err.Error(),
@@ -85,6 +116,11 @@ func (a *App) handleRegister(c *gin.Context) {
 		return
 	}
 
+	// Письмо не критично для регистрации: при сбое код можно запросить повторно.
+	if err := a.mailer.SendVerificationEmail(u.Email, code); err != nil {
+		log.Printf("failed to send verification email to %s: %v", u.Email, err)
+	}
+
 	resp := user.RegisterResponse{
 		ID:          u.ID.String(),
 		Email:       u.Email,
@@ -108,8 +144,14 @@ func (a *App) handleLogin(c *gin.Context) {
 		return
 	}
 
+	keys := a.throttleKeys(c, "login", req.Email)
+	if !a.allowAttempt(c, keys) {
+		return
+	}
+
 	var u user.User
 	if err := a.db.Where("email = ?", req.Email).First(&u).Error; err != nil {
+		a.recordFailure(c, keys)
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid email or password"})
 		return
 	}
@@ -120,29 +162,45 @@ func (a *App) handleLogin(c *gin.Context) {
 	}
 
 	if err := bcrypt.CompareHashAndPassword([]byte(u.PasswordHash), []byte(req.Password)); err != nil {
+		a.recordFailure(c, keys)
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid email or password"})
 		return
 	}
-
-	now := time.Now()
-	claims := jwt.MapClaims{
-		"sub": u.ID.String(),
-		"exp": now.Add(jwtTTL).Unix(),
-		"iat": now.Unix(),
+	a.resetAttempts(c, keys)
+
+	if u.TOTPEnabled {
+		mfaToken, err := a.newMFAToken(u.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate token"})
+			return
+		}
+
+		c.JSON(http.StatusOK, user.MFAChallengeResponse{
+			MFARequired: true,
+			MFAToken:    mfaToken,
+			ExpiresIn:   int(mfaTokenTTL.Seconds()),
+		})
+		return
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, err := token.SignedString(jwtSecret)
+	a.respondLogin(c, u)
+}
+
+// respondLogin открывает сессию и отдаёт ответ успешного логина.
+func (a *App) respondLogin(c *gin.Context, u user.User) {
+	tokens, err := a.issueTokens(c, u.ID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate token"})
 		return
 	}
 
 	resp := user.LoginResponse{
-		Token:       tokenString,
-		ID:          u.ID.String(),
-		Email:       u.Email,
-		TrainerName: u.TrainerName,
+		Token:        tokens.Token,
+		RefreshToken: tokens.RefreshToken,
+		ExpiresIn:    tokens.ExpiresIn,
+		ID:           u.ID.String(),
+		Email:        u.Email,
+		TrainerName:  u.TrainerName,
 	}
 
 	c.JSON(http.StatusOK, resp)
@@ -156,19 +214,47 @@ func (a *App) handleVerifyEmail(c *gin.Context) {
 		return
 	}
 
+	keys := a.throttleKeys(c, "verify-email", req.Email)
+	if !a.allowAttempt(c, keys) {
+		return
+	}
+
 	var u user.User
 	if err := a.db.Where("email = ?", req.Email).First(&u).Error; err != nil {
+		a.recordFailure(c, keys)
 		c.JSON(http.StatusBadRequest, gin.H{"error": "user_not_found"})
 		return
 	}
 
-	var v user.EmailVerification
-	if err := a.db.Where("user_id = ? AND code = ?", u.ID, req.Code).First(&v).Error; err != nil {
+	var codes []user.EmailVerification
+	if err := a.db.Where("user_id = ?", u.ID).Find(&codes).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed_to_verify"})
+		return
+	}
+
+	now := time.Now()
+	var v *user.EmailVerification
+	for i := range codes {
+		if subtle.ConstantTimeCompare([]byte(codes[i].Code), []byte(req.Code)) == 1 {
+			v = &codes[i]
+			break
+		}
+	}
+
+	if v == nil {
+		a.recordFailure(c, keys)
+		// Каждая неверная попытка расходует все действующие коды пользователя;
+		// код, исчерпавший лимит, сжигается.
+		a.db.Model(&user.EmailVerification{}).
+			Where("user_id = ? AND expires_at > ?", u.ID, now).
+			Update("attempts", gorm.Expr("attempts + 1"))
+		a.db.Where("user_id = ? AND attempts >= ?", u.ID, verificationMaxAttempts).
+			Delete(&user.EmailVerification{})
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_code"})
 		return
 	}
 
-	if time.Now().After(v.ExpiresAt) {
+	if now.After(v.ExpiresAt) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "code_expired"})
 		return
 	}
@@ -178,7 +264,177 @@ func (a *App) handleVerifyEmail(c *gin.Context) {
 		return
 	}
 
-	a.db.Delete(&v)
+	a.db.Where("user_id = ?", u.ID).Delete(&user.EmailVerification{})
+	a.resetAttempts(c, keys)
 
 	c.JSON(http.StatusOK, gin.H{"message": "email_verified"})
 }
+
+// handleResendVerification — повторно выслать код подтверждения почты.
+// Между отправками выдерживается пауза, а число действующих кодов ограничено.
+func (a *App) handleResendVerification(c *gin.Context) {
+	var req user.ResendVerificationRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.Email == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "email is required"})
+		return
+	}
+
+	resp := gin.H{"message": "if the account exists and is not verified, a new code has been sent"}
+
+	var u user.User
+	if err := a.db.Where("email = ?", req.Email).First(&u).Error; err != nil || u.EmailVerified {
+		c.JSON(http.StatusOK, resp)
+		return
+	}
+
+	now := time.Now()
+
+	var outstanding []user.EmailVerification
+	if err := a.db.
+		Where("user_id = ? AND expires_at > ?", u.ID, now).
+		Order("created_at asc").
+		Find(&outstanding).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load verification codes"})
+		return
+	}
+
+	if n := len(outstanding); n > 0 {
+		if wait := outstanding[n-1].CreatedAt.Add(verificationResendCooldown).Sub(now); wait > 0 {
+			abortTooManyRequests(c, wait, "verification code was sent recently, try again later")
+			return
+		}
+		if n >= verificationMaxOutstanding {
+			abortTooManyRequests(c, outstanding[0].ExpiresAt.Sub(now), "too many verification codes requested")
+			return
+		}
+	}
+
+	code, err := generateVerificationCode()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate verify code"})
+		return
+	}
+
+	verification := user.EmailVerification{
+		ID:        uuid.New(),
+		UserID:    u.ID,
+		Code:      code,
+		ExpiresAt: now.Add(verificationTTL),
+	}
+	if err := a.db.Create(&verification).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create verify code"})
+		return
+	}
+
+	if err := a.mailer.SendVerificationEmail(u.Email, code); err != nil {
+		log.Printf("failed to send verification email to %s: %v", u.Email, err)
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// handleRefresh — ротация refresh-токена: старый гасится, выдаётся новая пара.
+// Повторное предъявление уже ротированного токена считается кражей
+// и отзывает всё семейство сессий.
+func (a *App) handleRefresh(c *gin.Context) {
+	var req user.RefreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.RefreshToken == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "refresh_token is required"})
+		return
+	}
+
+	var s user.Session
+	if err := a.db.Where("token_hash = ?", hashToken(req.RefreshToken)).First(&s).Error; err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid refresh token"})
+		return
+	}
+
+	if s.RevokedAt != nil || time.Now().After(s.ExpiresAt) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "refresh token expired or revoked"})
+		return
+	}
+
+	if s.RotatedAt != nil {
+		_ = revokeSessionFamily(a.db.DB, s.FamilyID)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "refresh token reuse detected, session revoked"})
+		return
+	}
+
+	var refreshToken string
+	reused := false
+	err := a.db.Transaction(func(tx *gorm.DB) error {
+		// Условный UPDATE защищает от гонки двух параллельных refresh с одним токеном.
+		res := tx.Model(&user.Session{}).
+			Where("id = ? AND rotated_at IS NULL AND revoked_at IS NULL", s.ID).
+			Update("rotated_at", time.Now())
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			reused = true
+			return revokeSessionFamily(tx, s.FamilyID)
+		}
+
+		var err error
+		refreshToken, err = createSession(tx, c, s.UserID, s.FamilyID)
+		return err
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to refresh session"})
+		return
+	}
+	if reused {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "refresh token reuse detected, session revoked"})
+		return
+	}
+
+	accessToken, err := a.newAccessToken(s.UserID, s.FamilyID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate token"})
+		return
+	}
+
+	c.JSON(http.StatusOK, user.TokenResponse{
+		Token:        accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int(accessTokenTTL.Seconds()),
+	})
+}
+
+// handleLogout — завершить текущую сессию (семейство, к которому относится access-токен).
+func (a *App) handleLogout(c *gin.Context) {
+	familyIDVal, _ := c.Get("session_id")
+	familyID, ok := familyIDVal.(uuid.UUID)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "session_id not found in context"})
+		return
+	}
+
+	if err := revokeSessionFamily(a.db.DB, familyID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to revoke session"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// handleLogoutAll — завершить все сессии пользователя на всех устройствах.
+func (a *App) handleLogoutAll(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	if err := revokeUserSessions(a.db.DB, userID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to revoke sessions"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// handleJWKS — публичные ключи для проверки наших JWT другими сервисами.
+func (a *App) handleJWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, a.keys.JWKS())
+}
@@ -0,0 +1,441 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"traindesk/internal/calendar"
+	"traindesk/internal/client"
+	"traindesk/internal/ical"
+	"traindesk/internal/org"
+	"traindesk/internal/user"
+	"traindesk/internal/workout"
+)
+
+const (
+	// Окно ленты: недавние тренировки и расписание на год вперёд.
+	feedPast   = 30 * 24 * time.Hour
+	feedFuture = 365 * 24 * time.Hour
+
+	// maxFeedEvents — предел событий в одной ленте.
+	maxFeedEvents = 2000
+
+	// feedRefresh — как часто приложениям календаря обновлять ленту.
+	feedRefresh = time.Hour
+
+	// feedClientNames — сколько имён клиентов показывать в названии события.
+	feedClientNames = 3
+
+	feedProdID = "-//traindesk//schedule//RU"
+)
+
+func toFeedResponse(f calendar.Feed) calendar.FeedResponse {
+	resp := calendar.FeedResponse{
+		ID:        f.ID.String(),
+		CreatedAt: f.CreatedAt.Format(time.RFC3339),
+		UpdatedAt: f.UpdatedAt.Format(time.RFC3339),
+	}
+	if f.ClientID != nil {
+		s := f.ClientID.String()
+		resp.ClientID = &s
+	}
+	if f.LastUsedAt != nil {
+		s := f.LastUsedAt.Format(time.RFC3339)
+		resp.LastUsedAt = &s
+	}
+	return resp
+}
+
+func toFeedTokenResponse(f calendar.Feed, token string) calendar.FeedTokenResponse {
+	return calendar.FeedTokenResponse{
+		FeedResponse: toFeedResponse(f),
+		Token:        token,
+		Path:         "/calendar/" + token + ".ics",
+	}
+}
+
+// canReadFeedWorkouts — может ли участник выпускать ленты и видеть их содержимое.
+func canReadFeedWorkouts(m member, clientFeed bool) bool {
+	if !m.can(org.PermWorkoutsReadOwn) && !m.can(org.PermWorkoutsReadAll) {
+		return false
+	}
+	return !clientFeed || m.can(org.PermClientsRead)
+}
+
+// loadFeed находит ленту текущего участника по :id; при ошибке сам отвечает.
+func (a *App) loadFeed(c *gin.Context, m member) (calendar.Feed, bool) {
+	feedID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid feed id"})
+		return calendar.Feed{}, false
+	}
+
+	var f calendar.Feed
+	if err := a.db.Where("id = ? AND organization_id = ? AND user_id = ?", feedID, m.OrgID, m.UserID).
+		First(&f).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "feed not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load feed"})
+		}
+		return calendar.Feed{}, false
+	}
+	return f, true
+}
+
+// handleGetCalendarFeeds — ленты календаря, выпущенные текущим тренером в организации.
+func (a *App) handleGetCalendarFeeds(c *gin.Context) {
+	m, ok := currentMember(c)
+	if !ok {
+		return
+	}
+
+	var feeds []calendar.Feed
+	if err := a.db.Where("organization_id = ? AND user_id = ?", m.OrgID, m.UserID).
+		Order("created_at").Find(&feeds).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load feeds"})
+		return
+	}
+
+	resp := make([]calendar.FeedResponse, 0, len(feeds))
+	for _, f := range feeds {
+		resp = append(resp, toFeedResponse(f))
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// handleCreateCalendarFeed — выпустить ленту своего расписания или расписания клиента.
+func (a *App) handleCreateCalendarFeed(c *gin.Context) {
+	m, ok := currentMember(c)
+	if !ok {
+		return
+	}
+
+	var req calendar.CreateFeedRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid JSON"})
+		return
+	}
+
+	var clientID *uuid.UUID
+	if req.ClientID != "" {
+		id, err := uuid.Parse(req.ClientID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid client_id"})
+			return
+		}
+		clientID = &id
+	}
+	if !canReadFeedWorkouts(m, clientID != nil) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "role " + string(m.Role) + " is not allowed to do this"})
+		return
+	}
+
+	existing := a.db.Model(&calendar.Feed{}).Where("organization_id = ? AND user_id = ?", m.OrgID, m.UserID)
+	if clientID != nil {
+		var cnt int64
+		if err := a.db.Model(&client.Client{}).
+			Where("id = ? AND organization_id = ? AND archived_at IS NULL", *clientID, m.OrgID).
+			Count(&cnt).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load client"})
+			return
+		}
+		if cnt == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "client not found"})
+			return
+		}
+		existing = existing.Where("client_id = ?", *clientID)
+	} else {
+		existing = existing.Where("client_id IS NULL")
+	}
+
+	var cnt int64
+	if err := existing.Count(&cnt).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load feeds"})
+		return
+	}
+	if cnt > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "feed already exists; regenerate its link instead"})
+		return
+	}
+
+	token, err := generateToken()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate token"})
+		return
+	}
+
+	f := calendar.Feed{
+		ID:             uuid.New(),
+		OrganizationID: m.OrgID,
+		UserID:         m.UserID,
+		ClientID:       clientID,
+		TokenHash:      hashToken(token),
+	}
+	if err := a.db.Create(&f).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create feed"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, toFeedTokenResponse(f, token))
+}
+
+// handleRegenerateCalendarFeed — сменить ссылку на ленту; старая перестаёт работать.
+func (a *App) handleRegenerateCalendarFeed(c *gin.Context) {
+	m, ok := currentMember(c)
+	if !ok {
+		return
+	}
+
+	f, ok := a.loadFeed(c, m)
+	if !ok {
+		return
+	}
+
+	token, err := generateToken()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate token"})
+		return
+	}
+
+	f.TokenHash = hashToken(token)
+	f.LastUsedAt = nil
+	if err := a.db.Model(&f).Updates(map[string]interface{}{
+		"token_hash":   f.TokenHash,
+		"last_used_at": nil,
+	}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update feed"})
+		return
+	}
+
+	c.JSON(http.StatusOK, toFeedTokenResponse(f, token))
+}
+
+// handleDeleteCalendarFeed — отозвать ленту.
+func (a *App) handleDeleteCalendarFeed(c *gin.Context) {
+	m, ok := currentMember(c)
+	if !ok {
+		return
+	}
+
+	f, ok := a.loadFeed(c, m)
+	if !ok {
+		return
+	}
+
+	if err := a.db.Delete(&f).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete feed"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// handleCalendarFeed — лента в формате iCalendar по секретной ссылке, без входа.
+// Лента видит то же, что выпустивший её тренер: если его исключили из
+// организации или лишили прав, ссылка перестаёт работать.
+func (a *App) handleCalendarFeed(c *gin.Context) {
+	token, ok := strings.CutSuffix(c.Param("token"), ".ics")
+	if !ok || token == "" {
+		c.JSON(http.StatusNotFound, gin.H{"error": "feed not found"})
+		return
+	}
+
+	var f calendar.Feed
+	if err := a.db.Where("token_hash = ?", hashToken(token)).First(&f).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "feed not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load feed"})
+		}
+		return
+	}
+
+	var ms org.Membership
+	if err := a.db.Where("organization_id = ? AND user_id = ?", f.OrganizationID, f.UserID).First(&ms).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "feed not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load feed"})
+		}
+		return
+	}
+	m := member{UserID: f.UserID, OrgID: f.OrganizationID, Role: ms.Role}
+	if !canReadFeedWorkouts(m, f.ClientID != nil) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "feed not found"})
+		return
+	}
+
+	var cal ical.Calendar
+	var err error
+	if f.ClientID != nil {
+		cal, err = a.clientFeed(m, *f.ClientID)
+	} else {
+		cal, err = a.trainerFeed(m)
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "feed not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to build feed"})
+		return
+	}
+
+	if err := a.db.Model(&f).UpdateColumn("last_used_at", time.Now()).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update feed"})
+		return
+	}
+
+	c.Header("Content-Type", "text/calendar; charset=utf-8")
+	c.Header("Content-Disposition", `inline; filename="traindesk.ics"`)
+	c.Header("Cache-Control", "private, max-age=300")
+	c.Status(http.StatusOK)
+	if err := ical.Write(c.Writer, cal); err != nil {
+		log.Printf("calendar feed %s: %v", f.ID, err)
+	}
+}
+
+// feedWorkouts — тренировки в окне ленты, видимые участнику.
+func (a *App) feedWorkouts(q *gorm.DB, m member) ([]workout.Workout, error) {
+	now := time.Now()
+	var ws []workout.Workout
+	err := scopeWorkouts(q, m).
+		Where("workouts.starts_at >= ? AND workouts.starts_at < ?", now.Add(-feedPast), now.Add(feedFuture)).
+		Order("workouts.starts_at, workouts.id").
+		Limit(maxFeedEvents).
+		Find(&ws).Error
+	return ws, err
+}
+
+// trainerFeed — расписание тренера: тренировки, которые он ведёт.
+func (a *App) trainerFeed(m member) (ical.Calendar, error) {
+	var u user.User
+	if err := a.db.Where("id = ?", m.UserID).First(&u).Error; err != nil {
+		return ical.Calendar{}, err
+	}
+
+	ws, err := a.feedWorkouts(a.db.Where("workouts.user_id = ?", m.UserID), m)
+	if err != nil {
+		return ical.Calendar{}, err
+	}
+	ids := make([]uuid.UUID, 0, len(ws))
+	for _, w := range ws {
+		ids = append(ids, w.ID)
+	}
+	details, err := a.loadWorkoutDetails(ids, workoutEmbeds{clients: true})
+	if err != nil {
+		return ical.Calendar{}, err
+	}
+
+	cal := ical.Calendar{ProdID: feedProdID, Name: "TrainDesk — " + u.TrainerName, Refresh: feedRefresh}
+	for _, w := range ws {
+		names := make([]string, 0, feedClientNames)
+		refs := details.clients[w.ID]
+		for i, r := range refs {
+			if i == feedClientNames {
+				names = append(names, fmt.Sprintf("+%d", len(refs)-feedClientNames))
+				break
+			}
+			names = append(names, strings.TrimSpace(r.FirstName+" "+r.LastName))
+		}
+		cal.Events = append(cal.Events, workoutEvent(w, strings.Join(names, ", "), false))
+	}
+	return cal, nil
+}
+
+// clientFeed — тренировки клиента. Имена других участников группы
+// в ленту клиента не попадают; вместо них указан тренер.
+func (a *App) clientFeed(m member, clientID uuid.UUID) (ical.Calendar, error) {
+	var cl client.Client
+	if err := a.db.Where("id = ? AND organization_id = ?", clientID, m.OrgID).First(&cl).Error; err != nil {
+		return ical.Calendar{}, err
+	}
+
+	ws, err := a.feedWorkouts(a.db.
+		Joins("JOIN workout_clients ON workout_clients.workout_id = workouts.id").
+		Where("workout_clients.client_id = ?", clientID), m)
+	if err != nil {
+		return ical.Calendar{}, err
+	}
+
+	ids := make([]uuid.UUID, 0, len(ws))
+	trainerIDs := make([]uuid.UUID, 0, len(ws))
+	for _, w := range ws {
+		ids = append(ids, w.ID)
+		trainerIDs = append(trainerIDs, w.UserID)
+	}
+
+	var links []workout.WorkoutClient
+	if len(ids) > 0 {
+		if err := a.db.Where("client_id = ? AND workout_id IN ?", clientID, ids).Find(&links).Error; err != nil {
+			return ical.Calendar{}, err
+		}
+	}
+	cancelled := make(map[uuid.UUID]bool, len(links))
+	for _, l := range links {
+		cancelled[l.WorkoutID] = l.Attendance == workout.AttendanceCancelled || l.Attendance == workout.AttendanceCancelledLate
+	}
+
+	var trainers []user.User
+	if len(trainerIDs) > 0 {
+		if err := a.db.Select("id", "trainer_name").Where("id IN ?", trainerIDs).Find(&trainers).Error; err != nil {
+			return ical.Calendar{}, err
+		}
+	}
+	trainerNames := make(map[uuid.UUID]string, len(trainers))
+	for _, t := range trainers {
+		trainerNames[t.ID] = t.TrainerName
+	}
+
+	name := strings.TrimSpace(cl.FirstName + " " + cl.LastName)
+	cal := ical.Calendar{ProdID: feedProdID, Name: "TrainDesk — " + name, Refresh: feedRefresh}
+	for _, w := range ws {
+		cal.Events = append(cal.Events, workoutEvent(w, trainerNames[w.UserID], cancelled[w.ID]))
+	}
+	return cal, nil
+}
+
+// workoutEvent — событие календаря для тренировки; who дописывается к типу в названии.
+func workoutEvent(w workout.Workout, who string, cancelled bool) ical.Event {
+	summary := workoutTypeTitle(w.Type)
+	if who != "" {
+		summary += " — " + who
+	}
+	duration := time.Duration(w.DurationMin) * time.Minute
+	if w.AllDay {
+		duration = 24 * time.Hour
+	}
+	return ical.Event{
+		UID:          w.ID.String() + "@traindesk",
+		Stamp:        w.UpdatedAt,
+		Start:        w.StartsAt,
+		Duration:     duration,
+		AllDay:       w.AllDay,
+		Summary:      summary,
+		Description:  w.Notes,
+		Location:     w.Room,
+		Cancelled:    cancelled,
+		LastModified: w.UpdatedAt,
+	}
+}
+
+// workoutTypeTitle — вид тренировки с заглавной буквы.
+func workoutTypeTitle(t workout.WorkoutType) string {
+	s := string(t)
+	if s == "" {
+		return "Workout"
+	}
+	r, size := utf8.DecodeRuneInString(s)
+	return string(unicode.ToUpper(r)) + s[size:]
+}
@@ -1,31 +1,129 @@
 package app
 
 import (
+	"errors"
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 
+	"traindesk/internal/calendar"
 	"traindesk/internal/client"
+	"traindesk/internal/org"
+	"traindesk/internal/pagination"
+	"traindesk/internal/workout"
 )
 
-// handleCreateClient — создать нового клиента тренера.
-func (a *App) handleCreateClient(c *gin.Context) {
-	userIDVal, ok := c.Get("user_id")
-	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "user_id not found in context"})
-		return
+// toClientResponse собирает ответ; сведения о здоровье попадают в него только при withHealth.
+func toClientResponse(cl client.Client, tags []string, withHealth bool) client.ClientResponse {
+	if tags == nil {
+		tags = []string{}
+	}
+	resp := client.ClientResponse{
+		ID:                    cl.ID.String(),
+		FirstName:             cl.FirstName,
+		LastName:              cl.LastName,
+		Phone:                 cl.Phone,
+		Email:                 cl.Email,
+		Gender:                string(cl.Gender),
+		EmergencyContactName:  cl.EmergencyContactName,
+		EmergencyContactPhone: cl.EmergencyContactPhone,
+		Goals:                 cl.Goals,
+		Notes:                 cl.Notes,
+		Tags:                  tags,
+	}
+	if cl.BirthDate != nil {
+		s := cl.BirthDate.Format("2006-01-02")
+		resp.BirthDate = &s
 	}
+	if withHealth {
+		resp.Health = &client.HealthResponse{
+			Injuries:          cl.Injuries,
+			Contraindications: cl.Contraindications,
+		}
+	}
+	if cl.ArchivedAt != nil {
+		s := cl.ArchivedAt.Format(time.RFC3339)
+		resp.ArchivedAt = &s
+	}
+	return resp
+}
 
-	userIDStr, ok := userIDVal.(string)
-	if !ok || userIDStr == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user_id in context"})
+// respondClientValidation отвечает 400 с указанием поля, не прошедшего проверку.
+func respondClientValidation(c *gin.Context, err error) {
+	var ve *client.ValidationError
+	if errors.As(err, &ve) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": ve.Message, "field": ve.Field})
 		return
 	}
+	c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+}
+
+// loadClientTags возвращает метки клиентов, сгруппированные по клиенту.
+func (a *App) loadClientTags(clientIDs []uuid.UUID) (map[uuid.UUID][]string, error) {
+	tags := make(map[uuid.UUID][]string)
+	if len(clientIDs) == 0 {
+		return tags, nil
+	}
+
+	var rows []client.ClientTag
+	if err := a.db.Where("client_id IN ?", clientIDs).Order("tag").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, r := range rows {
+		tags[r.ClientID] = append(tags[r.ClientID], r.Tag)
+	}
+	return tags, nil
+}
+
+// replaceClientTags заменяет набор меток клиента.
+func replaceClientTags(tx *gorm.DB, clientID uuid.UUID, tags []string) error {
+	if err := tx.Where("client_id = ?", clientID).Delete(&client.ClientTag{}).Error; err != nil {
+		return err
+	}
+	if len(tags) == 0 {
+		return nil
+	}
+
+	rows := make([]client.ClientTag, 0, len(tags))
+	for _, t := range tags {
+		rows = append(rows, client.ClientTag{ClientID: clientID, Tag: t})
+	}
+	return tx.Create(&rows).Error
+}
 
-	userID, err := uuid.Parse(userIDStr)
+// loadClient находит клиента организации по :id (в том числе архивного);
+// при ошибке сам отвечает.
+func (a *App) loadClient(c *gin.Context, m member) (client.Client, bool) {
+	clientID, err := uuid.Parse(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user_id in token"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid client id"})
+		return client.Client{}, false
+	}
+
+	var cl client.Client
+	if err := a.db.Where("id = ? AND organization_id = ?", clientID, m.OrgID).First(&cl).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "client not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load client"})
+		}
+		return client.Client{}, false
+	}
+
+	return cl, true
+}
+
+// handleCreateClient — создать нового клиента в организации.
+func (a *App) handleCreateClient(c *gin.Context) {
+	m, ok := currentMember(c)
+	if !ok {
+		return
+	}
+	if !requirePermission(c, m, org.PermClientsWrite) {
 		return
 	}
 
@@ -35,68 +133,393 @@ func (a *App) handleCreateClient(c *gin.Context) {
 		return
 	}
 
-	if req.FirstName == "" || req.LastName == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "first_name and last_name are required",
-		})
+	if err := req.Validate(); err != nil {
+		respondClientValidation(c, err)
 		return
 	}
 
 	cl := client.Client{
-		ID:        uuid.New(),
-		UserID:    userID,
-		FirstName: req.FirstName,
-		LastName:  req.LastName,
+		ID:             uuid.New(),
+		OrganizationID: m.OrgID,
+		UserID:         m.UserID,
 	}
+	req.Apply(&cl)
 
-	if err := a.db.Create(&cl).Error; err != nil {
+	err := a.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&cl).Error; err != nil {
+			return err
+		}
+		return replaceClientTags(tx, cl.ID, req.Tags)
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create client"})
 		return
 	}
 
-	resp := client.ClientResponse{
-		ID:        cl.ID.String(),
-		FirstName: cl.FirstName,
-		LastName:  cl.LastName,
+	c.JSON(http.StatusCreated, toClientResponse(cl, req.Tags, true))
+}
+
+// clientSort описывает допустимую сортировку списка клиентов и её ключ для курсора.
+type clientSort struct {
+	columns []string
+	desc    bool
+	values  func(cl client.Client) []string
+	parse   func(values []string) ([]interface{}, error)
+}
+
+var (
+	sortByName = clientSort{
+		columns: []string{"last_name", "first_name", "id"},
+		values: func(cl client.Client) []string {
+			return []string{cl.LastName, cl.FirstName, cl.ID.String()}
+		},
+		parse: func(v []string) ([]interface{}, error) {
+			id, err := uuid.Parse(v[2])
+			return []interface{}{v[0], v[1], id}, err
+		},
+	}
+	sortByCreated = clientSort{
+		columns: []string{"created_at", "id"},
+		values: func(cl client.Client) []string {
+			return []string{cl.CreatedAt.Format(time.RFC3339Nano), cl.ID.String()}
+		},
+		parse: func(v []string) ([]interface{}, error) {
+			t, err := time.Parse(time.RFC3339Nano, v[0])
+			if err != nil {
+				return nil, err
+			}
+			id, err := uuid.Parse(v[1])
+			return []interface{}{t, id}, err
+		},
+	}
+)
+
+var clientSorts = map[string]clientSort{
+	"name":        sortByName,
+	"-name":       withDesc(sortByName),
+	"created_at":  sortByCreated,
+	"-created_at": withDesc(sortByCreated),
+}
+
+func withDesc(s clientSort) clientSort {
+	s.desc = true
+	return s
+}
+
+// orderBy и after строят ORDER BY и условие keyset-пагинации для сортировки.
+func (s clientSort) orderBy() string {
+	dir := ""
+	if s.desc {
+		dir = " DESC"
 	}
+	return strings.Join(s.columns, dir+", ") + dir
+}
 
-	c.JSON(http.StatusCreated, resp)
+func (s clientSort) after() string {
+	op := ">"
+	if s.desc {
+		op = "<"
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(s.columns)), ", ")
+	return "(" + strings.Join(s.columns, ", ") + ") " + op + " (" + placeholders + ")"
 }
 
-// handleGetClients — список клиентов текущего тренера.
+// escapeLike экранирует спецсимволы шаблона LIKE.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
+// filterClients — клиенты организации с фильтрами status, include_archived,
+// q и tag из запроса; при ошибке сам отвечает.
+func (a *App) filterClients(c *gin.Context, m member) (*gorm.DB, bool) {
+	q := a.db.Where("organization_id = ?", m.OrgID)
+
+	status := c.DefaultQuery("status", "active")
+	if c.Query("include_archived") == "true" {
+		status = "all"
+	}
+	switch status {
+	case "active":
+		q = q.Where("archived_at IS NULL")
+	case "archived":
+		q = q.Where("archived_at IS NOT NULL")
+	case "all":
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "status must be active, archived or all"})
+		return nil, false
+	}
+
+	if search := strings.TrimSpace(c.Query("q")); search != "" {
+		q = q.Where(client.SearchExpr+" ILIKE ?", "%"+escapeLike(search)+"%")
+	}
+
+	if tags := c.QueryArray("tag"); len(tags) > 0 {
+		tags, err := client.NormalizeTags(tags)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return nil, false
+		}
+		q = q.Where("id IN (?)", a.db.Model(&client.ClientTag{}).
+			Select("client_id").
+			Where("tag IN ?", tags).
+			Group("client_id").
+			Having("count(*) = ?", len(tags)))
+	}
+	return q, true
+}
+
+// handleGetClients — постраничный список клиентов организации.
+//
+// Параметры: q — поиск по имени, e-mail и телефону; tag — метка (можно
+// несколько, клиент должен иметь все); status — active (по умолчанию),
+// archived или all; sort — name, -name, created_at, -created_at;
+// limit и cursor — пагинация. Сведения о здоровье отдаются только с include=health.
 func (a *App) handleGetClients(c *gin.Context) {
-	userIDVal, ok := c.Get("user_id")
+	m, ok := currentMember(c)
 	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "user_id not found in context"})
+		return
+	}
+	if !requirePermission(c, m, org.PermClientsRead) {
 		return
 	}
 
-	userIDStr, ok := userIDVal.(string)
-	if !ok || userIDStr == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user_id in context"})
+	sortKey := c.DefaultQuery("sort", "name")
+	sort, ok := clientSorts[sortKey]
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":         "invalid sort",
+			"allowed_sorts": []string{"name", "-name", "created_at", "-created_at"},
+		})
 		return
 	}
 
-	userID, err := uuid.Parse(userIDStr)
+	limit, err := pagination.ParseLimit(c.Query("limit"))
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user_id in token"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
+	q, ok := a.filterClients(c, m)
+	if !ok {
+		return
+	}
+
+	if cur := c.Query("cursor"); cur != "" {
+		values, err := pagination.DecodeCursor(cur, sortKey, len(sort.columns))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		args, err := sort.parse(values)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": pagination.ErrInvalidCursor.Error()})
+			return
+		}
+		q = q.Where(sort.after(), args...)
+	}
+
 	var clientsDB []client.Client
-	if err := a.db.Where("user_id = ?", userID).Order("last_name, first_name").Find(&clientsDB).Error; err != nil {
+	if err := q.Order(sort.orderBy()).Limit(limit + 1).Find(&clientsDB).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load clients"})
 		return
 	}
 
-	resp := make([]client.ClientResponse, 0, len(clientsDB))
+	clientsDB, hasMore := pagination.Trim(clientsDB, limit)
+
+	clientIDs := make([]uuid.UUID, 0, len(clientsDB))
 	for _, cl := range clientsDB {
-		resp = append(resp, client.ClientResponse{
-			ID:        cl.ID.String(),
-			FirstName: cl.FirstName,
-			LastName:  cl.LastName,
-		})
+		clientIDs = append(clientIDs, cl.ID)
+	}
+	tagsMap, err := a.loadClientTags(clientIDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load client tags"})
+		return
+	}
+
+	withHealth := c.Query("include") == "health"
+
+	page := pagination.Page[client.ClientResponse]{
+		Items: make([]client.ClientResponse, 0, len(clientsDB)),
+	}
+	for _, cl := range clientsDB {
+		page.Items = append(page.Items, toClientResponse(cl, tagsMap[cl.ID], withHealth))
+	}
+	if hasMore {
+		next := pagination.EncodeCursor(sortKey, sort.values(clientsDB[len(clientsDB)-1])...)
+		page.NextCursor = &next
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
+// handleGetClientByID — один клиент; архивные тоже доступны, чтобы
+// по ссылке из старой тренировки можно было открыть карточку.
+func (a *App) handleGetClientByID(c *gin.Context) {
+	m, ok := currentMember(c)
+	if !ok {
+		return
+	}
+	if !requirePermission(c, m, org.PermClientsRead) {
+		return
+	}
+
+	cl, ok := a.loadClient(c, m)
+	if !ok {
+		return
+	}
+
+	tagsMap, err := a.loadClientTags([]uuid.UUID{cl.ID})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load client tags"})
+		return
+	}
+
+	c.JSON(http.StatusOK, toClientResponse(cl, tagsMap[cl.ID], true))
+}
+
+// handleUpdateClient — частично обновить клиента.
+func (a *App) handleUpdateClient(c *gin.Context) {
+	m, ok := currentMember(c)
+	if !ok {
+		return
+	}
+	if !requirePermission(c, m, org.PermClientsWrite) {
+		return
+	}
+
+	cl, ok := a.loadClient(c, m)
+	if !ok {
+		return
+	}
+
+	var req client.UpdateClientRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid JSON"})
+		return
+	}
+
+	tagsMap, err := a.loadClientTags([]uuid.UUID{cl.ID})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load client tags"})
+		return
+	}
+
+	merged := client.RequestFromClient(cl, tagsMap[cl.ID])
+	req.Merge(&merged)
+	if err := merged.Validate(); err != nil {
+		respondClientValidation(c, err)
+		return
+	}
+	merged.Apply(&cl)
+
+	err = a.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&cl).Error; err != nil {
+			return err
+		}
+		if req.Tags == nil {
+			return nil
+		}
+		return replaceClientTags(tx, cl.ID, merged.Tags)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update client"})
+		return
+	}
+
+	c.JSON(http.StatusOK, toClientResponse(cl, merged.Tags, true))
+}
+
+// handleDeleteClient — по умолчанию архивирует клиента.
+// С hard=true удаляет его безвозвратно вместе с участием и результатами в тренировках.
+func (a *App) handleDeleteClient(c *gin.Context) {
+	m, ok := currentMember(c)
+	if !ok {
+		return
+	}
+	if !requirePermission(c, m, org.PermClientsWrite) {
+		return
+	}
+
+	cl, ok := a.loadClient(c, m)
+	if !ok {
+		return
+	}
+
+	if c.Query("hard") != "true" {
+		if cl.ArchivedAt == nil {
+			if err := a.db.Model(&cl).Update("archived_at", time.Now()).Error; err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to archive client"})
+				return
+			}
+		}
+		c.Status(http.StatusNoContent)
+		return
+	}
+
+	if !requirePermission(c, m, org.PermClientsDelete) {
+		return
+	}
+
+	err := a.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("client_id = ?", cl.ID).Delete(&workout.ClientSetResult{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("client_id = ?", cl.ID).Delete(&workout.WorkoutClient{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("client_id = ?", cl.ID).Delete(&client.ClientTag{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("client_id = ?", cl.ID).Delete(&calendar.Feed{}).Error; err != nil {
+			return err
+		}
+		// Постоянные участники серий и шаблонов хранятся списком в jsonb.
+		listed := `["` + cl.ID.String() + `"]`
+		for _, model := range []interface{}{&workout.WorkoutSeries{}, &workout.Template{}} {
+			if err := tx.Model(model).
+				Where("organization_id = ? AND client_ids @> ?::jsonb", cl.OrganizationID, listed).
+				UpdateColumn("client_ids", gorm.Expr("client_ids - ?", cl.ID.String())).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Delete(&cl).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete client"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// handleRestoreClient — вернуть клиента из архива.
+func (a *App) handleRestoreClient(c *gin.Context) {
+	m, ok := currentMember(c)
+	if !ok {
+		return
+	}
+	if !requirePermission(c, m, org.PermClientsWrite) {
+		return
+	}
+
+	cl, ok := a.loadClient(c, m)
+	if !ok {
+		return
+	}
+
+	if cl.ArchivedAt != nil {
+		if err := a.db.Model(&cl).Update("archived_at", nil).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to restore client"})
+			return
+		}
+		cl.ArchivedAt = nil
+	}
+
+	tagsMap, err := a.loadClientTags([]uuid.UUID{cl.ID})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load client tags"})
+		return
 	}
 
-	c.JSON(http.StatusOK, resp)
+	c.JSON(http.StatusOK, toClientResponse(cl, tagsMap[cl.ID], true))
 }
@@ -0,0 +1,249 @@
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"traindesk/internal/exercise"
+	"traindesk/internal/org"
+	"traindesk/internal/workout"
+)
+
+func toExerciseResponse(e exercise.Exercise) exercise.ExerciseResponse {
+	groups := make([]string, 0, len(e.MuscleGroups))
+	for _, g := range e.MuscleGroups {
+		groups = append(groups, string(g))
+	}
+	return exercise.ExerciseResponse{
+		ID:           e.ID.String(),
+		Name:         e.Name,
+		MuscleGroups: groups,
+		Equipment:    string(e.Equipment),
+		Global:       e.IsGlobal(),
+	}
+}
+
+// bindExerciseRequest разбирает и проверяет тело запроса; при ошибке сам отвечает.
+func bindExerciseRequest(c *gin.Context) (exercise.CreateExerciseRequest, []exercise.MuscleGroup, bool) {
+	var req exercise.CreateExerciseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid JSON"})
+		return req, nil, false
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" || utf8.RuneCountInString(req.Name) > 100 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "name is required (up to 100 characters)"})
+		return req, nil, false
+	}
+
+	if req.Equipment == "" {
+		req.Equipment = string(exercise.EquipmentNone)
+	}
+	if !exercise.IsValidEquipment(req.Equipment) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":             "invalid equipment",
+			"allowed_equipment": exercise.ValidEquipment,
+		})
+		return req, nil, false
+	}
+
+	groups := make([]exercise.MuscleGroup, 0, len(req.MuscleGroups))
+	for _, g := range req.MuscleGroups {
+		if !exercise.IsValidMuscleGroup(g) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":                 "invalid muscle group: " + g,
+				"allowed_muscle_groups": exercise.ValidMuscleGroups,
+			})
+			return req, nil, false
+		}
+		groups = append(groups, exercise.MuscleGroup(g))
+	}
+
+	return req, groups, true
+}
+
+// loadOrgExercise находит упражнение организации по :id. Общие упражнения
+// видны всем, но менять их нельзя; при ошибке сам отвечает.
+func (a *App) loadOrgExercise(c *gin.Context, m member) (exercise.Exercise, bool) {
+	exerciseID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid exercise id"})
+		return exercise.Exercise{}, false
+	}
+
+	var e exercise.Exercise
+	if err := a.db.Where("id = ? AND (organization_id IS NULL OR organization_id = ?)", exerciseID, m.OrgID).
+		First(&e).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "exercise not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load exercise"})
+		}
+		return exercise.Exercise{}, false
+	}
+
+	if e.IsGlobal() {
+		c.JSON(http.StatusForbidden, gin.H{"error": "global exercises are read-only"})
+		return exercise.Exercise{}, false
+	}
+
+	return e, true
+}
+
+// handleGetExercises — справочник упражнений: общие и упражнения организации.
+// Фильтры: q — часть названия, muscle_group, equipment.
+func (a *App) handleGetExercises(c *gin.Context) {
+	m, ok := currentMember(c)
+	if !ok {
+		return
+	}
+	if !requirePermission(c, m, org.PermWorkoutsReadOwn) {
+		return
+	}
+
+	q := a.db.Where("organization_id IS NULL OR organization_id = ?", m.OrgID)
+
+	if search := strings.TrimSpace(c.Query("q")); search != "" {
+		q = q.Where("name ILIKE ?", "%"+escapeLike(search)+"%")
+	}
+	if g := c.Query("muscle_group"); g != "" {
+		if !exercise.IsValidMuscleGroup(g) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid muscle_group"})
+			return
+		}
+		groupJSON, _ := json.Marshal([]string{g})
+		q = q.Where("muscle_groups @> ?::jsonb", string(groupJSON))
+	}
+	if eq := c.Query("equipment"); eq != "" {
+		if !exercise.IsValidEquipment(eq) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid equipment"})
+			return
+		}
+		q = q.Where("equipment = ?", eq)
+	}
+
+	var exercises []exercise.Exercise
+	if err := q.Order("name").Find(&exercises).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load exercises"})
+		return
+	}
+
+	resp := make([]exercise.ExerciseResponse, 0, len(exercises))
+	for _, e := range exercises {
+		resp = append(resp, toExerciseResponse(e))
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// handleCreateExercise — добавить упражнение в справочник организации.
+func (a *App) handleCreateExercise(c *gin.Context) {
+	m, ok := currentMember(c)
+	if !ok {
+		return
+	}
+	if !requirePermission(c, m, org.PermExercisesWrite) {
+		return
+	}
+
+	req, groups, ok := bindExerciseRequest(c)
+	if !ok {
+		return
+	}
+
+	orgID, userID := m.OrgID, m.UserID
+	e := exercise.Exercise{
+		ID:             uuid.New(),
+		OrganizationID: &orgID,
+		CreatedBy:      &userID,
+		Name:           req.Name,
+		MuscleGroups:   groups,
+		Equipment:      exercise.Equipment(req.Equipment),
+	}
+	if err := a.db.Create(&e).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create exercise"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, toExerciseResponse(e))
+}
+
+// handleUpdateExercise — изменить упражнение организации.
+func (a *App) handleUpdateExercise(c *gin.Context) {
+	m, ok := currentMember(c)
+	if !ok {
+		return
+	}
+	if !requirePermission(c, m, org.PermExercisesWrite) {
+		return
+	}
+
+	e, ok := a.loadOrgExercise(c, m)
+	if !ok {
+		return
+	}
+
+	req, groups, ok := bindExerciseRequest(c)
+	if !ok {
+		return
+	}
+
+	e.Name = req.Name
+	e.MuscleGroups = groups
+	e.Equipment = exercise.Equipment(req.Equipment)
+	if err := a.db.Save(&e).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update exercise"})
+		return
+	}
+
+	c.JSON(http.StatusOK, toExerciseResponse(e))
+}
+
+// handleDeleteExercise — удалить упражнение организации, если его нет ни в тренировках, ни в шаблонах.
+func (a *App) handleDeleteExercise(c *gin.Context) {
+	m, ok := currentMember(c)
+	if !ok {
+		return
+	}
+	if !requirePermission(c, m, org.PermExercisesWrite) {
+		return
+	}
+
+	e, ok := a.loadOrgExercise(c, m)
+	if !ok {
+		return
+	}
+
+	var used int64
+	if err := a.db.Model(&workout.WorkoutBlock{}).Where("exercise_id = ?", e.ID).Count(&used).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to check exercise usage"})
+		return
+	}
+	if used == 0 {
+		blockJSON, _ := json.Marshal([]map[string]string{{"exercise_id": e.ID.String()}})
+		if err := a.db.Model(&workout.Template{}).
+			Where("exercises @> ?::jsonb", string(blockJSON)).
+			Count(&used).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to check exercise usage"})
+			return
+		}
+	}
+	if used > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "exercise is used in workouts or templates"})
+		return
+	}
+
+	if err := a.db.Delete(&e).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete exercise"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,279 @@
+package app
+
+import (
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"traindesk/internal/client"
+	"traindesk/internal/org"
+	"traindesk/internal/tz"
+	"traindesk/internal/user"
+	"traindesk/internal/workout"
+	"traindesk/internal/xlsx"
+)
+
+// exportBatch — сколько строк выгрузки читается из БД за раз.
+const exportBatch = 500
+
+// handleExportWorkouts — тренировки, видимые участнику, таблицей CSV или XLSX.
+//
+// Параметры: format — csv (по умолчанию) или xlsx; from, to, client_id, type_id и type —
+// как в списке тренировок. Строки идут от старых к новым, время — в поясе тренировки.
+func (a *App) handleExportWorkouts(c *gin.Context) {
+	m, ok := currentMember(c)
+	if !ok {
+		return
+	}
+	if !requirePermission(c, m, org.PermWorkoutsReadOwn) {
+		return
+	}
+
+	format, ok := parseExportFormat(c)
+	if !ok {
+		return
+	}
+	q, ok := a.filterWorkouts(c, m)
+	if !ok {
+		return
+	}
+
+	// Первая порция читается до заголовков ответа, чтобы ошибка БД
+	// ещё могла вернуться обычным JSON.
+	batch, rows, err := a.workoutExportBatch(q, nil)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load workouts"})
+		return
+	}
+
+	sheet, err := startSheet(c, format, "workouts")
+	if err == nil {
+		err = sheet.WriteRow(workoutExportHeader)
+	}
+	for err == nil && len(batch) > 0 {
+		for _, row := range rows {
+			if err = sheet.WriteRow(row); err != nil {
+				break
+			}
+		}
+		if err != nil || len(batch) < exportBatch {
+			break
+		}
+		batch, rows, err = a.workoutExportBatch(q, &batch[len(batch)-1])
+	}
+	if err == nil {
+		err = sheet.Close()
+	}
+	if err != nil {
+		log.Printf("workouts export for %s: %v", m.UserID, err)
+	}
+}
+
+var workoutExportHeader = headerRow(
+	"date", "start", "end", "duration_min", "all_day", "time_zone", "type",
+	"trainer", "clients", "room", "notes", "series_id", "id",
+)
+
+// workoutExportBatch читает следующую за after порцию тренировок и строит
+// их строки: имена клиентов и тренеров подтягиваются на всю порцию сразу.
+func (a *App) workoutExportBatch(q *gorm.DB, after *workout.Workout) ([]workout.Workout, [][]xlsx.Cell, error) {
+	q = q.Session(&gorm.Session{})
+	if after != nil {
+		q = q.Where("(workouts.starts_at, workouts.id) > (?, ?)", after.StartsAt, after.ID)
+	}
+	var ws []workout.Workout
+	if err := q.Order("workouts.starts_at, workouts.id").Limit(exportBatch).Find(&ws).Error; err != nil {
+		return nil, nil, err
+	}
+
+	workoutIDs := make([]uuid.UUID, 0, len(ws))
+	trainerIDs := make([]uuid.UUID, 0)
+	seen := make(map[uuid.UUID]bool)
+	for _, w := range ws {
+		workoutIDs = append(workoutIDs, w.ID)
+		if !seen[w.UserID] {
+			seen[w.UserID] = true
+			trainerIDs = append(trainerIDs, w.UserID)
+		}
+	}
+
+	details, err := a.loadWorkoutDetails(workoutIDs, workoutEmbeds{clients: true})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var trainers []user.User
+	if len(trainerIDs) > 0 {
+		if err := a.db.Select("id", "trainer_name").Where("id IN ?", trainerIDs).Find(&trainers).Error; err != nil {
+			return nil, nil, err
+		}
+	}
+	trainerNames := make(map[uuid.UUID]string, len(trainers))
+	for _, t := range trainers {
+		trainerNames[t.ID] = t.TrainerName
+	}
+
+	rows := make([][]xlsx.Cell, 0, len(ws))
+	for _, w := range ws {
+		names := make([]string, 0, len(details.clients[w.ID]))
+		for _, cl := range details.clients[w.ID] {
+			names = append(names, strings.TrimSpace(cl.FirstName+" "+cl.LastName))
+		}
+
+		row := make([]xlsx.Cell, 0, len(workoutExportHeader))
+		if w.AllDay {
+			row = append(row, xlsx.Date(w.StartsAt.UTC()), xlsx.Empty, xlsx.Empty, xlsx.Empty, xlsx.Str("true"))
+		} else {
+			loc := tz.LoadOrUTC(w.TimeZone)
+			row = append(row,
+				xlsx.Date(w.StartsAt.In(loc)),
+				xlsx.DateTime(w.StartsAt.In(loc)),
+				xlsx.DateTime(w.EndsAt.In(loc)),
+				xlsx.Int(w.DurationMin),
+				xlsx.Str("false"),
+			)
+		}
+		row = append(row,
+			xlsx.Str(w.TimeZone),
+			xlsx.Str(string(w.Type)),
+			optStr(trainerNames[w.UserID]),
+			optStr(strings.Join(names, "; ")),
+			optStr(w.Room),
+			optStr(w.Notes),
+			optStr(formatOptUUID(w.SeriesID)),
+			xlsx.Str(w.ID.String()),
+		)
+		rows = append(rows, row)
+	}
+	return ws, rows, nil
+}
+
+// handleExportClients — клиенты организации таблицей CSV или XLSX.
+//
+// Параметры: format — csv (по умолчанию) или xlsx; q, tag, status — как
+// в списке клиентов. Сведения о здоровье выгружаются только с include=health.
+func (a *App) handleExportClients(c *gin.Context) {
+	m, ok := currentMember(c)
+	if !ok {
+		return
+	}
+	if !requirePermission(c, m, org.PermClientsRead) {
+		return
+	}
+
+	format, ok := parseExportFormat(c)
+	if !ok {
+		return
+	}
+	q, ok := a.filterClients(c, m)
+	if !ok {
+		return
+	}
+	withHealth := c.Query("include") == "health"
+
+	batch, rows, err := a.clientExportBatch(q, nil, withHealth)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load clients"})
+		return
+	}
+
+	sheet, err := startSheet(c, format, "clients")
+	if err == nil {
+		err = sheet.WriteRow(clientExportHeader(withHealth))
+	}
+	for err == nil && len(batch) > 0 {
+		for _, row := range rows {
+			if err = sheet.WriteRow(row); err != nil {
+				break
+			}
+		}
+		if err != nil || len(batch) < exportBatch {
+			break
+		}
+		batch, rows, err = a.clientExportBatch(q, &batch[len(batch)-1], withHealth)
+	}
+	if err == nil {
+		err = sheet.Close()
+	}
+	if err != nil {
+		log.Printf("clients export for %s: %v", m.UserID, err)
+	}
+}
+
+func clientExportHeader(withHealth bool) []xlsx.Cell {
+	names := []string{
+		"last_name", "first_name", "phone", "email", "birth_date", "gender",
+		"tags", "emergency_contact_name", "emergency_contact_phone", "goals", "notes",
+	}
+	if withHealth {
+		names = append(names, "injuries", "contraindications")
+	}
+	names = append(names, "archived", "created_at", "id")
+	return headerRow(names...)
+}
+
+// clientExportBatch читает следующую за after порцию клиентов (по фамилии
+// и имени) и строит их строки вместе с метками.
+func (a *App) clientExportBatch(q *gorm.DB, after *client.Client, withHealth bool) ([]client.Client, [][]xlsx.Cell, error) {
+	q = q.Session(&gorm.Session{})
+	if after != nil {
+		q = q.Where(sortByName.after(), after.LastName, after.FirstName, after.ID)
+	}
+	var cls []client.Client
+	if err := q.Order(sortByName.orderBy()).Limit(exportBatch).Find(&cls).Error; err != nil {
+		return nil, nil, err
+	}
+
+	clientIDs := make([]uuid.UUID, 0, len(cls))
+	for _, cl := range cls {
+		clientIDs = append(clientIDs, cl.ID)
+	}
+	tagsMap, err := a.loadClientTags(clientIDs)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rows := make([][]xlsx.Cell, 0, len(cls))
+	for _, cl := range cls {
+		birth := xlsx.Empty
+		if cl.BirthDate != nil {
+			birth = xlsx.Date(cl.BirthDate.UTC())
+		}
+		row := []xlsx.Cell{
+			optStr(cl.LastName),
+			optStr(cl.FirstName),
+			optStr(cl.Phone),
+			optStr(cl.Email),
+			birth,
+			optStr(string(cl.Gender)),
+			optStr(strings.Join(tagsMap[cl.ID], "; ")),
+			optStr(cl.EmergencyContactName),
+			optStr(cl.EmergencyContactPhone),
+			optStr(cl.Goals),
+			optStr(cl.Notes),
+		}
+		if withHealth {
+			row = append(row, optStr(cl.Injuries), optStr(cl.Contraindications))
+		}
+		archived := "false"
+		if cl.ArchivedAt != nil {
+			archived = "true"
+		}
+		row = append(row, xlsx.Str(archived), xlsx.DateTime(cl.CreatedAt.UTC()), xlsx.Str(cl.ID.String()))
+		rows = append(rows, row)
+	}
+	return cls, rows, nil
+}
+
+// headerRow — строка заголовков таблицы.
+func headerRow(names ...string) []xlsx.Cell {
+	row := make([]xlsx.Cell, len(names))
+	for i, n := range names {
+		row[i] = xlsx.Str(n)
+	}
+	return row
+}
@@ -0,0 +1,423 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"traindesk/internal/apikey"
+	"traindesk/internal/client"
+	"traindesk/internal/ical"
+	"traindesk/internal/importer"
+	"traindesk/internal/org"
+	"traindesk/internal/tz"
+	"traindesk/internal/workout"
+)
+
+// maxImportBytes — предел размера загружаемого файла.
+const maxImportBytes = 5 << 20
+
+// errImportDryRun откатывает транзакцию пробного импорта.
+var errImportDryRun = errors.New("dry run")
+
+// importOptions — параметры импорта из формы.
+type importOptions struct {
+	dryRun        bool
+	createClients bool
+	defaultType   *workout.Type
+	zone          string
+}
+
+// importRun — состояние импорта одного файла.
+type importRun struct {
+	m       member
+	opts    importOptions
+	catalog workout.Catalog
+	matcher *importer.Matcher
+	seen    map[string]bool // тренировки, уже встреченные в файле
+	report  importer.Report
+}
+
+// handleImportWorkouts — импорт тренировок из .ics или .csv (multipart-поле file).
+// Поля формы: dry_run=true — только отчёт, без сохранения; create_clients=true —
+// создавать клиентов, которых не удалось сопоставить; default_type — тип для
+// строк без типа; time_zone — пояс для времени без смещения (по умолчанию пояс
+// тренера); mapping — JSON с сопоставлением колонок CSV; format — ics или csv,
+// если его не понять по имени файла. Тренировки создаются от имени текущего
+// тренера, пересечения по времени не проверяются.
+func (a *App) handleImportWorkouts(c *gin.Context) {
+	m, ok := currentMember(c)
+	if !ok {
+		return
+	}
+	if !requireWorkoutsWrite(c, m) {
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImportBytes+64<<10)
+	fh, err := c.FormFile("file")
+	if err != nil {
+		var mbe *http.MaxBytesError
+		if errors.As(err, &mbe) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("file must be at most %d bytes", maxImportBytes)})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "file is required (multipart field \"file\")"})
+		return
+	}
+	if fh.Size > maxImportBytes {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("file must be at most %d bytes", maxImportBytes)})
+		return
+	}
+	f, err := fh.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "failed to read file"})
+		return
+	}
+	data, err := io.ReadAll(io.LimitReader(f, maxImportBytes))
+	f.Close()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "failed to read file"})
+		return
+	}
+
+	opts := importOptions{
+		dryRun:        c.PostForm("dry_run") == "true",
+		createClients: c.PostForm("create_clients") == "true",
+		zone:          strings.TrimSpace(c.PostForm("time_zone")),
+	}
+	if opts.createClients {
+		if !requirePermission(c, m, org.PermClientsWrite) {
+			return
+		}
+		if !hasScope(c, apikey.ScopeClientsWrite) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "api key lacks scope " + string(apikey.ScopeClientsWrite)})
+			return
+		}
+	}
+	catalog, err := workout.LoadCatalog(a.db.DB, m.OrgID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load workout types"})
+		return
+	}
+	if v := strings.TrimSpace(c.PostForm("default_type")); v != "" {
+		t, ok := catalog.Find(v)
+		if !ok {
+			respondInvalidType(c, "invalid default_type", catalog)
+			return
+		}
+		opts.defaultType = &t
+	}
+	if opts.zone == "" {
+		opts.zone = a.userTimeZone(m.UserID)
+	}
+	if _, err := tz.Load(opts.zone); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "time_zone: " + err.Error()})
+		return
+	}
+
+	format, ok := importFormat(fh.Filename, c.PostForm("format"), data)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "unsupported file format, expected .ics or .csv"})
+		return
+	}
+
+	run := importRun{
+		m:       m,
+		opts:    opts,
+		catalog: catalog,
+		seen:    make(map[string]bool),
+		report:  importer.Report{Format: format, DryRun: opts.dryRun, Rows: []importer.RowReport{}},
+	}
+
+	var items []importer.Item
+	switch format {
+	case "ics":
+		events, err := ical.Parse(bytes.NewReader(data), opts.zone)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		// Серии разворачиваются на тот же горизонт, что и свои.
+		if items, err = importer.FromEvents(events, opts.zone, time.Now().Add(seriesHorizon)); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	case "csv":
+		var mapping *importer.Mapping
+		if v := c.PostForm("mapping"); v != "" {
+			mapping = &importer.Mapping{}
+			if err := json.Unmarshal([]byte(v), mapping); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid mapping JSON"})
+				return
+			}
+		}
+		var applied importer.Mapping
+		items, applied, err = importer.ParseCSV(data, mapping, opts.zone)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "mapping": applied})
+			return
+		}
+		run.report.Mapping = &applied
+	}
+
+	// Архивных клиентов не предлагаем: записать их на тренировку нельзя.
+	var candidates []importer.Candidate
+	if err := a.db.Model(&client.Client{}).
+		Select("id", "first_name", "last_name", "email").
+		Where("organization_id = ? AND archived_at IS NULL", m.OrgID).
+		Scan(&candidates).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load clients"})
+		return
+	}
+	run.matcher = importer.NewMatcher(candidates)
+
+	err = a.db.Transaction(func(tx *gorm.DB) error {
+		for _, it := range items {
+			row, err := run.importItem(tx, it)
+			if err != nil {
+				return err
+			}
+			run.report.Rows = append(run.report.Rows, row)
+			switch row.Status {
+			case importer.StatusCreated:
+				run.report.Created++
+			case importer.StatusSkipped:
+				run.report.Skipped++
+			case importer.StatusFailed:
+				run.report.Failed++
+			}
+		}
+		if opts.dryRun {
+			return errImportDryRun
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errImportDryRun) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to import workouts"})
+		return
+	}
+
+	c.JSON(http.StatusOK, run.report)
+}
+
+// importFormat определяет формат по явному полю, расширению файла или содержимому.
+func importFormat(name, explicit string, data []byte) (string, bool) {
+	switch strings.ToLower(strings.TrimSpace(explicit)) {
+	case "ics":
+		return "ics", true
+	case "csv":
+		return "csv", true
+	}
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".ics", ".ical", ".ifb":
+		return "ics", true
+	case ".csv", ".tsv", ".txt":
+		return "csv", true
+	}
+	head := bytes.TrimSpace(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf")))
+	if bytes.HasPrefix(bytes.ToUpper(head), []byte("BEGIN:VCALENDAR")) {
+		return "ics", true
+	}
+	return "", false
+}
+
+// importType — вид тренировки из справочника: по колонке, по названию события
+// (самое длинное из встретившихся в нём названий видов) или по умолчанию.
+// Если вид указан, но его нет в справочнике, возвращается false.
+func (r *importRun) importType(it importer.Item) (*workout.Type, bool) {
+	if it.Req.Type != "" {
+		t, ok := r.catalog.Find(it.Req.Type)
+		return &t, ok
+	}
+	summary := strings.ToLower(it.Summary)
+	var best *workout.Type
+	for i, t := range r.catalog {
+		if strings.Contains(summary, strings.ToLower(t.Name)) && (best == nil || len(t.Name) > len(best.Name)) {
+			best = &r.catalog[i]
+		}
+	}
+	if best != nil {
+		return best, true
+	}
+	return r.opts.defaultType, true
+}
+
+// importItem проверяет и сохраняет одну тренировку. Ошибки данных попадают
+// в отчёт строки; возвращаемая ошибка — только ошибка БД, она прерывает импорт.
+func (r *importRun) importItem(tx *gorm.DB, it importer.Item) (importer.RowReport, error) {
+	row := importer.RowReport{Row: it.Row}
+	fail := func(format string, args ...interface{}) (importer.RowReport, error) {
+		row.Status, row.Reason = importer.StatusFailed, fmt.Sprintf(format, args...)
+		return row, nil
+	}
+
+	if it.Err != nil {
+		return fail("%v", it.Err)
+	}
+
+	req := it.Req
+	wtype, ok := r.importType(it)
+	switch {
+	case !ok:
+		row.Type = req.Type
+		return fail("invalid workout type %q", req.Type)
+	case wtype == nil:
+		return fail("type is required; set the type column or default_type")
+	}
+	row.Type = wtype.Name
+	if !req.AllDay && req.StartsAt != "" && req.EndsAt == "" && req.DurationMin == 0 && wtype.DefaultDurationMin != nil {
+		req.DurationMin = *wtype.DefaultDurationMin
+	}
+	if utf8.RuneCountInString(req.Room) > maxRoomLen {
+		return fail("room must be at most %d characters", maxRoomLen)
+	}
+
+	s, err := workout.ResolveSchedule(req, r.opts.zone)
+	if err != nil {
+		return fail("%v", err)
+	}
+	if s.AllDay {
+		row.StartsAt = s.StartsAt.Format("2006-01-02")
+	} else {
+		row.StartsAt = s.StartsAt.In(tz.LoadOrUTC(s.TimeZone)).Format(time.RFC3339)
+	}
+
+	if it.Skip != "" {
+		row.Status, row.Reason = importer.StatusSkipped, it.Skip
+		return row, nil
+	}
+
+	key := fmt.Sprintf("%d/%s", s.StartsAt.Unix(), wtype.ID)
+	if r.seen[key] {
+		row.Status, row.Reason = importer.StatusSkipped, "duplicate of an earlier row in the file"
+		return row, nil
+	}
+	r.seen[key] = true
+
+	var cnt int64
+	if err := tx.Model(&workout.Workout{}).
+		Where("organization_id = ? AND user_id = ? AND starts_at = ? AND type_id = ?", r.m.OrgID, r.m.UserID, s.StartsAt, wtype.ID).
+		Count(&cnt).Error; err != nil {
+		return row, err
+	}
+	if cnt > 0 {
+		row.Status, row.Reason = importer.StatusSkipped, "workout already exists"
+		return row, nil
+	}
+
+	clientIDs, ok, err := r.matchClients(tx, it.Attendees, &row)
+	if err != nil || !ok {
+		return row, err
+	}
+
+	w := workout.Workout{
+		ID:             uuid.New(),
+		OrganizationID: r.m.OrgID,
+		UserID:         r.m.UserID,
+		TypeID:         wtype.ID,
+		Type:           workout.WorkoutType(wtype.Name),
+		Notes:          req.Notes,
+		Room:           req.Room,
+	}
+	s.Apply(&w)
+	if err := tx.Create(&w).Error; err != nil {
+		return row, err
+	}
+	if err := syncWorkoutClients(tx, w.ID, clientIDs); err != nil {
+		return row, err
+	}
+
+	row.Status = importer.StatusCreated
+	if !r.opts.dryRun {
+		id := w.ID.String()
+		row.WorkoutID = &id
+	}
+	return row, nil
+}
+
+// matchClients сопоставляет участников с клиентами и при create_clients создаёт
+// недостающих. Если сопоставить не удалось, помечает строку как failed и
+// возвращает ok=false; клиенты тогда не создаются.
+func (r *importRun) matchClients(tx *gorm.DB, attendees []importer.Attendee, row *importer.RowReport) ([]uuid.UUID, bool, error) {
+	type pending struct {
+		match *importer.ClientMatch
+		req   client.CreateClientRequest
+	}
+	var (
+		ids      []uuid.UUID
+		toCreate []pending
+		problems []string
+	)
+	row.Clients = make([]importer.ClientMatch, 0, len(attendees))
+	for _, a := range attendees {
+		cand, kind, dist := r.matcher.Match(a.Name, a.Email)
+		row.Clients = append(row.Clients, importer.ClientMatch{Name: a.Name, Email: a.Email, Match: kind, Distance: dist})
+		cm := &row.Clients[len(row.Clients)-1]
+
+		switch kind {
+		case importer.MatchAmbiguous:
+			problems = append(problems, fmt.Sprintf("%q matches several clients", displayName(a)))
+		case importer.MatchNone:
+			if !r.opts.createClients {
+				problems = append(problems, fmt.Sprintf("no client matches %q", displayName(a)))
+				continue
+			}
+			first, last := importer.SplitName(a.Name)
+			req := client.CreateClientRequest{FirstName: first, LastName: last, Email: a.Email}
+			if err := req.Validate(); err != nil {
+				problems = append(problems, fmt.Sprintf("cannot create client %q: %v", displayName(a), err))
+				continue
+			}
+			toCreate = append(toCreate, pending{match: cm, req: req})
+		default:
+			id := cand.ID.String()
+			cm.ClientID = &id
+			ids = append(ids, cand.ID)
+		}
+	}
+	if len(problems) > 0 {
+		row.Status, row.Reason = importer.StatusFailed, strings.Join(problems, "; ")
+		return nil, false, nil
+	}
+
+	for _, p := range toCreate {
+		cl := client.Client{
+			ID:             uuid.New(),
+			OrganizationID: r.m.OrgID,
+			UserID:         r.m.UserID,
+		}
+		p.req.Apply(&cl)
+		if err := tx.Create(&cl).Error; err != nil {
+			return nil, false, err
+		}
+		r.matcher.Add(importer.Candidate{ID: cl.ID, FirstName: cl.FirstName, LastName: cl.LastName, Email: cl.Email})
+		r.report.ClientsCreated++
+
+		p.match.Match = importer.MatchNew
+		if !r.opts.dryRun {
+			id := cl.ID.String()
+			p.match.ClientID = &id
+		}
+		ids = append(ids, cl.ID)
+	}
+	return ids, true, nil
+}
+
+func displayName(a importer.Attendee) string {
+	if a.Name != "" {
+		return a.Name
+	}
+	return a.Email
+}
@@ -0,0 +1,312 @@
+package app
+
+import (
+	"crypto/subtle"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+
+	"traindesk/internal/tz"
+	"traindesk/internal/user"
+)
+
+const (
+	emailChangeTTL         = time.Hour
+	emailChangeMaxAttempts = 5
+)
+
+var errEmailTaken = errors.New("email is already taken")
+
+func toProfileResponse(u user.User) user.ProfileResponse {
+	resp := user.ProfileResponse{
+		ID:            u.ID.String(),
+		Email:         u.Email,
+		TrainerName:   u.TrainerName,
+		EmailVerified: u.EmailVerified,
+		TOTPEnabled:   u.TOTPEnabled,
+		TimeZone:      u.TimeZone,
+		CreatedAt:     u.CreatedAt.Format(time.RFC3339),
+	}
+	if u.DeletionScheduledAt != nil {
+		s := u.DeletionScheduledAt.Format(time.RFC3339)
+		resp.DeletionScheduledAt = &s
+	}
+	return resp
+}
+
+// handleGetMe — профиль текущего тренера.
+func (a *App) handleGetMe(c *gin.Context) {
+	u, ok := a.loadCurrentUser(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, toProfileResponse(u))
+}
+
+// handleUpdateMe — изменить профиль: имя тренера и часовой пояс.
+func (a *App) handleUpdateMe(c *gin.Context) {
+	u, ok := a.loadCurrentUser(c)
+	if !ok {
+		return
+	}
+
+	var req user.UpdateProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid JSON"})
+		return
+	}
+
+	updates := map[string]interface{}{}
+	if req.TrainerName != nil {
+		name := strings.TrimSpace(*req.TrainerName)
+		if name == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "trainer_name must not be empty"})
+			return
+		}
+		updates["trainer_name"] = name
+		u.TrainerName = name
+	}
+	if req.TimeZone != nil {
+		if _, err := tz.Load(*req.TimeZone); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "time_zone: " + err.Error()})
+			return
+		}
+		updates["time_zone"] = strings.TrimSpace(*req.TimeZone)
+		u.TimeZone = strings.TrimSpace(*req.TimeZone)
+	}
+
+	if len(updates) > 0 {
+		if err := a.db.Model(&u).Updates(updates).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update profile"})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, toProfileResponse(u))
+}
+
+// handleChangePassword — сменить пароль, зная текущий.
+// Все остальные сессии пользователя при этом завершаются.
+func (a *App) handleChangePassword(c *gin.Context) {
+	u, ok := a.loadCurrentUser(c)
+	if !ok {
+		return
+	}
+
+	var req user.ChangePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid JSON"})
+		return
+	}
+
+	if req.CurrentPassword == "" || len(req.NewPassword) < 6 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "current_password and new_password (>=6) are required",
+		})
+		return
+	}
+
+	keys := a.throttleKeys(c, "change-password", u.ID.String())
+	if !a.allowAttempt(c, keys) {
+		return
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(u.PasswordHash), []byte(req.CurrentPassword)); err != nil {
+		a.recordFailure(c, keys)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid current password"})
+		return
+	}
+	a.resetAttempts(c, keys)
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), 10)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to hash password"})
+		return
+	}
+
+	familyID, _ := c.MustGet("session_id").(uuid.UUID)
+
+	err = a.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&u).Update("password_hash", string(hash)).Error; err != nil {
+			return err
+		}
+		return tx.Model(&user.Session{}).
+			Where("user_id = ? AND family_id <> ? AND revoked_at IS NULL", u.ID, familyID).
+			Update("revoked_at", time.Now()).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to change password"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// handleRequestEmailChange — начать смену e-mail: отправить код на новый адрес.
+func (a *App) handleRequestEmailChange(c *gin.Context) {
+	u, ok := a.loadCurrentUser(c)
+	if !ok {
+		return
+	}
+
+	var req user.ChangeEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid JSON"})
+		return
+	}
+
+	req.NewEmail = strings.TrimSpace(req.NewEmail)
+	if req.NewEmail == "" || !strings.Contains(req.NewEmail, "@") || req.Password == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "new_email and password are required"})
+		return
+	}
+	if req.NewEmail == u.Email {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "new_email is the same as the current one"})
+		return
+	}
+
+	keys := a.throttleKeys(c, "change-email", u.ID.String())
+	if !a.allowAttempt(c, keys) {
+		return
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(u.PasswordHash), []byte(req.Password)); err != nil {
+		a.recordFailure(c, keys)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid password"})
+		return
+	}
+	a.resetAttempts(c, keys)
+
+	var taken int64
+	if err := a.db.Model(&user.User{}).Where("email = ?", req.NewEmail).Count(&taken).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to check email"})
+		return
+	}
+	if taken > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": errEmailTaken.Error()})
+		return
+	}
+
+	code, err := generateVerificationCode()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate verify code"})
+		return
+	}
+
+	change := user.EmailChange{
+		ID:        uuid.New(),
+		UserID:    u.ID,
+		NewEmail:  req.NewEmail,
+		CodeHash:  hashToken(code),
+		ExpiresAt: time.Now().Add(emailChangeTTL),
+	}
+
+	err = a.db.Transaction(func(tx *gorm.DB) error {
+		// Одновременно действует только одна заявка на смену адреса.
+		if err := tx.Where("user_id = ?", u.ID).Delete(&user.EmailChange{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&change).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create email change request"})
+		return
+	}
+
+	if err := a.mailer.SendEmailChangeEmail(change.NewEmail, code); err != nil {
+		log.Printf("failed to send email change code to %s: %v", change.NewEmail, err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "failed to send confirmation email"})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"message": "confirmation code sent to the new address"})
+}
+
+// handleConfirmEmailChange — подтвердить смену e-mail кодом с нового адреса.
+func (a *App) handleConfirmEmailChange(c *gin.Context) {
+	u, ok := a.loadCurrentUser(c)
+	if !ok {
+		return
+	}
+
+	var req user.ConfirmEmailChangeRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.Code == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "code is required"})
+		return
+	}
+
+	var change user.EmailChange
+	if err := a.db.Where("user_id = ?", u.ID).First(&change).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "no pending email change"})
+		return
+	}
+
+	if time.Now().After(change.ExpiresAt) {
+		a.db.Delete(&change)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid or expired code"})
+		return
+	}
+
+	// Попытка списывается до сравнения кода одним условным UPDATE, чтобы
+	// параллельные запросы не проверили больше кодов, чем разрешено.
+	res := a.db.Model(&user.EmailChange{}).
+		Where("id = ? AND attempts < ?", change.ID, emailChangeMaxAttempts).
+		UpdateColumn("attempts", gorm.Expr("attempts + 1"))
+	if res.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to change email"})
+		return
+	}
+	if res.RowsAffected == 0 {
+		a.db.Delete(&change)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid or expired code"})
+		return
+	}
+
+	if subtle.ConstantTimeCompare([]byte(hashToken(req.Code)), []byte(change.CodeHash)) != 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid or expired code"})
+		return
+	}
+
+	err := a.db.Transaction(func(tx *gorm.DB) error {
+		// Адрес мог быть занят, пока шло подтверждение; uniqueIndex всё равно
+		// не даст записать дубль, но так ошибка будет понятной.
+		var taken int64
+		if err := tx.Model(&user.User{}).
+			Where("email = ? AND id <> ?", change.NewEmail, u.ID).
+			Count(&taken).Error; err != nil {
+			return err
+		}
+		if taken > 0 {
+			return errEmailTaken
+		}
+
+		if err := tx.Model(&u).Updates(map[string]interface{}{
+			"email":          change.NewEmail,
+			"email_verified": true,
+		}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&change).Error
+	})
+	if errors.Is(err, errEmailTaken) {
+		c.JSON(http.StatusConflict, gin.H{"error": errEmailTaken.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to change email"})
+		return
+	}
+
+	u.Email = change.NewEmail
+	u.EmailVerified = true
+
+	c.JSON(http.StatusOK, toProfileResponse(u))
+}
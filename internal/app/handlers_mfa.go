@@ -0,0 +1,355 @@
+package app
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+
+	"traindesk/internal/totp"
+	"traindesk/internal/user"
+)
+
+const (
+	totpIssuer         = "TrainDesk"
+	totpSkew           = 1 // допускаем ±30 секунд рассинхрона часов
+	recoveryCodesCount = 10
+)
+
+// generateRecoveryCodes генерирует набор резервных кодов вида xxxxx-xxxxx.
+func generateRecoveryCodes() ([]string, error) {
+	enc := base32.StdEncoding.WithPadding(base32.NoPadding)
+
+	codes := make([]string, 0, recoveryCodesCount)
+	for i := 0; i < recoveryCodesCount; i++ {
+		var b [7]byte
+		if _, err := rand.Read(b[:]); err != nil {
+			return nil, err
+		}
+		s := strings.ToLower(enc.EncodeToString(b[:]))[:10]
+		codes = append(codes, s[:5]+"-"+s[5:])
+	}
+	return codes, nil
+}
+
+// normalizeRecoveryCode приводит введённый пользователем код к виду, в котором он хешировался.
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+	if len(code) != 10 {
+		return code
+	}
+	return code[:5] + "-" + code[5:]
+}
+
+// replaceRecoveryCodes удаляет старые резервные коды и сохраняет новые.
+func replaceRecoveryCodes(tx *gorm.DB, userID uuid.UUID) ([]string, error) {
+	codes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Where("user_id = ?", userID).Delete(&user.RecoveryCode{}).Error; err != nil {
+		return nil, err
+	}
+
+	rows := make([]user.RecoveryCode, 0, len(codes))
+	for _, code := range codes {
+		rows = append(rows, user.RecoveryCode{
+			ID:       uuid.New(),
+			UserID:   userID,
+			CodeHash: hashToken(code),
+		})
+	}
+	if err := tx.Create(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// checkSecondFactor проверяет TOTP-код или резервный код пользователя.
+// Использованный код помечается, чтобы его нельзя было предъявить повторно.
+func (a *App) checkSecondFactor(u user.User, code, recoveryCode string) (bool, error) {
+	if code != "" {
+		step, ok := totp.Validate(u.TOTPSecret, code, time.Now(), totpSkew)
+		if !ok {
+			return false, nil
+		}
+
+		res := a.db.Model(&user.User{}).
+			Where("id = ? AND totp_last_step < ?", u.ID, step).
+			Update("totp_last_step", step)
+		return res.RowsAffected == 1, res.Error
+	}
+
+	if recoveryCode != "" {
+		res := a.db.Model(&user.RecoveryCode{}).
+			Where("user_id = ? AND code_hash = ? AND used_at IS NULL", u.ID, hashToken(normalizeRecoveryCode(recoveryCode))).
+			Update("used_at", time.Now())
+		return res.RowsAffected == 1, res.Error
+	}
+
+	return false, nil
+}
+
+// loadCurrentUser загружает тренера из контекста запроса; при ошибке сам отвечает.
+func (a *App) loadCurrentUser(c *gin.Context) (user.User, bool) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return user.User{}, false
+	}
+
+	var u user.User
+	if err := a.db.Where("id = ?", userID).First(&u).Error; err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not found"})
+		return user.User{}, false
+	}
+
+	return u, true
+}
+
+// handleTOTPSetup — начать подключение 2FA: сгенерировать секрет и otpauth:// ссылку.
+// До подтверждения кодом вход по-прежнему работает только по паролю.
+func (a *App) handleTOTPSetup(c *gin.Context) {
+	u, ok := a.loadCurrentUser(c)
+	if !ok {
+		return
+	}
+
+	if u.TOTPEnabled {
+		c.JSON(http.StatusConflict, gin.H{"error": "two-factor authentication is already enabled"})
+		return
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate secret"})
+		return
+	}
+
+	if err := a.db.Model(&u).Update("totp_secret", secret).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save secret"})
+		return
+	}
+
+	c.JSON(http.StatusOK, user.TOTPSetupResponse{
+		Secret:     secret,
+		OTPAuthURI: totp.URI(totpIssuer, u.Email, secret),
+	})
+}
+
+// handleTOTPConfirm — подтвердить подключение 2FA кодом из приложения.
+// Возвращает резервные коды, они показываются один раз.
+func (a *App) handleTOTPConfirm(c *gin.Context) {
+	u, ok := a.loadCurrentUser(c)
+	if !ok {
+		return
+	}
+
+	var req user.TOTPCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.Code == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "code is required"})
+		return
+	}
+
+	if u.TOTPEnabled {
+		c.JSON(http.StatusConflict, gin.H{"error": "two-factor authentication is already enabled"})
+		return
+	}
+	if u.TOTPSecret == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "two-factor setup was not started"})
+		return
+	}
+
+	keys := a.throttleKeys(c, "mfa", u.ID.String())
+	if !a.allowAttempt(c, keys) {
+		return
+	}
+
+	step, valid := totp.Validate(u.TOTPSecret, req.Code, time.Now(), totpSkew)
+	if !valid {
+		a.recordFailure(c, keys)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid code"})
+		return
+	}
+	a.resetAttempts(c, keys)
+
+	var codes []string
+	err := a.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&u).Updates(map[string]interface{}{
+			"totp_enabled":   true,
+			"totp_last_step": step,
+		}).Error; err != nil {
+			return err
+		}
+
+		var err error
+		codes, err = replaceRecoveryCodes(tx, u.ID)
+		return err
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to enable two-factor authentication"})
+		return
+	}
+
+	c.JSON(http.StatusOK, user.RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// handleTOTPDisable — отключить 2FA. Требует пароль и второй фактор.
+func (a *App) handleTOTPDisable(c *gin.Context) {
+	u, ok := a.loadCurrentUser(c)
+	if !ok {
+		return
+	}
+
+	var req user.TOTPDisableRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid JSON"})
+		return
+	}
+
+	if !u.TOTPEnabled {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "two-factor authentication is not enabled"})
+		return
+	}
+
+	keys := a.throttleKeys(c, "mfa", u.ID.String())
+	if !a.allowAttempt(c, keys) {
+		return
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(u.PasswordHash), []byte(req.Password)); err != nil {
+		a.recordFailure(c, keys)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid password"})
+		return
+	}
+
+	valid, err := a.checkSecondFactor(u, req.Code, req.RecoveryCode)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to verify code"})
+		return
+	}
+	if !valid {
+		a.recordFailure(c, keys)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid code"})
+		return
+	}
+	a.resetAttempts(c, keys)
+
+	err = a.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&u).Updates(map[string]interface{}{
+			"totp_enabled":   false,
+			"totp_secret":    "",
+			"totp_last_step": 0,
+		}).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", u.ID).Delete(&user.RecoveryCode{}).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to disable two-factor authentication"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// handleRegenerateRecoveryCodes — выпустить новый набор резервных кодов (старые перестают действовать).
+func (a *App) handleRegenerateRecoveryCodes(c *gin.Context) {
+	u, ok := a.loadCurrentUser(c)
+	if !ok {
+		return
+	}
+
+	var req user.TOTPCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.Code == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "code is required"})
+		return
+	}
+
+	if !u.TOTPEnabled {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "two-factor authentication is not enabled"})
+		return
+	}
+
+	keys := a.throttleKeys(c, "mfa", u.ID.String())
+	if !a.allowAttempt(c, keys) {
+		return
+	}
+
+	valid, err := a.checkSecondFactor(u, req.Code, "")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to verify code"})
+		return
+	}
+	if !valid {
+		a.recordFailure(c, keys)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid code"})
+		return
+	}
+	a.resetAttempts(c, keys)
+
+	var codes []string
+	err = a.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		codes, err = replaceRecoveryCodes(tx, u.ID)
+		return err
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate recovery codes"})
+		return
+	}
+
+	c.JSON(http.StatusOK, user.RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// handleMFAVerify — второй шаг логина: обменять MFA-токен и код на пару токенов.
+func (a *App) handleMFAVerify(c *gin.Context) {
+	var req user.MFAVerifyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid JSON"})
+		return
+	}
+
+	if req.MFAToken == "" || (req.Code == "" && req.RecoveryCode == "") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "mfa_token and code or recovery_code are required"})
+		return
+	}
+
+	userID, err := a.parseMFAToken(req.MFAToken)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid or expired mfa token"})
+		return
+	}
+
+	keys := a.throttleKeys(c, "mfa", userID.String())
+	if !a.allowAttempt(c, keys) {
+		return
+	}
+
+	var u user.User
+	if err := a.db.Where("id = ?", userID).First(&u).Error; err != nil || !u.TOTPEnabled {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid or expired mfa token"})
+		return
+	}
+
+	valid, err := a.checkSecondFactor(u, req.Code, req.RecoveryCode)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to verify code"})
+		return
+	}
+	if !valid {
+		a.recordFailure(c, keys)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid code"})
+		return
+	}
+	a.resetAttempts(c, keys)
+
+	a.respondLogin(c, u)
+}
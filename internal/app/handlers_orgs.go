@@ -0,0 +1,538 @@
+package app
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"traindesk/internal/org"
+)
+
+const invitationTTL = 7 * 24 * time.Hour
+
+var errLastOwner = errors.New("organization must keep at least one owner")
+
+func toInvitationResponse(inv org.Invitation) org.InvitationResponse {
+	return org.InvitationResponse{
+		ID:        inv.ID.String(),
+		Email:     inv.Email,
+		Role:      string(inv.Role),
+		ExpiresAt: inv.ExpiresAt.Format(time.RFC3339),
+	}
+}
+
+// loadOrgMember проверяет, что текущий тренер состоит в организации :id,
+// и возвращает его членство; при ошибке сам отвечает.
+func (a *App) loadOrgMember(c *gin.Context) (member, bool) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return member{}, false
+	}
+
+	orgID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid organization id"})
+		return member{}, false
+	}
+
+	var ms org.Membership
+	if err := a.db.Where("organization_id = ? AND user_id = ?", orgID, userID).First(&ms).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "organization not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load membership"})
+		}
+		return member{}, false
+	}
+
+	return member{UserID: userID, OrgID: orgID, Role: ms.Role}, true
+}
+
+// countOwners — сколько владельцев осталось в организации.
+func countOwners(tx *gorm.DB, orgID uuid.UUID) (int64, error) {
+	var cnt int64
+	err := tx.Model(&org.Membership{}).
+		Where("organization_id = ? AND role = ?", orgID, org.RoleOwner).
+		Count(&cnt).Error
+	return cnt, err
+}
+
+// handleGetOrganizations — организации текущего тренера с его ролью в каждой.
+func (a *App) handleGetOrganizations(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var rows []struct {
+		org.Organization
+		Role org.Role
+	}
+	if err := a.db.Table("organizations").
+		Select("organizations.*, memberships.role").
+		Joins("JOIN memberships ON memberships.organization_id = organizations.id").
+		Where("memberships.user_id = ?", userID).
+		Order("organizations.personal desc, organizations.name").
+		Scan(&rows).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load organizations"})
+		return
+	}
+
+	resp := make([]org.OrganizationResponse, 0, len(rows))
+	for _, r := range rows {
+		resp = append(resp, org.OrganizationResponse{
+			ID:       r.ID.String(),
+			Name:     r.Name,
+			Personal: r.Personal,
+			Role:     string(r.Role),
+		})
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// handleCreateOrganization — создать организацию; создатель становится владельцем.
+func (a *App) handleCreateOrganization(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var req org.CreateOrganizationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid JSON"})
+		return
+	}
+
+	req.Normalize()
+	if err := req.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	o := org.Organization{
+		ID:   uuid.New(),
+		Name: req.Name,
+	}
+
+	err := a.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&o).Error; err != nil {
+			return err
+		}
+		return tx.Create(&org.Membership{
+			OrganizationID: o.ID,
+			UserID:         userID,
+			Role:           org.RoleOwner,
+		}).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create organization"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, org.OrganizationResponse{
+		ID:   o.ID.String(),
+		Name: o.Name,
+		Role: string(org.RoleOwner),
+	})
+}
+
+// handleGetMembers — участники организации.
+func (a *App) handleGetMembers(c *gin.Context) {
+	m, ok := a.loadOrgMember(c)
+	if !ok {
+		return
+	}
+
+	var rows []struct {
+		UserID      uuid.UUID
+		Email       string
+		TrainerName string
+		Role        org.Role
+	}
+	if err := a.db.Table("memberships").
+		Select("memberships.user_id, users.email, users.trainer_name, memberships.role").
+		Joins("JOIN users ON users.id = memberships.user_id").
+		Where("memberships.organization_id = ?", m.OrgID).
+		Order("memberships.created_at").
+		Scan(&rows).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load members"})
+		return
+	}
+
+	resp := make([]org.MemberResponse, 0, len(rows))
+	for _, r := range rows {
+		resp = append(resp, org.MemberResponse{
+			UserID:      r.UserID.String(),
+			Email:       r.Email,
+			TrainerName: r.TrainerName,
+			Role:        string(r.Role),
+		})
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// loadTargetMembership находит членство участника :user_id в организации m.OrgID.
+func (a *App) loadTargetMembership(c *gin.Context, m member) (org.Membership, bool) {
+	targetID, err := uuid.Parse(c.Param("user_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return org.Membership{}, false
+	}
+
+	var target org.Membership
+	if err := a.db.Where("organization_id = ? AND user_id = ?", m.OrgID, targetID).First(&target).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "member not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load member"})
+		}
+		return org.Membership{}, false
+	}
+
+	return target, true
+}
+
+// handleUpdateMember — сменить роль участника.
+// Назначать и снимать владельцев может только владелец; последнего владельца понизить нельзя.
+func (a *App) handleUpdateMember(c *gin.Context) {
+	m, ok := a.loadOrgMember(c)
+	if !ok {
+		return
+	}
+	if !requirePermission(c, m, org.PermMembersManage) {
+		return
+	}
+
+	target, ok := a.loadTargetMembership(c, m)
+	if !ok {
+		return
+	}
+
+	var req org.UpdateMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid JSON"})
+		return
+	}
+	if !org.IsValidRole(req.Role) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":         "invalid role",
+			"allowed_roles": org.ValidRoles,
+		})
+		return
+	}
+	newRole := org.Role(req.Role)
+
+	if (target.Role == org.RoleOwner || newRole == org.RoleOwner) && m.Role != org.RoleOwner {
+		c.JSON(http.StatusForbidden, gin.H{"error": "only owners can grant or revoke the owner role"})
+		return
+	}
+
+	err := a.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&target).Update("role", newRole).Error; err != nil {
+			return err
+		}
+		owners, err := countOwners(tx, m.OrgID)
+		if err != nil {
+			return err
+		}
+		if owners == 0 {
+			return errLastOwner
+		}
+		return nil
+	})
+	if errors.Is(err, errLastOwner) {
+		c.JSON(http.StatusConflict, gin.H{"error": errLastOwner.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update member"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// handleDeleteMember — исключить участника или выйти из организации самому.
+func (a *App) handleDeleteMember(c *gin.Context) {
+	m, ok := a.loadOrgMember(c)
+	if !ok {
+		return
+	}
+
+	target, ok := a.loadTargetMembership(c, m)
+	if !ok {
+		return
+	}
+
+	if target.UserID != m.UserID {
+		if !requirePermission(c, m, org.PermMembersManage) {
+			return
+		}
+		if target.Role == org.RoleOwner && m.Role != org.RoleOwner {
+			c.JSON(http.StatusForbidden, gin.H{"error": "only owners can remove owners"})
+			return
+		}
+	}
+
+	err := a.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("organization_id = ? AND user_id = ?", target.OrganizationID, target.UserID).
+			Delete(&org.Membership{}).Error; err != nil {
+			return err
+		}
+		owners, err := countOwners(tx, m.OrgID)
+		if err != nil {
+			return err
+		}
+		if owners == 0 {
+			return errLastOwner
+		}
+		return nil
+	})
+	if errors.Is(err, errLastOwner) {
+		c.JSON(http.StatusConflict, gin.H{"error": errLastOwner.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to remove member"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// handleGetInvitations — действующие приглашения организации.
+func (a *App) handleGetInvitations(c *gin.Context) {
+	m, ok := a.loadOrgMember(c)
+	if !ok {
+		return
+	}
+	if !requirePermission(c, m, org.PermMembersManage) {
+		return
+	}
+
+	var invitations []org.Invitation
+	if err := a.db.
+		Where("organization_id = ? AND accepted_at IS NULL AND expires_at > ?", m.OrgID, time.Now()).
+		Order("created_at desc").
+		Find(&invitations).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load invitations"})
+		return
+	}
+
+	resp := make([]org.InvitationResponse, 0, len(invitations))
+	for _, inv := range invitations {
+		resp = append(resp, toInvitationResponse(inv))
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// handleCreateInvitation — пригласить тренера в организацию по e-mail.
+// Токен приходит только в письме, в базе хранится его хеш.
+func (a *App) handleCreateInvitation(c *gin.Context) {
+	m, ok := a.loadOrgMember(c)
+	if !ok {
+		return
+	}
+	if !requirePermission(c, m, org.PermMembersManage) {
+		return
+	}
+
+	var req org.CreateInvitationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid JSON"})
+		return
+	}
+
+	req.Email = strings.TrimSpace(req.Email)
+	if req.Email == "" || !strings.Contains(req.Email, "@") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "email is required"})
+		return
+	}
+	if !org.IsValidRole(req.Role) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":         "invalid role",
+			"allowed_roles": org.ValidRoles,
+		})
+		return
+	}
+	if org.Role(req.Role) == org.RoleOwner && m.Role != org.RoleOwner {
+		c.JSON(http.StatusForbidden, gin.H{"error": "only owners can invite owners"})
+		return
+	}
+
+	var already int64
+	if err := a.db.Table("memberships").
+		Joins("JOIN users ON users.id = memberships.user_id").
+		Where("memberships.organization_id = ? AND lower(users.email) = lower(?)", m.OrgID, req.Email).
+		Count(&already).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to check members"})
+		return
+	}
+	if already > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "user is already a member"})
+		return
+	}
+
+	var o org.Organization
+	if err := a.db.Where("id = ?", m.OrgID).First(&o).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load organization"})
+		return
+	}
+
+	token, err := generateToken()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate token"})
+		return
+	}
+
+	inv := org.Invitation{
+		ID:             uuid.New(),
+		OrganizationID: m.OrgID,
+		Email:          req.Email,
+		Role:           org.Role(req.Role),
+		TokenHash:      hashToken(token),
+		InvitedBy:      m.UserID,
+		ExpiresAt:      time.Now().Add(invitationTTL),
+	}
+	if err := a.db.Create(&inv).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create invitation"})
+		return
+	}
+
+	if err := a.mailer.SendInvitationEmail(inv.Email, o.Name, token); err != nil {
+		log.Printf("failed to send invitation to %s: %v", inv.Email, err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "failed to send invitation email"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, toInvitationResponse(inv))
+}
+
+// handleDeleteInvitation — отозвать приглашение.
+func (a *App) handleDeleteInvitation(c *gin.Context) {
+	m, ok := a.loadOrgMember(c)
+	if !ok {
+		return
+	}
+	if !requirePermission(c, m, org.PermMembersManage) {
+		return
+	}
+
+	invID, err := uuid.Parse(c.Param("invitation_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid invitation id"})
+		return
+	}
+
+	res := a.db.Where("id = ? AND organization_id = ? AND accepted_at IS NULL", invID, m.OrgID).
+		Delete(&org.Invitation{})
+	if res.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete invitation"})
+		return
+	}
+	if res.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "invitation not found"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// handleAcceptInvitation — принять приглашение. Принять его может только
+// тренер, вошедший под тем e-mail, на который оно отправлено.
+func (a *App) handleAcceptInvitation(c *gin.Context) {
+	u, ok := a.loadCurrentUser(c)
+	if !ok {
+		return
+	}
+
+	var req org.AcceptInvitationRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.Token == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "token is required"})
+		return
+	}
+
+	var inv org.Invitation
+	if err := a.db.Where("token_hash = ?", hashToken(req.Token)).First(&inv).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid or expired invitation"})
+		return
+	}
+
+	if inv.AcceptedAt != nil || time.Now().After(inv.ExpiresAt) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid or expired invitation"})
+		return
+	}
+	if !strings.EqualFold(inv.Email, u.Email) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "invitation was sent to a different email"})
+		return
+	}
+
+	isMember, err := a.isOrgMember(inv.OrganizationID, u.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to check membership"})
+		return
+	}
+	if isMember {
+		c.JSON(http.StatusConflict, gin.H{"error": "already a member of this organization"})
+		return
+	}
+
+	var o org.Organization
+	err = a.db.Transaction(func(tx *gorm.DB) error {
+		// Условный UPDATE: приглашение принимается ровно один раз даже при гонке.
+		res := tx.Model(&org.Invitation{}).
+			Where("id = ? AND accepted_at IS NULL", inv.ID).
+			Update("accepted_at", time.Now())
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		if err := tx.Create(&org.Membership{
+			OrganizationID: inv.OrganizationID,
+			UserID:         u.ID,
+			Role:           inv.Role,
+		}).Error; err != nil {
+			return err
+		}
+		return tx.Where("id = ?", inv.OrganizationID).First(&o).Error
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid or expired invitation"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to accept invitation"})
+		return
+	}
+
+	c.JSON(http.StatusOK, org.OrganizationResponse{
+		ID:       o.ID.String(),
+		Name:     o.Name,
+		Personal: o.Personal,
+		Role:     string(inv.Role),
+	})
+}
+
+// soleOwnedSharedOrgs — организации, где userID единственный владелец,
+// но есть и другие участники. Такие организации нельзя оставить без владельца.
+func soleOwnedSharedOrgs(tx *gorm.DB, userID uuid.UUID) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	err := tx.Model(&org.Membership{}).
+		Where("user_id = ? AND role = ?", userID, org.RoleOwner).
+		Where("NOT EXISTS (SELECT 1 FROM memberships o WHERE o.organization_id = memberships.organization_id AND o.user_id <> ? AND o.role = ?)", userID, org.RoleOwner).
+		Where("EXISTS (SELECT 1 FROM memberships o WHERE o.organization_id = memberships.organization_id AND o.user_id <> ?)", userID).
+		Pluck("organization_id", &ids).Error
+	return ids, err
+}
@@ -0,0 +1,298 @@
+package app
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"traindesk/internal/org"
+	"traindesk/internal/pagination"
+	"traindesk/internal/tz"
+	"traindesk/internal/workout"
+)
+
+// loadParticipants возвращает участников тренировок с их итогами и
+// результатами по подходам. Если clientID задан — только этого клиента.
+func (a *App) loadParticipants(workoutIDs []uuid.UUID, clientID *uuid.UUID) (map[uuid.UUID][]workout.ParticipantResponse, error) {
+	result := make(map[uuid.UUID][]workout.ParticipantResponse)
+	if len(workoutIDs) == 0 {
+		return result, nil
+	}
+
+	var rows []struct {
+		workout.WorkoutClient
+		FirstName string
+		LastName  string
+	}
+	q := a.db.Table("workout_clients").
+		Select("workout_clients.*, clients.first_name, clients.last_name").
+		Joins("JOIN clients ON clients.id = workout_clients.client_id").
+		Where("workout_clients.workout_id IN ?", workoutIDs).
+		Order("clients.last_name, clients.first_name")
+	if clientID != nil {
+		q = q.Where("workout_clients.client_id = ?", *clientID)
+	}
+	if err := q.Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	var results []workout.ClientSetResult
+	rq := a.db.Where("workout_id IN ?", workoutIDs).Order("block_position, set_position")
+	if clientID != nil {
+		rq = rq.Where("client_id = ?", *clientID)
+	}
+	if err := rq.Find(&results).Error; err != nil {
+		return nil, err
+	}
+	type key struct{ workoutID, clientID uuid.UUID }
+	byParticipant := make(map[key][]workout.SetResult)
+	for _, r := range results {
+		k := key{r.WorkoutID, r.ClientID}
+		byParticipant[k] = append(byParticipant[k], r.ToSetResult())
+	}
+
+	for _, r := range rows {
+		setResults := byParticipant[key{r.WorkoutID, r.ClientID}]
+		if setResults == nil {
+			setResults = []workout.SetResult{}
+		}
+		result[r.WorkoutID] = append(result[r.WorkoutID], workout.ParticipantResponse{
+			ClientID:           r.ClientID.String(),
+			FirstName:          r.FirstName,
+			LastName:           r.LastName,
+			Attendance:         string(r.Attendance),
+			AttendanceReason:   r.AttendanceReason,
+			BookedAt:           r.BookedAt,
+			AttendanceMarkedAt: r.AttendanceMarkedAt,
+			RPE:                r.RPE,
+			Comment:            r.Comment,
+			Results:            setResults,
+		})
+	}
+
+	return result, nil
+}
+
+// handleGetParticipants — участники тренировки с личными итогами.
+func (a *App) handleGetParticipants(c *gin.Context) {
+	m, ok := currentMember(c)
+	if !ok {
+		return
+	}
+
+	w, ok := a.loadWorkout(c, m)
+	if !ok {
+		return
+	}
+
+	participants, err := a.loadParticipants([]uuid.UUID{w.ID}, nil)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load participants"})
+		return
+	}
+
+	resp := participants[w.ID]
+	if resp == nil {
+		resp = []workout.ParticipantResponse{}
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// handlePutParticipant — записать личный итог клиента в тренировке:
+// посещение, RPE, комментарий и фактические результаты по подходам.
+// Результаты заменяются целиком.
+func (a *App) handlePutParticipant(c *gin.Context) {
+	m, ok := currentMember(c)
+	if !ok {
+		return
+	}
+
+	w, ok := a.loadWorkout(c, m)
+	if !ok {
+		return
+	}
+	if !canEditWorkout(m, w) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "not allowed to edit this workout"})
+		return
+	}
+
+	clientID, err := uuid.Parse(c.Param("client_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid client id"})
+		return
+	}
+
+	var link workout.WorkoutClient
+	if err := a.db.Where("workout_id = ? AND client_id = ?", w.ID, clientID).First(&link).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "client is not a participant of this workout"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load participant"})
+		}
+		return
+	}
+
+	var req workout.ParticipantRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid JSON"})
+		return
+	}
+
+	setCounts, exerciseIDs, err := a.workoutSetCounts(w.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load workout structure"})
+		return
+	}
+	if err := workout.ValidateParticipant(req, setCounts); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err = a.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&workout.WorkoutClient{}).
+			Where("workout_id = ? AND client_id = ?", w.ID, clientID).
+			Updates(map[string]interface{}{
+				"rpe":     req.RPE,
+				"comment": req.Comment,
+			}).Error; err != nil {
+			return err
+		}
+		if req.Attendance != "" {
+			status := workout.AttendanceStatus(req.Attendance)
+			if err := markAttendance(tx, w.ID, []uuid.UUID{clientID}, status, req.AttendanceReason, m.UserID); err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Where("workout_id = ? AND client_id = ?", w.ID, clientID).
+			Delete(&workout.ClientSetResult{}).Error; err != nil {
+			return err
+		}
+		if len(req.Results) == 0 {
+			return nil
+		}
+
+		rows := make([]workout.ClientSetResult, 0, len(req.Results))
+		for _, r := range req.Results {
+			rows = append(rows, workout.ClientSetResult{
+				WorkoutID:     w.ID,
+				ClientID:      clientID,
+				BlockPosition: r.ExerciseIndex,
+				ExerciseID:    exerciseIDs[r.ExerciseIndex],
+				SetPosition:   r.SetIndex,
+				Reps:          r.Reps,
+				WeightKg:      r.WeightKg,
+				DurationSec:   r.DurationSec,
+				DistanceM:     r.DistanceM,
+			})
+		}
+		return tx.Create(&rows).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save participant"})
+		return
+	}
+
+	participants, err := a.loadParticipants([]uuid.UUID{w.ID}, &clientID)
+	if err != nil || len(participants[w.ID]) == 0 {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load participant"})
+		return
+	}
+
+	c.JSON(http.StatusOK, participants[w.ID][0])
+}
+
+// workoutSetCounts — число подходов в каждом упражнении тренировки по порядку
+// и сами упражнения.
+func (a *App) workoutSetCounts(workoutID uuid.UUID) ([]int, []uuid.UUID, error) {
+	var rows []struct {
+		Position   int
+		ExerciseID uuid.UUID
+		Sets       int
+	}
+	if err := a.db.Table("workout_blocks b").
+		Select("b.position, b.exercise_id, count(s.id) AS sets").
+		Joins("LEFT JOIN workout_sets s ON s.block_id = b.id").
+		Where("b.workout_id = ?", workoutID).
+		Group("b.position, b.exercise_id").
+		Order("b.position").
+		Scan(&rows).Error; err != nil {
+		return nil, nil, err
+	}
+
+	counts := make([]int, len(rows))
+	exerciseIDs := make([]uuid.UUID, len(rows))
+	for i, r := range rows {
+		counts[i] = r.Sets
+		exerciseIDs[i] = r.ExerciseID
+	}
+	return counts, exerciseIDs, nil
+}
+
+// handleGetClientLog — дневник тренировок клиента: тренировки с упражнениями
+// и его личными итогами, от новых к старым. Поддерживает from, to, limit, cursor.
+func (a *App) handleGetClientLog(c *gin.Context) {
+	m, ok := currentMember(c)
+	if !ok {
+		return
+	}
+	if !requirePermission(c, m, org.PermClientsRead) || !requirePermission(c, m, org.PermWorkoutsReadOwn) {
+		return
+	}
+
+	cl, ok := a.loadClient(c, m)
+	if !ok {
+		return
+	}
+
+	limit, err := pagination.ParseLimit(c.Query("limit"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	q := scopeWorkouts(a.db.DB, m).Where("workouts.id IN (?)",
+		a.db.Model(&workout.WorkoutClient{}).Select("workout_id").Where("client_id = ?", cl.ID))
+	q, ok = filterWorkoutDates(c, q, tz.LoadOrUTC(a.userTimeZone(m.UserID)))
+	if !ok {
+		return
+	}
+
+	workoutsDB, nextCursor, ok := pageWorkouts(c, q, limit)
+	if !ok {
+		return
+	}
+
+	workoutIDs := make([]uuid.UUID, 0, len(workoutsDB))
+	for _, w := range workoutsDB {
+		workoutIDs = append(workoutIDs, w.ID)
+	}
+
+	details, err := a.loadWorkoutDetails(workoutIDs, workoutEmbeds{exercises: true})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load workout details"})
+		return
+	}
+	participants, err := a.loadParticipants(workoutIDs, &cl.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load participants"})
+		return
+	}
+
+	page := pagination.Page[workout.TrainingLogEntry]{
+		Items:      make([]workout.TrainingLogEntry, 0, len(workoutsDB)),
+		NextCursor: nextCursor,
+	}
+	for _, w := range workoutsDB {
+		entry := workout.TrainingLogEntry{Workout: details.response(w)}
+		if p := participants[w.ID]; len(p) > 0 {
+			entry.Participant = p[0]
+		}
+		page.Items = append(page.Items, entry)
+	}
+
+	c.JSON(http.StatusOK, page)
+}
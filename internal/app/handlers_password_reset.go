@@ -0,0 +1,183 @@
+package app
+
+import (
+	"crypto/subtle"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+
+	"traindesk/internal/user"
+)
+
+const (
+	passwordResetTTL         = 30 * time.Minute
+	passwordResetMaxAttempts = 5
+)
+
+// handleForgotPassword — отправить на почту код для сброса пароля.
+// Отвечает одинаково независимо от того, существует ли аккаунт,
+// чтобы по ответу нельзя было перебирать зарегистрированные адреса.
+func (a *App) handleForgotPassword(c *gin.Context) {
+	var req user.ForgotPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.Email == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "email is required"})
+		return
+	}
+
+	resp := gin.H{"message": "if the account exists, a reset code has been sent"}
+
+	var u user.User
+	if err := a.db.Where("email = ?", req.Email).First(&u).Error; err != nil {
+		c.JSON(http.StatusOK, resp)
+		return
+	}
+
+	// Повторный запрос вскоре после предыдущего не шлёт письмо и не меняет
+	// код: иначе адрес можно заваливать письмами, а счётчик попыток —
+	// обнулять новым кодом. Ответ тот же, чтобы не выдавать аккаунт.
+	var recent int64
+	if err := a.db.Model(&user.PasswordReset{}).
+		Where("user_id = ? AND used_at IS NULL AND created_at > ?", u.ID, time.Now().Add(-verificationResendCooldown)).
+		Count(&recent).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create reset code"})
+		return
+	}
+	if recent > 0 {
+		c.JSON(http.StatusOK, resp)
+		return
+	}
+
+	code, err := generateVerificationCode()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate reset code"})
+		return
+	}
+
+	reset := user.PasswordReset{
+		UserID:    u.ID,
+		CodeHash:  hashToken(code),
+		ExpiresAt: time.Now().Add(passwordResetTTL),
+	}
+
+	err = a.db.Transaction(func(tx *gorm.DB) error {
+		// Новый код делает недействительными все предыдущие.
+		if err := tx.Where("user_id = ? AND used_at IS NULL", u.ID).Delete(&user.PasswordReset{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&reset).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create reset code"})
+		return
+	}
+
+	if err := a.mailer.SendPasswordResetEmail(u.Email, code); err != nil {
+		log.Printf("failed to send password reset email to %s: %v", u.Email, err)
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// handleResetPassword — установить новый пароль по коду из письма.
+// После смены пароля все сессии пользователя отзываются.
+func (a *App) handleResetPassword(c *gin.Context) {
+	var req user.ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid JSON"})
+		return
+	}
+
+	if req.Email == "" || req.Code == "" || len(req.NewPassword) < 6 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "email, code and new_password (>=6) are required",
+		})
+		return
+	}
+
+	keys := a.throttleKeys(c, "reset-password", req.Email)
+	if !a.allowAttempt(c, keys) {
+		return
+	}
+
+	var u user.User
+	if err := a.db.Where("email = ?", req.Email).First(&u).Error; err != nil {
+		a.recordFailure(c, keys)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid or expired code"})
+		return
+	}
+
+	var reset user.PasswordReset
+	if err := a.db.
+		Where("user_id = ? AND used_at IS NULL", u.ID).
+		Order("created_at desc").
+		First(&reset).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid or expired code"})
+		return
+	}
+
+	if time.Now().After(reset.ExpiresAt) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid or expired code"})
+		return
+	}
+
+	// Попытка списывается до сравнения кода одним условным UPDATE:
+	// параллельные запросы не смогут проверить больше кодов, чем разрешено.
+	res := a.db.Model(&user.PasswordReset{}).
+		Where("id = ? AND attempts < ?", reset.ID, passwordResetMaxAttempts).
+		UpdateColumn("attempts", gorm.Expr("attempts + 1"))
+	if res.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to reset password"})
+		return
+	}
+	if res.RowsAffected == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid or expired code"})
+		return
+	}
+
+	if subtle.ConstantTimeCompare([]byte(hashToken(req.Code)), []byte(reset.CodeHash)) != 1 {
+		a.recordFailure(c, keys)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid or expired code"})
+		return
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), 10)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to hash password"})
+		return
+	}
+
+	err = a.db.Transaction(func(tx *gorm.DB) error {
+		// Условный UPDATE гарантирует, что код сработает ровно один раз.
+		res := tx.Model(&user.PasswordReset{}).
+			Where("id = ? AND used_at IS NULL", reset.ID).
+			Update("used_at", time.Now())
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		if err := tx.Model(&u).Update("password_hash", string(hash)).Error; err != nil {
+			return err
+		}
+
+		return revokeUserSessions(tx, u.ID)
+	})
+	if err == gorm.ErrRecordNotFound {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid or expired code"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to reset password"})
+		return
+	}
+
+	a.resetAttempts(c, keys)
+
+	c.JSON(http.StatusOK, gin.H{"message": "password_reset"})
+}
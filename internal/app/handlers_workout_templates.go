@@ -0,0 +1,471 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"traindesk/internal/client"
+	"traindesk/internal/exercise"
+	"traindesk/internal/org"
+	"traindesk/internal/workout"
+)
+
+// scopeTemplates ограничивает запрос шаблонами, которые участник вправе видеть:
+// как и с тренировками, тренер видит свои, руководство — все шаблоны организации.
+func scopeTemplates(q *gorm.DB, m member) *gorm.DB {
+	q = q.Where("workout_templates.organization_id = ?", m.OrgID)
+	if !m.can(org.PermWorkoutsReadAll) {
+		q = q.Where("workout_templates.user_id = ?", m.UserID)
+	}
+	return q
+}
+
+// canEditTemplate — может ли участник менять или удалять шаблон t.
+func canEditTemplate(m member, t workout.Template) bool {
+	if m.can(org.PermWorkoutsWriteAll) {
+		return true
+	}
+	return m.can(org.PermWorkoutsWriteOwn) && t.UserID == m.UserID
+}
+
+// requireWorkoutsWrite отвечает 403, если участник не может создавать тренировки.
+func requireWorkoutsWrite(c *gin.Context, m member) bool {
+	if !m.can(org.PermWorkoutsWriteOwn) && !m.can(org.PermWorkoutsWriteAll) {
+		return requirePermission(c, m, org.PermWorkoutsWriteOwn)
+	}
+	return true
+}
+
+// loadTemplate находит видимый участнику шаблон по :id; при ошибке сам отвечает.
+func (a *App) loadTemplate(c *gin.Context, m member) (workout.Template, bool) {
+	templateID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid template id"})
+		return workout.Template{}, false
+	}
+
+	var t workout.Template
+	if err := scopeTemplates(a.db.Where("workout_templates.id = ?", templateID), m).First(&t).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "template not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load template"})
+		}
+		return workout.Template{}, false
+	}
+
+	return t, true
+}
+
+// bindTemplate разбирает и проверяет тело запроса и переносит его в шаблон t;
+// при ошибке сам отвечает.
+func (a *App) bindTemplate(c *gin.Context, m member, t *workout.Template) bool {
+	var req workout.TemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid JSON"})
+		return false
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	req.Room = strings.TrimSpace(req.Room)
+	if err := req.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return false
+	}
+	if utf8.RuneCountInString(req.Room) > maxRoomLen {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("room must be at most %d characters", maxRoomLen)})
+		return false
+	}
+
+	wtype, ok := a.resolveWorkoutType(c, m, req.TypeID, req.Type)
+	if !ok {
+		return false
+	}
+	clientIDs, ok := a.parseWorkoutClients(c, m, uuid.Nil, req.ClientIDs)
+	if !ok {
+		return false
+	}
+	if _, ok := a.parseWorkoutExercises(c, m, req.Exercises); !ok {
+		return false
+	}
+
+	t.Name = req.Name
+	t.TypeID = wtype.ID
+	t.Type = workout.WorkoutType(wtype.Name)
+	t.DurationMin = req.DurationMin
+	t.Notes = req.Notes
+	t.Room = req.Room
+	t.ClientIDs = clientIDs
+	t.Exercises = req.Exercises
+	return true
+}
+
+// templateResponses собирает ответы по шаблонам; названия упражнений
+// подтягиваются одним запросом на весь набор.
+func (a *App) templateResponses(ts []workout.Template) ([]workout.TemplateResponse, error) {
+	unique := make(map[uuid.UUID]bool)
+	for _, t := range ts {
+		for _, b := range t.Exercises {
+			if id, err := uuid.Parse(b.ExerciseID); err == nil {
+				unique[id] = true
+			}
+		}
+	}
+	names := make(map[string]string, len(unique))
+	if len(unique) > 0 {
+		ids := make([]uuid.UUID, 0, len(unique))
+		for id := range unique {
+			ids = append(ids, id)
+		}
+		var exercises []exercise.Exercise
+		if err := a.db.Select("id", "name").Where("id IN ?", ids).Find(&exercises).Error; err != nil {
+			return nil, err
+		}
+		for _, ex := range exercises {
+			names[ex.ID.String()] = ex.Name
+		}
+	}
+
+	resp := make([]workout.TemplateResponse, 0, len(ts))
+	for _, t := range ts {
+		clientIDs := make([]string, 0, len(t.ClientIDs))
+		for _, id := range t.ClientIDs {
+			clientIDs = append(clientIDs, id.String())
+		}
+		blocks := make([]workout.ExerciseBlockResponse, 0, len(t.Exercises))
+		for _, b := range t.Exercises {
+			sets := b.Sets
+			if sets == nil {
+				sets = []workout.SetData{}
+			}
+			blocks = append(blocks, workout.ExerciseBlockResponse{
+				ExerciseID:   b.ExerciseID,
+				ExerciseName: names[b.ExerciseID],
+				Notes:        b.Notes,
+				Sets:         sets,
+			})
+		}
+		resp = append(resp, workout.TemplateResponse{
+			ID:          t.ID.String(),
+			Name:        t.Name,
+			TypeID:      t.TypeID.String(),
+			Type:        string(t.Type),
+			DurationMin: t.DurationMin,
+			Notes:       t.Notes,
+			Room:        t.Room,
+			ClientIDs:   clientIDs,
+			Exercises:   blocks,
+			TrainerID:   t.UserID.String(),
+			CreatedAt:   t.CreatedAt.UTC().Format(time.RFC3339),
+			UpdatedAt:   t.UpdatedAt.UTC().Format(time.RFC3339),
+		})
+	}
+	return resp, nil
+}
+
+// respondTemplate отдаёт один шаблон.
+func (a *App) respondTemplate(c *gin.Context, status int, t workout.Template) {
+	resp, err := a.templateResponses([]workout.Template{t})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load template exercises"})
+		return
+	}
+	c.JSON(status, resp[0])
+}
+
+// handleGetTemplates — шаблоны тренировок, видимые участнику, по названию.
+// Параметр q — часть названия.
+func (a *App) handleGetTemplates(c *gin.Context) {
+	m, ok := currentMember(c)
+	if !ok {
+		return
+	}
+	if !requirePermission(c, m, org.PermWorkoutsReadOwn) {
+		return
+	}
+
+	q := scopeTemplates(a.db.DB, m)
+	if search := strings.TrimSpace(c.Query("q")); search != "" {
+		q = q.Where("workout_templates.name ILIKE ?", "%"+escapeLike(search)+"%")
+	}
+
+	var ts []workout.Template
+	if err := q.Order("lower(workout_templates.name), workout_templates.id").Find(&ts).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load templates"})
+		return
+	}
+
+	resp, err := a.templateResponses(ts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load template exercises"})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+func (a *App) handleGetTemplate(c *gin.Context) {
+	m, ok := currentMember(c)
+	if !ok {
+		return
+	}
+	if !requirePermission(c, m, org.PermWorkoutsReadOwn) {
+		return
+	}
+
+	t, ok := a.loadTemplate(c, m)
+	if !ok {
+		return
+	}
+
+	a.respondTemplate(c, http.StatusOK, t)
+}
+
+// handleCreateTemplate — сохранить шаблон тренировки текущего тренера.
+func (a *App) handleCreateTemplate(c *gin.Context) {
+	m, ok := currentMember(c)
+	if !ok {
+		return
+	}
+	if !requireWorkoutsWrite(c, m) {
+		return
+	}
+
+	t := workout.Template{
+		ID:             uuid.New(),
+		OrganizationID: m.OrgID,
+		UserID:         m.UserID,
+	}
+	if !a.bindTemplate(c, m, &t) {
+		return
+	}
+
+	if err := a.db.Create(&t).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create template"})
+		return
+	}
+
+	a.respondTemplate(c, http.StatusCreated, t)
+}
+
+// handleUpdateTemplate — заменить шаблон целиком. Созданные из него
+// тренировки не меняются.
+func (a *App) handleUpdateTemplate(c *gin.Context) {
+	m, ok := currentMember(c)
+	if !ok {
+		return
+	}
+
+	t, ok := a.loadTemplate(c, m)
+	if !ok {
+		return
+	}
+	if !canEditTemplate(m, t) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "not allowed to edit this template"})
+		return
+	}
+
+	if !a.bindTemplate(c, m, &t) {
+		return
+	}
+
+	if err := a.db.Save(&t).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update template"})
+		return
+	}
+
+	a.respondTemplate(c, http.StatusOK, t)
+}
+
+func (a *App) handleDeleteTemplate(c *gin.Context) {
+	m, ok := currentMember(c)
+	if !ok {
+		return
+	}
+
+	t, ok := a.loadTemplate(c, m)
+	if !ok {
+		return
+	}
+	if !canEditTemplate(m, t) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "not allowed to delete this template"})
+		return
+	}
+
+	if err := a.db.Delete(&t).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete template"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// templateWorkoutRequest — запрос на создание тренировки по шаблону. Из
+// постоянных участников остаются только те, кто ещё не в архиве.
+func (a *App) templateWorkoutRequest(m member, t workout.Template) (workout.CreateWorkoutRequest, error) {
+	req := workout.CreateWorkoutRequest{
+		DurationMin: t.DurationMin,
+		TypeID:      t.TypeID.String(),
+		Notes:       t.Notes,
+		Room:        t.Room,
+		ClientIDs:   []string{},
+		Exercises:   t.Exercises,
+	}
+
+	if len(t.ClientIDs) > 0 {
+		var active []uuid.UUID
+		if err := a.db.Model(&client.Client{}).
+			Where("organization_id = ? AND id IN ? AND archived_at IS NULL", m.OrgID, t.ClientIDs).
+			Pluck("id", &active).Error; err != nil {
+			return req, err
+		}
+		isActive := make(map[uuid.UUID]bool, len(active))
+		for _, id := range active {
+			isActive[id] = true
+		}
+		for _, id := range t.ClientIDs {
+			if isActive[id] {
+				req.ClientIDs = append(req.ClientIDs, id.String())
+			}
+		}
+	}
+	return req, nil
+}
+
+// handleCreateWorkoutFromTemplate — создать тренировку по шаблону.
+//
+// Тело — те же поля, что при создании тренировки: время (starts_at, date,
+// ends_at, time_zone) обязательно, остальные поля, если переданы, заменяют
+// значения из шаблона (client_ids: [] — без участников). ends_at без
+// duration_min отменяет длительность шаблона. Параметр force — как при создании.
+func (a *App) handleCreateWorkoutFromTemplate(c *gin.Context) {
+	m, ok := currentMember(c)
+	if !ok {
+		return
+	}
+	if !requireWorkoutsWrite(c, m) {
+		return
+	}
+
+	t, ok := a.loadTemplate(c, m)
+	if !ok {
+		return
+	}
+
+	req, err := a.templateWorkoutRequest(m, t)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load template clients"})
+		return
+	}
+
+	body, err := c.GetRawData()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "failed to read request body"})
+		return
+	}
+	var present map[string]json.RawMessage
+	if err := json.Unmarshal(body, &present); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid JSON"})
+		return
+	}
+	// Вид по названию заменяет вид шаблона, если не указан и type_id.
+	if _, ok := present["type"]; ok {
+		req.TypeID = ""
+	}
+	if _, ok := present["ends_at"]; ok {
+		req.DurationMin = 0
+	}
+	if err := json.Unmarshal(body, &req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid JSON"})
+		return
+	}
+
+	in, ok := a.checkWorkoutInput(c, m, nil, req)
+	if !ok {
+		return
+	}
+	a.createWorkout(c, m, in)
+}
+
+// handleSaveWorkoutAsTemplate — сохранить тренировку как шаблон текущего тренера:
+// вид, длительность, заметки, зал, упражнения с подходами и участники (кроме
+// архивных). Тело необязательно: name — название, по умолчанию — вид тренировки.
+func (a *App) handleSaveWorkoutAsTemplate(c *gin.Context) {
+	m, ok := currentMember(c)
+	if !ok {
+		return
+	}
+	if !requireWorkoutsWrite(c, m) {
+		return
+	}
+
+	w, ok := a.loadWorkout(c, m)
+	if !ok {
+		return
+	}
+
+	var req workout.SaveTemplateRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid JSON"})
+			return
+		}
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		req.Name = workoutTypeTitle(w.Type)
+	}
+
+	t := workout.Template{
+		ID:             uuid.New(),
+		OrganizationID: m.OrgID,
+		UserID:         m.UserID,
+		Name:           req.Name,
+		TypeID:         w.TypeID,
+		Type:           w.Type,
+		Notes:          w.Notes,
+		Room:           w.Room,
+		ClientIDs:      []uuid.UUID{},
+		Exercises:      []workout.ExerciseBlockInput{},
+	}
+	if !w.AllDay {
+		t.DurationMin = w.DurationMin
+	}
+	if err := (workout.TemplateRequest{Name: t.Name}).Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	details, err := a.loadWorkoutDetails([]uuid.UUID{w.ID}, workoutEmbeds{clients: true, exercises: true})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load workout details"})
+		return
+	}
+	for _, cl := range details.clients[w.ID] {
+		if id, err := uuid.Parse(cl.ID); err == nil && !cl.Archived {
+			t.ClientIDs = append(t.ClientIDs, id)
+		}
+	}
+	for _, b := range details.exercises[w.ID] {
+		t.Exercises = append(t.Exercises, workout.ExerciseBlockInput{
+			ExerciseID: b.ExerciseID,
+			Notes:      b.Notes,
+			Sets:       b.Sets,
+		})
+	}
+
+	if err := a.db.Create(&t).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create template"})
+		return
+	}
+
+	a.respondTemplate(c, http.StatusCreated, t)
+}
@@ -0,0 +1,295 @@
+package app
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+
+	"traindesk/internal/org"
+	"traindesk/internal/workout"
+)
+
+func toWorkoutTypeResponse(t workout.Type) workout.TypeResponse {
+	return workout.TypeResponse{
+		ID:                 t.ID.String(),
+		Name:               t.Name,
+		Color:              t.Color,
+		DefaultDurationMin: t.DefaultDurationMin,
+		DefaultPriceCents:  t.DefaultPriceCents,
+		Global:             t.IsGlobal(),
+	}
+}
+
+// respondInvalidType — 400 со списком видов, доступных организации.
+func respondInvalidType(c *gin.Context, msg string, catalog workout.Catalog) {
+	c.JSON(http.StatusBadRequest, gin.H{
+		"error":         msg,
+		"allowed_types": catalog.Names(),
+	})
+}
+
+// resolveWorkoutType находит вид тренировки по type_id или, если его нет,
+// по названию среди видов организации участника; при ошибке сам отвечает.
+func (a *App) resolveWorkoutType(c *gin.Context, m member, typeID, name string) (workout.Type, bool) {
+	catalog, err := workout.LoadCatalog(a.db.DB, m.OrgID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load workout types"})
+		return workout.Type{}, false
+	}
+
+	var (
+		t  workout.Type
+		ok bool
+	)
+	if typeID != "" {
+		if id, err := uuid.Parse(typeID); err == nil {
+			t, ok = catalog.Get(id)
+		}
+		if byName, found := catalog.Find(name); ok && name != "" && (!found || byName.ID != t.ID) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "type does not match type_id"})
+			return workout.Type{}, false
+		}
+	} else {
+		t, ok = catalog.Find(name)
+	}
+	if !ok {
+		respondInvalidType(c, "invalid workout type", catalog)
+		return workout.Type{}, false
+	}
+	return t, true
+}
+
+// canEditWorkoutType — может ли участник менять или удалять вид t организации:
+// свой вид — любой тренер, чужой — только тот, кто правит все тренировки.
+func canEditWorkoutType(m member, t workout.Type) bool {
+	if m.can(org.PermWorkoutsWriteAll) {
+		return true
+	}
+	return t.CreatedBy != nil && *t.CreatedBy == m.UserID
+}
+
+// isUniqueViolation — ошибка Postgres о нарушении уникального индекса.
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
+
+// loadOrgWorkoutType находит вид тренировки организации по :id для изменения.
+// Общие виды видны всем, но менять их нельзя, как и чужие виды без права
+// на все тренировки; при ошибке сам отвечает.
+func (a *App) loadOrgWorkoutType(c *gin.Context, m member) (workout.Type, bool) {
+	typeID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid workout type id"})
+		return workout.Type{}, false
+	}
+
+	var t workout.Type
+	if err := a.db.Where("id = ? AND (organization_id IS NULL OR organization_id = ?)", typeID, m.OrgID).
+		First(&t).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "workout type not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load workout type"})
+		}
+		return workout.Type{}, false
+	}
+
+	if t.IsGlobal() {
+		c.JSON(http.StatusForbidden, gin.H{"error": "global workout types are read-only"})
+		return workout.Type{}, false
+	}
+	if !canEditWorkoutType(m, t) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "only the trainer who created this workout type can change it"})
+		return workout.Type{}, false
+	}
+
+	return t, true
+}
+
+// bindWorkoutTypeRequest разбирает и проверяет тело запроса. Название не должно
+// совпадать с другим видом организации или общим видом (кроме самого вида
+// selfID); при ошибке сам отвечает.
+func (a *App) bindWorkoutTypeRequest(c *gin.Context, m member, selfID uuid.UUID) (workout.TypeRequest, bool) {
+	var req workout.TypeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid JSON"})
+		return req, false
+	}
+	req.Normalize()
+	if err := req.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return req, false
+	}
+
+	catalog, err := workout.LoadCatalog(a.db.DB, m.OrgID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load workout types"})
+		return req, false
+	}
+	if t, ok := catalog.Find(req.Name); ok && t.ID != selfID {
+		c.JSON(http.StatusConflict, gin.H{"error": "workout type with this name already exists", "id": t.ID.String()})
+		return req, false
+	}
+
+	return req, true
+}
+
+// handleGetWorkoutTypes — справочник видов тренировок: общие и виды организации.
+func (a *App) handleGetWorkoutTypes(c *gin.Context) {
+	m, ok := currentMember(c)
+	if !ok {
+		return
+	}
+	if !requirePermission(c, m, org.PermWorkoutsReadOwn) {
+		return
+	}
+
+	catalog, err := workout.LoadCatalog(a.db.DB, m.OrgID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load workout types"})
+		return
+	}
+
+	resp := make([]workout.TypeResponse, 0, len(catalog))
+	for _, t := range catalog {
+		resp = append(resp, toWorkoutTypeResponse(t))
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// handleCreateWorkoutType — добавить вид тренировки в справочник организации.
+func (a *App) handleCreateWorkoutType(c *gin.Context) {
+	m, ok := currentMember(c)
+	if !ok {
+		return
+	}
+	if !requirePermission(c, m, org.PermWorkoutTypesWrite) {
+		return
+	}
+
+	req, ok := a.bindWorkoutTypeRequest(c, m, uuid.Nil)
+	if !ok {
+		return
+	}
+
+	orgID, userID := m.OrgID, m.UserID
+	t := workout.Type{
+		ID:                 uuid.New(),
+		OrganizationID:     &orgID,
+		CreatedBy:          &userID,
+		Name:               req.Name,
+		Color:              req.Color,
+		DefaultDurationMin: req.DefaultDurationMin,
+		DefaultPriceCents:  req.DefaultPriceCents,
+	}
+	if err := a.db.Create(&t).Error; err != nil {
+		if isUniqueViolation(err) {
+			c.JSON(http.StatusConflict, gin.H{"error": "workout type with this name already exists"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create workout type"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, toWorkoutTypeResponse(t))
+}
+
+// handleUpdateWorkoutType — изменить вид тренировки организации. Новое
+// название сразу переходит в тренировки, серии и шаблоны этого вида.
+func (a *App) handleUpdateWorkoutType(c *gin.Context) {
+	m, ok := currentMember(c)
+	if !ok {
+		return
+	}
+	if !requirePermission(c, m, org.PermWorkoutTypesWrite) {
+		return
+	}
+
+	t, ok := a.loadOrgWorkoutType(c, m)
+	if !ok {
+		return
+	}
+
+	req, ok := a.bindWorkoutTypeRequest(c, m, t.ID)
+	if !ok {
+		return
+	}
+
+	renamed := req.Name != t.Name
+	t.Name = req.Name
+	t.Color = req.Color
+	t.DefaultDurationMin = req.DefaultDurationMin
+	t.DefaultPriceCents = req.DefaultPriceCents
+	err := a.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&t).Error; err != nil {
+			return err
+		}
+		if !renamed {
+			return nil
+		}
+		if err := tx.Model(&workout.Workout{}).Where("type_id = ?", t.ID).
+			UpdateColumn("type", t.Name).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&workout.WorkoutSeries{}).Where("type_id = ?", t.ID).
+			UpdateColumn("type", t.Name).Error; err != nil {
+			return err
+		}
+		return tx.Model(&workout.Template{}).Where("type_id = ?", t.ID).
+			UpdateColumn("type", t.Name).Error
+	})
+	if isUniqueViolation(err) {
+		c.JSON(http.StatusConflict, gin.H{"error": "workout type with this name already exists"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update workout type"})
+		return
+	}
+
+	c.JSON(http.StatusOK, toWorkoutTypeResponse(t))
+}
+
+// handleDeleteWorkoutType — удалить вид тренировки организации, если он нигде не используется.
+func (a *App) handleDeleteWorkoutType(c *gin.Context) {
+	m, ok := currentMember(c)
+	if !ok {
+		return
+	}
+	if !requirePermission(c, m, org.PermWorkoutTypesWrite) {
+		return
+	}
+
+	t, ok := a.loadOrgWorkoutType(c, m)
+	if !ok {
+		return
+	}
+
+	var used int64
+	for _, model := range []interface{}{&workout.Workout{}, &workout.WorkoutSeries{}, &workout.Template{}} {
+		if err := a.db.Model(model).Where("type_id = ?", t.ID).Count(&used).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to check workout type usage"})
+			return
+		}
+		if used > 0 {
+			break
+		}
+	}
+	if used > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "workout type is used in workouts or templates"})
+		return
+	}
+
+	if err := a.db.Delete(&t).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete workout type"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
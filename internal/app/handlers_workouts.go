@@ -1,6 +1,7 @@
 package app
 
 import (
+	"errors"
 	"net/http"
 	"time"
 
@@ -8,432 +9,416 @@ import (
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 
-	"traindesk/internal/client"
+	"traindesk/internal/org"
+	"traindesk/internal/pagination"
+	"traindesk/internal/tz"
 	"traindesk/internal/workout"
 )
 
-// handleCreateWorkout — создать тренировку (индивидуальную или групповую).
-func (a *App) handleCreateWorkout(c *gin.Context) {
-	userIDVal, ok := c.Get("user_id")
-	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "user_id not found in context"})
-		return
-	}
-
-	userIDStr, ok := userIDVal.(string)
-	if !ok || userIDStr == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user_id in context"})
-		return
-	}
-
-	userID, err := uuid.Parse(userIDStr)
-	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user_id in token"})
-		return
+func toWorkoutResponse(w workout.Workout, clientIDs []string) workout.WorkoutResponse {
+	loc := tz.LoadOrUTC(w.TimeZone)
+	return workout.WorkoutResponse{
+		ID:          w.ID.String(),
+		Date:        w.LocalDate(),
+		StartsAt:    w.StartsAt.In(loc).Format(time.RFC3339),
+		EndsAt:      w.EndsAt.In(loc).Format(time.RFC3339),
+		AllDay:      w.AllDay,
+		TimeZone:    w.TimeZone,
+		DurationMin: w.DurationMin,
+		TypeID:      w.TypeID.String(),
+		Type:        string(w.Type),
+		ClientIDs:   clientIDs,
+		Notes:       w.Notes,
+		Room:        w.Room,
+		TrainerID:   w.UserID.String(),
+		SeriesID:    seriesIDString(w.SeriesID),
+		Detached:    w.Detached,
 	}
+}
 
-	var req workout.CreateWorkoutRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid JSON"})
-		return
+func seriesIDString(id *uuid.UUID) *string {
+	if id == nil {
+		return nil
 	}
+	s := id.String()
+	return &s
+}
 
-	if req.Date == "" || req.DurationMin < 1 || req.DurationMin > 300 {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "date and duration_min (1-300) are required",
-		})
+// handleCreateWorkout — создать тренировку (индивидуальную или групповую).
+func (a *App) handleCreateWorkout(c *gin.Context) {
+	m, ok := currentMember(c)
+	if !ok {
 		return
 	}
-
-	if !workout.IsValidType(req.Type) {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":         "invalid workout type",
-			"allowed_types": workout.ValidWorkoutTypes,
-		})
+	if !requireWorkoutsWrite(c, m) {
 		return
 	}
 
-	date, err := time.Parse("2006-01-02", req.Date)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "invalid date format, expected YYYY-MM-DD",
-		})
+	in, ok := a.bindWorkoutInput(c, m, nil)
+	if !ok {
 		return
 	}
+	a.createWorkout(c, m, in)
+}
 
-	// Парсим client_ids в UUID и проверяем, что все клиенты принадлежат текущему тренеру.
-	clientUUIDs := make([]uuid.UUID, 0, len(req.ClientIDs))
-	for _, cidStr := range req.ClientIDs {
-		cid, err := uuid.Parse(cidStr)
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid client_id: " + cidStr})
-			return
-		}
-		clientUUIDs = append(clientUUIDs, cid)
-	}
-
-	if len(clientUUIDs) > 0 {
-		var cnt int64
-		if err := a.db.
-			Model(&client.Client{}).
-			Where("user_id = ? AND id IN ?", userID, clientUUIDs).
-			Count(&cnt).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to validate clients"})
-			return
-		}
-		if cnt != int64(len(clientUUIDs)) {
-			c.JSON(http.StatusBadRequest, gin.H{
-				"error": "one or more client_ids do not belong to the current user",
-			})
-			return
-		}
-	}
+// createWorkout сохраняет новую тренировку (и серию, если задано правило
+// повторения) из проверенного входа и отвечает ею.
+func (a *App) createWorkout(c *gin.Context, m member, in workoutInput) {
+	force := forceSchedule(c)
 
 	w := workout.Workout{
-		ID:          uuid.New(),
-		UserID:      userID,
-		Date:        date,
-		DurationMin: req.DurationMin,
-		Type:        workout.WorkoutType(req.Type),
-		Notes:       req.Notes,
+		ID:             uuid.New(),
+		OrganizationID: m.OrgID,
 	}
+	in.apply(&w)
 
-	err = a.db.Transaction(func(tx *gorm.DB) error {
+	err := a.db.Transaction(func(tx *gorm.DB) error {
 		if err := tx.Create(&w).Error; err != nil {
 			return err
 		}
-
-		if len(clientUUIDs) > 0 {
-			links := make([]workout.WorkoutClient, 0, len(clientUUIDs))
-			for _, cid := range clientUUIDs {
-				links = append(links, workout.WorkoutClient{
-					WorkoutID: w.ID,
-					ClientID:  cid,
-				})
-			}
-			if err := tx.Create(&links).Error; err != nil {
+		if err := saveWorkoutContent(tx, w.ID, in); err != nil {
+			return err
+		}
+		if in.rule != nil {
+			if err := startSeries(tx, &w, in); err != nil {
 				return err
 			}
 		}
-
-		return nil
+		return checkWorkoutConflicts(tx, w, force)
 	})
+	var ce *conflictError
+	if errors.As(err, &ce) {
+		respondConflicts(c, ce)
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create workout"})
 		return
 	}
 
-	resp := workout.WorkoutResponse{
-		ID:          w.ID.String(),
-		Date:        w.Date.Format("2006-01-02"),
-		DurationMin: w.DurationMin,
-		Type:        string(w.Type),
-		ClientIDs:   req.ClientIDs,
-		Notes:       w.Notes,
-	}
-
-	c.JSON(http.StatusCreated, resp)
+	a.respondWorkout(c, http.StatusCreated, w)
 }
 
-// handleGetWorkouts — список тренировок текущего тренера с client_ids.
-func (a *App) handleGetWorkouts(c *gin.Context) {
-	userIDVal, ok := c.Get("user_id")
-	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "user_id not found in context"})
+// respondWorkout отдаёт тренировку с клиентами и упражнениями.
+func (a *App) respondWorkout(c *gin.Context, status int, w workout.Workout) {
+	e := parseWorkoutEmbeds(c)
+	e.exercises = true
+
+	details, err := a.loadWorkoutDetails([]uuid.UUID{w.ID}, e)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load workout details"})
 		return
 	}
 
-	userIDStr, ok := userIDVal.(string)
-	if !ok || userIDStr == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user_id in context"})
-		return
+	c.JSON(status, details.response(w))
+}
+
+// filterWorkoutDates применяет параметры from и to (YYYY-MM-DD, включительно).
+// Даты понимаются в поясе loc — обычно в поясе того, кто смотрит расписание;
+// при ошибке сам отвечает.
+func filterWorkoutDates(c *gin.Context, q *gorm.DB, loc *time.Location) (*gorm.DB, bool) {
+	for _, f := range []struct {
+		param string
+		days  int    // сдвиг границы: to включительно — это начало следующего дня
+		op    string // сравнение starts_at с границей
+	}{
+		{"from", 0, ">="},
+		{"to", 1, "<"},
+	} {
+		v := c.Query(f.param)
+		if v == "" {
+			continue
+		}
+		d, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "invalid " + f.param + ", expected YYYY-MM-DD",
+			})
+			return nil, false
+		}
+		q = whereWorkoutStart(q, f.op, d.AddDate(0, 0, f.days), loc)
 	}
+	return q, true
+}
 
-	userID, err := uuid.Parse(userIDStr)
-	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user_id in token"})
-		return
+// whereWorkoutStart сравнивает начало тренировки с полуночью даты d.
+// Тренировки на весь день хранят дату в UTC, остальные сравниваются
+// с полуночью d в поясе loc.
+func whereWorkoutStart(q *gorm.DB, op string, d time.Time, loc *time.Location) *gorm.DB {
+	return q.Where(
+		"((workouts.all_day AND workouts.starts_at "+op+" ?) OR (NOT workouts.all_day AND workouts.starts_at "+op+" ?))",
+		d, tz.StartOfDay(d, loc),
+	)
+}
+
+// workoutsSortKey — тренировки всегда листаются от новых к старым.
+const workoutsSortKey = "-starts_at"
+
+// pageWorkouts выбирает одну страницу тренировок по cursor и возвращает
+// курсор следующей страницы (nil, если это последняя); при ошибке сам отвечает.
+func pageWorkouts(c *gin.Context, q *gorm.DB, limit int) ([]workout.Workout, *string, bool) {
+	if cur := c.Query("cursor"); cur != "" {
+		values, err := pagination.DecodeCursor(cur, workoutsSortKey, 2)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return nil, nil, false
+		}
+		d, errDate := time.Parse(time.RFC3339Nano, values[0])
+		id, errID := uuid.Parse(values[1])
+		if errDate != nil || errID != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": pagination.ErrInvalidCursor.Error()})
+			return nil, nil, false
+		}
+		q = q.Where("(workouts.starts_at, workouts.id) < (?, ?)", d, id)
 	}
 
 	var workoutsDB []workout.Workout
-	if err := a.db.Where("user_id = ?", userID).Order("date desc").Find(&workoutsDB).Error; err != nil {
+	if err := q.Order("workouts.starts_at desc, workouts.id desc").Limit(limit + 1).Find(&workoutsDB).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load workouts"})
-		return
+		return nil, nil, false
 	}
 
-	workoutIDs := make([]uuid.UUID, 0, len(workoutsDB))
-	for _, w := range workoutsDB {
-		workoutIDs = append(workoutIDs, w.ID)
+	workoutsDB, hasMore := pagination.Trim(workoutsDB, limit)
+	if !hasMore {
+		return workoutsDB, nil, true
 	}
 
-	linksMap := make(map[uuid.UUID][]string)
-	if len(workoutIDs) > 0 {
-		var links []workout.WorkoutClient
-		if err := a.db.Where("workout_id IN ?", workoutIDs).Find(&links).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load workout clients"})
-			return
-		}
+	last := workoutsDB[len(workoutsDB)-1]
+	next := pagination.EncodeCursor(workoutsSortKey, last.StartsAt.UTC().Format(time.RFC3339Nano), last.ID.String())
+	return workoutsDB, &next, true
+}
 
-		for _, l := range links {
-			linksMap[l.WorkoutID] = append(linksMap[l.WorkoutID], l.ClientID.String())
-		}
+// filterWorkouts — тренировки, видимые участнику, с фильтрами from, to,
+// client_id и type_id или type (название вида) из запроса; при ошибке сам отвечает.
+func (a *App) filterWorkouts(c *gin.Context, m member) (*gorm.DB, bool) {
+	q, ok := filterWorkoutDates(c, scopeWorkouts(a.db.DB, m), tz.LoadOrUTC(a.userTimeZone(m.UserID)))
+	if !ok {
+		return nil, false
 	}
 
-	resp := make([]workout.WorkoutResponse, 0, len(workoutsDB))
-	for _, w := range workoutsDB {
-		resp = append(resp, workout.WorkoutResponse{
-			ID:          w.ID.String(),
-			Date:        w.Date.Format("2006-01-02"),
-			DurationMin: w.DurationMin,
-			Type:        string(w.Type),
-			ClientIDs:   linksMap[w.ID], // это []string
-			Notes:       w.Notes,
-		})
+	if v := c.Query("client_id"); v != "" {
+		clientID, err := uuid.Parse(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid client_id"})
+			return nil, false
+		}
+		q = q.Where("workouts.id IN (?)", a.db.Model(&workout.WorkoutClient{}).
+			Select("workout_id").
+			Where("client_id = ?", clientID))
 	}
 
-	c.JSON(http.StatusOK, resp)
+	if typeID, name := c.Query("type_id"), c.Query("type"); typeID != "" || name != "" {
+		t, ok := a.resolveWorkoutType(c, m, typeID, name)
+		if !ok {
+			return nil, false
+		}
+		q = q.Where("workouts.type_id = ?", t.ID)
+	}
+	return q, true
 }
 
-func (a *App) handleGetWorkoutByID(c *gin.Context) {
-	userIDVal, ok := c.Get("user_id")
+// handleGetWorkouts — постраничный список тренировок, видимых участнику.
+// Владелец, администратор и ассистент видят расписание всей организации, тренер — своё.
+//
+// Параметры: from и to — диапазон дат включительно (YYYY-MM-DD); client_id;
+// type_id или type; limit и cursor — пагинация (от новых к старым); embed=clients,exercises —
+// вернуть имена клиентов и/или упражнения с подходами.
+func (a *App) handleGetWorkouts(c *gin.Context) {
+	m, ok := currentMember(c)
 	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "user_id not found in context"})
 		return
 	}
-	userIDStr, ok := userIDVal.(string)
-	if !ok || userIDStr == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user_id in context"})
+	if !requirePermission(c, m, org.PermWorkoutsReadOwn) {
 		return
 	}
-	userID, err := uuid.Parse(userIDStr)
+
+	limit, err := pagination.ParseLimit(c.Query("limit"))
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user_id in token"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	workoutIDStr := c.Param("id")
-	workoutID, err := uuid.Parse(workoutIDStr)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid workout id"})
+	q, ok := a.filterWorkouts(c, m)
+	if !ok {
 		return
 	}
 
-	var w workout.Workout
-	if err := a.db.Where("id = ? AND user_id = ?", workoutID, userID).First(&w).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "workout not found"})
-		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load workout"})
-		}
+	workoutsDB, nextCursor, ok := pageWorkouts(c, q, limit)
+	if !ok {
 		return
 	}
 
-	// Подтягиваем связанных клиентов.
-	var links []workout.WorkoutClient
-	if err := a.db.Where("workout_id = ?", w.ID).Find(&links).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load workout clients"})
-		return
+	workoutIDs := make([]uuid.UUID, 0, len(workoutsDB))
+	for _, w := range workoutsDB {
+		workoutIDs = append(workoutIDs, w.ID)
 	}
 
-	clientIDs := make([]string, 0, len(links))
-	for _, l := range links {
-		clientIDs = append(clientIDs, l.ClientID.String())
+	details, err := a.loadWorkoutDetails(workoutIDs, parseWorkoutEmbeds(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load workout details"})
+		return
 	}
 
-	resp := workout.WorkoutResponse{
-		ID:          w.ID.String(),
-		Date:        w.Date.Format("2006-01-02"),
-		DurationMin: w.DurationMin,
-		Type:        string(w.Type),
-		ClientIDs:   clientIDs,
-		Notes:       w.Notes,
+	page := pagination.Page[workout.WorkoutResponse]{
+		Items:      make([]workout.WorkoutResponse, 0, len(workoutsDB)),
+		NextCursor: nextCursor,
+	}
+	for _, w := range workoutsDB {
+		page.Items = append(page.Items, details.response(w))
 	}
 
-	c.JSON(http.StatusOK, resp)
+	c.JSON(http.StatusOK, page)
 }
 
-func (a *App) handleUpdateWorkout(c *gin.Context) {
-	userIDVal, ok := c.Get("user_id")
+func (a *App) handleGetWorkoutByID(c *gin.Context) {
+	m, ok := currentMember(c)
 	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "user_id not found in context"})
 		return
 	}
-	userIDStr, ok := userIDVal.(string)
-	if !ok || userIDStr == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user_id in context"})
+
+	w, ok := a.loadWorkout(c, m)
+	if !ok {
 		return
 	}
-	userID, err := uuid.Parse(userIDStr)
-	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user_id in token"})
+
+	a.respondWorkout(c, http.StatusOK, w)
+}
+
+func (a *App) handleUpdateWorkout(c *gin.Context) {
+	m, ok := currentMember(c)
+	if !ok {
 		return
 	}
 
-	workoutIDStr := c.Param("id")
-	workoutID, err := uuid.Parse(workoutIDStr)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid workout id"})
+	existing, ok := a.loadWorkout(c, m)
+	if !ok {
 		return
 	}
-
-	var existing workout.Workout
-	if err := a.db.Where("id = ? AND user_id = ?", workoutID, userID).First(&existing).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "workout not found"})
-		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load workout"})
-		}
+	if !canEditWorkout(m, existing) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "not allowed to edit this workout"})
 		return
 	}
 
-	var req workout.CreateWorkoutRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid JSON"})
+	scope, ok := parseEditScope(c, existing)
+	if !ok {
 		return
 	}
 
-	if req.Date == "" || req.DurationMin < 1 || req.DurationMin > 300 {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "date and duration_min (1-300) are required",
-		})
+	in, ok := a.bindWorkoutInput(c, m, &existing)
+	if !ok {
 		return
 	}
 
-	if !workout.IsValidType(req.Type) {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":         "invalid workout type",
-			"allowed_types": workout.ValidWorkoutTypes,
-		})
+	force := forceSchedule(c)
+	if scope != workout.ScopeThis {
+		a.updateWorkoutSeries(c, existing, in, scope, force)
 		return
 	}
-
-	date, err := time.Parse("2006-01-02", req.Date)
-	if err != nil {
+	if in.rule != nil && existing.SeriesID != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "invalid date format, expected YYYY-MM-DD",
+			"error": "recurrence of a series can only be changed with scope=following or scope=all",
 		})
 		return
 	}
 
-	// Разбираем client_ids и проверяем их владельца.
-	clientUUIDs := make([]uuid.UUID, 0, len(req.ClientIDs))
-	for _, cidStr := range req.ClientIDs {
-		cid, err := uuid.Parse(cidStr)
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid client_id: " + cidStr})
-			return
-		}
-		clientUUIDs = append(clientUUIDs, cid)
+	in.apply(&existing)
+	if existing.SeriesID != nil {
+		existing.Detached = true
 	}
 
-	if len(clientUUIDs) > 0 {
-		var cnt int64
-		if err := a.db.
-			Model(&client.Client{}).
-			Where("user_id = ? AND id IN ?", userID, clientUUIDs).
-			Count(&cnt).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to validate clients"})
-			return
-		}
-		if cnt != int64(len(clientUUIDs)) {
-			c.JSON(http.StatusBadRequest, gin.H{
-				"error": "one or more client_ids do not belong to the current user",
-			})
-			return
-		}
-	}
-
-	existing.Date = date
-	existing.DurationMin = req.DurationMin
-	existing.Type = workout.WorkoutType(req.Type)
-	existing.Notes = req.Notes
-
-	err = a.db.Transaction(func(tx *gorm.DB) error {
+	err := a.db.Transaction(func(tx *gorm.DB) error {
 		if err := tx.Save(&existing).Error; err != nil {
 			return err
 		}
-
-		// Сначала удаляем старые связи.
-		if err := tx.Where("workout_id = ?", existing.ID).Delete(&workout.WorkoutClient{}).Error; err != nil {
+		if err := saveWorkoutContent(tx, existing.ID, in); err != nil {
 			return err
 		}
-
-		// Затем добавляем новые связи.
-		if len(clientUUIDs) > 0 {
-			links := make([]workout.WorkoutClient, 0, len(clientUUIDs))
-			for _, cid := range clientUUIDs {
-				links = append(links, workout.WorkoutClient{
-					WorkoutID: existing.ID,
-					ClientID:  cid,
-				})
-			}
-			if err := tx.Create(&links).Error; err != nil {
+		if in.rule != nil {
+			if err := startSeries(tx, &existing, in); err != nil {
 				return err
 			}
+			return checkWorkoutConflicts(tx, existing, force)
 		}
-
-		return nil
+		// Остальные повторения серии не менялись — проверяем только это.
+		return checkWorkoutConflicts(tx, workout.Workout{ID: existing.ID}, force)
 	})
+	var ce *conflictError
+	if errors.As(err, &ce) {
+		respondConflicts(c, ce)
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update workout"})
 		return
 	}
 
-	resp := workout.WorkoutResponse{
-		ID:          existing.ID.String(),
-		Date:        existing.Date.Format("2006-01-02"),
-		DurationMin: existing.DurationMin,
-		Type:        string(existing.Type),
-		ClientIDs:   req.ClientIDs,
-		Notes:       existing.Notes,
-	}
-
-	c.JSON(http.StatusOK, resp)
+	a.respondWorkout(c, http.StatusOK, existing)
 }
 
-func (a *App) handleDeleteWorkout(c *gin.Context) {
-	userIDVal, ok := c.Get("user_id")
-	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "user_id not found in context"})
+// updateWorkoutSeries — изменение повторения вместе со следующими или всей серией.
+// Если по новому правилу этого повторения больше нет, отвечает 204.
+func (a *App) updateWorkoutSeries(c *gin.Context, existing workout.Workout, in workoutInput, scope workout.EditScope, force bool) {
+	var updated *workout.Workout
+	err := a.db.Transaction(func(tx *gorm.DB) error {
+		var (
+			seriesID uuid.UUID
+			err      error
+		)
+		updated, seriesID, err = updateSeries(tx, existing, in, scope)
+		if err != nil {
+			return err
+		}
+		// Проверяем повторения серии, даже если этого повторения в ней больше нет.
+		return checkWorkoutConflicts(tx, workout.Workout{ID: existing.ID, SeriesID: &seriesID}, force)
+	})
+	var ce *conflictError
+	if errors.As(err, &ce) {
+		respondConflicts(c, ce)
 		return
 	}
-	userIDStr, ok := userIDVal.(string)
-	if !ok || userIDStr == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user_id in context"})
+	if errors.Is(err, errSeriesStart) || errors.Is(err, errSeriesShift) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, errSeriesMarked) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
 		return
 	}
-	userID, err := uuid.Parse(userIDStr)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user_id in token"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update series"})
 		return
 	}
 
-	workoutIDStr := c.Param("id")
-	workoutID, err := uuid.Parse(workoutIDStr)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid workout id"})
+	if updated == nil {
+		c.Status(http.StatusNoContent)
 		return
 	}
+	a.respondWorkout(c, http.StatusOK, *updated)
+}
 
-	// Проверяем, что тренировка принадлежит пользователю.
-	var w workout.Workout
-	if err := a.db.Where("id = ? AND user_id = ?", workoutID, userID).First(&w).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "workout not found"})
-		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load workout"})
-		}
+func (a *App) handleDeleteWorkout(c *gin.Context) {
+	m, ok := currentMember(c)
+	if !ok {
 		return
 	}
 
-	err = a.db.Transaction(func(tx *gorm.DB) error {
-		if err := tx.Where("workout_id = ?", w.ID).Delete(&workout.WorkoutClient{}).Error; err != nil {
-			return err
-		}
-		if err := tx.Delete(&w).Error; err != nil {
-			return err
-		}
-		return nil
+	// Проверяем, что тренировка видна участнику и он вправе её удалить.
+	w, ok := a.loadWorkout(c, m)
+	if !ok {
+		return
+	}
+	if !canEditWorkout(m, w) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "not allowed to delete this workout"})
+		return
+	}
+
+	scope, ok := parseEditScope(c, w)
+	if !ok {
+		return
+	}
+
+	err := a.db.Transaction(func(tx *gorm.DB) error {
+		return deleteSeriesWorkouts(tx, w, scope)
 	})
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete workout"})
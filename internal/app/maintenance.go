@@ -0,0 +1,170 @@
+package app
+
+import (
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"traindesk/internal/apikey"
+	"traindesk/internal/calendar"
+	"traindesk/internal/client"
+	"traindesk/internal/exercise"
+	"traindesk/internal/org"
+	"traindesk/internal/user"
+	"traindesk/internal/workout"
+)
+
+const maintenanceInterval = time.Hour
+
+// runMaintenance периодически выполняет фоновые задачи:
+// удаляет аккаунты с истёкшим льготным периодом и просроченные выгрузки,
+// досоздаёт повторения серий тренировок.
+func (a *App) runMaintenance() {
+	ticker := time.NewTicker(maintenanceInterval)
+	defer ticker.Stop()
+
+	for {
+		a.purgeDeletedAccounts()
+		a.purgeExpiredExports()
+		a.extendSeries()
+
+		<-ticker.C
+	}
+}
+
+func (a *App) purgeDeletedAccounts() {
+	var userIDs []uuid.UUID
+	if err := a.db.Model(&user.User{}).
+		Where("deletion_scheduled_at IS NOT NULL AND deletion_scheduled_at <= ?", time.Now()).
+		Pluck("id", &userIDs).Error; err != nil {
+		log.Printf("maintenance: failed to load accounts to delete: %v", err)
+		return
+	}
+
+	for _, id := range userIDs {
+		if err := a.db.Transaction(func(tx *gorm.DB) error {
+			return purgeUser(tx, id)
+		}); err != nil {
+			log.Printf("maintenance: failed to delete account %s: %v", id, err)
+			continue
+		}
+		log.Printf("maintenance: account %s deleted", id)
+	}
+}
+
+func (a *App) purgeExpiredExports() {
+	// Задача, не завершившаяся за час, скорее всего потеряна при рестарте.
+	if err := a.db.Model(&user.DataExport{}).
+		Where("status = ? AND created_at <= ?", user.ExportStatusPending, time.Now().Add(-time.Hour)).
+		Updates(map[string]interface{}{"status": user.ExportStatusFailed, "error": "export was interrupted"}).Error; err != nil {
+		log.Printf("maintenance: failed to fail stale exports: %v", err)
+	}
+
+	if err := a.db.Where("expires_at <= ?", time.Now()).Delete(&user.DataExport{}).Error; err != nil {
+		log.Printf("maintenance: failed to delete expired exports: %v", err)
+	}
+}
+
+// purgeUser безвозвратно удаляет тренера и все его данные.
+// Организации, где он был единственным участником, удаляются целиком;
+// в общих организациях удаляются только его тренировки, а клиенты остаются залу.
+// Порядок важен: сначала связи, потом тренировки и клиенты, в конце сам пользователь.
+func purgeUser(tx *gorm.DB, userID uuid.UUID) error {
+	var orgIDs []uuid.UUID
+	if err := tx.Model(&org.Membership{}).
+		Where("user_id = ?", userID).
+		Where("NOT EXISTS (SELECT 1 FROM memberships o WHERE o.organization_id = memberships.organization_id AND o.user_id <> ?)", userID).
+		Pluck("organization_id", &orgIDs).Error; err != nil {
+		return err
+	}
+
+	// Если владелец не успел передать права, владельцем становится самый давний участник.
+	sharedOrgIDs, err := soleOwnedSharedOrgs(tx, userID)
+	if err != nil {
+		return err
+	}
+	for _, orgID := range sharedOrgIDs {
+		var heir org.Membership
+		if err := tx.Where("organization_id = ? AND user_id <> ?", orgID, userID).
+			Order("created_at").First(&heir).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&heir).Update("role", org.RoleOwner).Error; err != nil {
+			return err
+		}
+	}
+
+	var workoutIDs []uuid.UUID
+	if err := tx.Model(&workout.Workout{}).
+		Where("user_id = ? OR organization_id IN ?", userID, orgIDs).
+		Pluck("id", &workoutIDs).Error; err != nil {
+		return err
+	}
+	if err := deleteWorkouts(tx, workoutIDs); err != nil {
+		return err
+	}
+	if err := tx.Where("user_id = ? OR organization_id IN ?", userID, orgIDs).
+		Delete(&workout.WorkoutSeries{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("user_id = ? OR organization_id IN ?", userID, orgIDs).
+		Delete(&workout.Template{}).Error; err != nil {
+		return err
+	}
+
+	clientIDs := tx.Model(&client.Client{}).Select("id").Where("organization_id IN ?", orgIDs)
+	if err := tx.Where("client_id IN (?)", clientIDs).Delete(&workout.ClientSetResult{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("client_id IN (?)", clientIDs).Delete(&workout.WorkoutClient{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("client_id IN (?)", clientIDs).Delete(&client.ClientTag{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("organization_id IN ?", orgIDs).Delete(&client.Client{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("organization_id IN ?", orgIDs).Delete(&exercise.Exercise{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Model(&exercise.Exercise{}).Where("created_by = ?", userID).Update("created_by", nil).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("organization_id IN ?", orgIDs).Delete(&workout.Type{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Model(&workout.Type{}).Where("created_by = ?", userID).Update("created_by", nil).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("organization_id IN ? OR invited_by = ?", orgIDs, userID).Delete(&org.Invitation{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("organization_id IN ? OR user_id = ?", orgIDs, userID).Delete(&calendar.Feed{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("user_id = ?", userID).Delete(&org.Membership{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("id IN ?", orgIDs).Delete(&org.Organization{}).Error; err != nil {
+		return err
+	}
+
+	for _, m := range []interface{}{
+		&user.Session{},
+		&user.EmailVerification{},
+		&user.PasswordReset{},
+		&user.RecoveryCode{},
+		&user.EmailChange{},
+		&user.DataExport{},
+		&apikey.APIKey{},
+	} {
+		if err := tx.Where("user_id = ?", userID).Delete(m).Error; err != nil {
+			return err
+		}
+	}
+
+	return tx.Where("id = ?", userID).Delete(&user.User{}).Error
+}
@@ -6,8 +6,17 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+
+	"traindesk/internal/apikey"
+)
+
+const (
+	authMethodSession = "session"
+	authMethodAPIKey  = "api_key"
 )
 
+// AuthMiddleware принимает "Bearer <JWT>" или "ApiKey <ключ>".
 func (a *App) AuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
@@ -19,6 +28,11 @@ func (a *App) AuthMiddleware() gin.HandlerFunc {
 		}
 
 		parts := strings.SplitN(authHeader, " ", 2)
+		if len(parts) == 2 && strings.EqualFold(parts[0], "ApiKey") {
+			a.authenticateWithAPIKey(c, parts[1])
+			return
+		}
+
 		if len(parts) != 2 || !strings.EqualFold(parts[0], "Bearer") {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
 				"error": "invalid Authorization header format",
@@ -28,12 +42,7 @@ func (a *App) AuthMiddleware() gin.HandlerFunc {
 
 		tokenStr := parts[1]
 
-		token, err := jwt.Parse(tokenStr, func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, jwt.ErrSignatureInvalid
-			}
-			return jwtSecret, nil
-		})
+		token, err := jwt.Parse(tokenStr, a.keys.Keyfunc)
 		if err != nil || !token.Valid {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
 				"error": "invalid or expired token",
@@ -49,6 +58,14 @@ func (a *App) AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
+		// MFA-токен годится только для /auth/2fa/verify.
+		if typ, _ := claims["typ"].(string); typ == mfaTokenType {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"error": "mfa verification required",
+			})
+			return
+		}
+
 		sub, ok := claims["sub"].(string)
 		if !ok || sub == "" {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
@@ -57,7 +74,126 @@ func (a *App) AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
+		userID, err := uuid.Parse(sub)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"error": "invalid subject in token",
+			})
+			return
+		}
+
+		sid, _ := claims["sid"].(string)
+		familyID, err := uuid.Parse(sid)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"error": "invalid session in token",
+			})
+			return
+		}
+
+		active, err := a.isSessionActive(userID, familyID)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+				"error": "failed to check session",
+			})
+			return
+		}
+		if !active {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"error": "session revoked",
+			})
+			return
+		}
+
 		c.Set("user_id", sub)
+		c.Set("session_id", familyID)
+		c.Set("auth_method", authMethodSession)
 		c.Next()
 	}
 }
+
+// authenticateWithAPIKey — ветка AuthMiddleware для схемы "ApiKey".
+func (a *App) authenticateWithAPIKey(c *gin.Context, key string) {
+	k, ok, err := a.authenticateAPIKey(strings.TrimSpace(key))
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to check api key",
+		})
+		return
+	}
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+			"error": "invalid or expired api key",
+		})
+		return
+	}
+
+	c.Set("user_id", k.UserID.String())
+	c.Set("auth_method", authMethodAPIKey)
+	c.Set("api_key", k)
+	c.Next()
+}
+
+// RequireScope пропускает запрос, если он аутентифицирован сессией
+// или API-ключом с правом scope. Ставится после AuthMiddleware.
+func (a *App) RequireScope(scope apikey.Scope) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !hasScope(c, scope) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"error": "api key lacks scope " + string(scope),
+			})
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// hasScope — разрешено ли запросу действие с правом scope: сессии можно
+// всё, API-ключу — только выданное. Для проверок внутри обработчика.
+func hasScope(c *gin.Context, scope apikey.Scope) bool {
+	if c.GetString("auth_method") != authMethodAPIKey {
+		return true
+	}
+	k, ok := c.MustGet("api_key").(apikey.APIKey)
+	return ok && k.HasScope(scope)
+}
+
+// RequireSession запрещает доступ по API-ключу: управление аккаунтом,
+// сессиями и самими ключами доступно только после логина.
+func (a *App) RequireSession() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetString("auth_method") != authMethodSession {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"error": "this endpoint requires a user session",
+			})
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// currentUserID достаёт ID тренера, положенный в контекст AuthMiddleware.
+// Если его нет или он битый — сам отвечает 401 и возвращает false.
+func currentUserID(c *gin.Context) (uuid.UUID, bool) {
+	userIDVal, ok := c.Get("user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user_id not found in context"})
+		return uuid.Nil, false
+	}
+
+	userIDStr, ok := userIDVal.(string)
+	if !ok || userIDStr == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user_id in context"})
+		return uuid.Nil, false
+	}
+
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user_id in token"})
+		return uuid.Nil, false
+	}
+
+	return userID, true
+}
@@ -0,0 +1,129 @@
+package app
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"traindesk/internal/org"
+	"traindesk/internal/workout"
+)
+
+// member — кто выполняет запрос и в какой организации.
+type member struct {
+	UserID uuid.UUID
+	OrgID  uuid.UUID
+	Role   org.Role
+}
+
+func (m member) can(p org.Permission) bool {
+	return m.Role.Can(p)
+}
+
+// OrgMiddleware определяет организацию запроса и роль тренера в ней.
+// Организация берётся из заголовка X-Organization-ID, а если его нет —
+// используется личная организация тренера. Ставится после AuthMiddleware.
+func (a *App) OrgMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, ok := currentUserID(c)
+		if !ok {
+			c.Abort()
+			return
+		}
+
+		q := a.db.Model(&org.Membership{}).Where("memberships.user_id = ?", userID)
+		if h := c.GetHeader("X-Organization-ID"); h != "" {
+			orgID, err := uuid.Parse(h)
+			if err != nil {
+				c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "invalid X-Organization-ID header"})
+				return
+			}
+			q = q.Where("memberships.organization_id = ?", orgID)
+		} else {
+			q = q.Joins("JOIN organizations o ON o.id = memberships.organization_id").
+				Order("o.personal desc, memberships.created_at")
+		}
+
+		var m org.Membership
+		if err := q.First(&m).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "not a member of this organization"})
+			} else {
+				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "failed to load membership"})
+			}
+			return
+		}
+
+		c.Set("member", member{UserID: userID, OrgID: m.OrganizationID, Role: m.Role})
+		c.Next()
+	}
+}
+
+// currentMember достаёт участника, положенного в контекст OrgMiddleware.
+func currentMember(c *gin.Context) (member, bool) {
+	v, _ := c.Get("member")
+	m, ok := v.(member)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "organization not resolved"})
+		return member{}, false
+	}
+	return m, true
+}
+
+// requirePermission отвечает 403, если у роли нет права p.
+func requirePermission(c *gin.Context, m member, p org.Permission) bool {
+	if !m.can(p) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "role " + string(m.Role) + " is not allowed to do this"})
+		return false
+	}
+	return true
+}
+
+// scopeWorkouts ограничивает запрос тренировками, которые участник вправе видеть.
+func scopeWorkouts(q *gorm.DB, m member) *gorm.DB {
+	q = q.Where("workouts.organization_id = ?", m.OrgID)
+	if !m.can(org.PermWorkoutsReadAll) {
+		q = q.Where("workouts.user_id = ?", m.UserID)
+	}
+	return q
+}
+
+// canEditWorkout — может ли участник менять или удалять тренировку w.
+func canEditWorkout(m member, w workout.Workout) bool {
+	if m.can(org.PermWorkoutsWriteAll) {
+		return true
+	}
+	return m.can(org.PermWorkoutsWriteOwn) && w.UserID == m.UserID
+}
+
+// loadWorkout находит видимую участнику тренировку по :id; при ошибке сам отвечает.
+func (a *App) loadWorkout(c *gin.Context, m member) (workout.Workout, bool) {
+	workoutID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid workout id"})
+		return workout.Workout{}, false
+	}
+
+	var w workout.Workout
+	if err := scopeWorkouts(a.db.Where("workouts.id = ?", workoutID), m).First(&w).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "workout not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load workout"})
+		}
+		return workout.Workout{}, false
+	}
+
+	return w, true
+}
+
+// isOrgMember проверяет, что userID состоит в организации orgID.
+func (a *App) isOrgMember(orgID, userID uuid.UUID) (bool, error) {
+	var cnt int64
+	err := a.db.Model(&org.Membership{}).
+		Where("organization_id = ? AND user_id = ?", orgID, userID).
+		Count(&cnt).Error
+	return cnt > 0, err
+}
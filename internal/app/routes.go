@@ -4,6 +4,8 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+
+	"traindesk/internal/apikey"
 )
 
 func (a *App) registerRoutes() {
@@ -11,6 +13,11 @@ func (a *App) registerRoutes() {
 		c.JSON(http.StatusOK, gin.H{"status": "ok"})
 	})
 
+	a.router.GET("/.well-known/jwks.json", a.handleJWKS)
+
+	// Подписка на расписание из приложений календаря: доступ по секретной ссылке.
+	a.router.GET("/calendar/:token", a.handleCalendarFeed)
+
 	api := a.router.Group("/api/v1")
 	{
 		auth := api.Group("/auth")
@@ -18,21 +25,131 @@ func (a *App) registerRoutes() {
 			auth.POST("/register", a.handleRegister)
 			auth.POST("/login", a.handleLogin)
 			auth.POST("/verify-email", a.handleVerifyEmail)
+			auth.POST("/resend-verification", a.handleResendVerification)
+			auth.POST("/refresh", a.handleRefresh)
+			auth.POST("/forgot-password", a.handleForgotPassword)
+			auth.POST("/reset-password", a.handleResetPassword)
+			auth.POST("/2fa/verify", a.handleMFAVerify)
+
+			// Эндпоинты, требующие именно пользовательской сессии (не API-ключа).
+			session := auth.Group("", a.AuthMiddleware(), a.RequireSession())
+			{
+				session.POST("/logout", a.handleLogout)
+				session.POST("/logout-all", a.handleLogoutAll)
+				session.POST("/2fa/setup", a.handleTOTPSetup)
+				session.POST("/2fa/confirm", a.handleTOTPConfirm)
+				session.POST("/2fa/disable", a.handleTOTPDisable)
+				session.POST("/2fa/recovery-codes", a.handleRegenerateRecoveryCodes)
+			}
+		}
+
+		me := api.Group("/me", a.AuthMiddleware(), a.RequireSession())
+		{
+			me.GET("", a.handleGetMe)
+			me.PATCH("", a.handleUpdateMe)
+			me.POST("/password", a.handleChangePassword)
+			me.POST("/email", a.handleRequestEmailChange)
+			me.POST("/email/confirm", a.handleConfirmEmailChange)
+			me.POST("/deletion", a.handleScheduleAccountDeletion)
+			me.DELETE("/deletion", a.handleCancelAccountDeletion)
+			me.GET("/exports", a.handleGetDataExports)
+			me.POST("/exports", a.handleCreateDataExport)
+			me.GET("/exports/:id", a.handleGetDataExport)
+			me.GET("/exports/:id/download", a.handleDownloadDataExport)
+		}
+
+		apiKeys := api.Group("/api-keys", a.AuthMiddleware(), a.RequireSession())
+		{
+			apiKeys.GET("", a.handleGetAPIKeys)
+			apiKeys.POST("", a.handleCreateAPIKey)
+			apiKeys.DELETE("/:id", a.handleDeleteAPIKey)
+		}
+
+		orgs := api.Group("/orgs", a.AuthMiddleware(), a.RequireSession())
+		{
+			orgs.GET("", a.handleGetOrganizations)
+			orgs.POST("", a.handleCreateOrganization)
+			orgs.GET("/:id/members", a.handleGetMembers)
+			orgs.PATCH("/:id/members/:user_id", a.handleUpdateMember)
+			orgs.DELETE("/:id/members/:user_id", a.handleDeleteMember)
+			orgs.GET("/:id/invitations", a.handleGetInvitations)
+			orgs.POST("/:id/invitations", a.handleCreateInvitation)
+			orgs.DELETE("/:id/invitations/:invitation_id", a.handleDeleteInvitation)
+		}
+
+		feeds := api.Group("/calendar/feeds", a.AuthMiddleware(), a.RequireSession(), a.OrgMiddleware())
+		{
+			feeds.GET("", a.handleGetCalendarFeeds)
+			feeds.POST("", a.handleCreateCalendarFeed)
+			feeds.POST("/:id/regenerate", a.handleRegenerateCalendarFeed)
+			feeds.DELETE("/:id", a.handleDeleteCalendarFeed)
+		}
+
+		invitations := api.Group("/invitations", a.AuthMiddleware(), a.RequireSession())
+		{
+			invitations.POST("/accept", a.handleAcceptInvitation)
+		}
+
+		workoutsRead := a.RequireScope(apikey.ScopeWorkoutsRead)
+		workoutsWrite := a.RequireScope(apikey.ScopeWorkoutsWrite)
+
+		workouts := api.Group("/workouts", a.AuthMiddleware(), a.OrgMiddleware())
+		{
+			workouts.GET("", workoutsRead, a.handleGetWorkouts)
+			workouts.POST("", workoutsWrite, a.handleCreateWorkout)
+			workouts.POST("/import", workoutsWrite, a.handleImportWorkouts)
+			workouts.GET("/export", workoutsRead, a.handleExportWorkouts)
+			workouts.GET("/series/:id", workoutsRead, a.handleGetSeries)
+			workouts.GET("/:id", workoutsRead, a.handleGetWorkoutByID)
+			workouts.PUT("/:id", workoutsWrite, a.handleUpdateWorkout)
+			workouts.DELETE("/:id", workoutsWrite, a.handleDeleteWorkout)
+			workouts.POST("/:id/template", workoutsWrite, a.handleSaveWorkoutAsTemplate)
+			workouts.GET("/:id/participants", workoutsRead, a.handleGetParticipants)
+			workouts.PUT("/:id/participants/:client_id", workoutsWrite, a.handlePutParticipant)
+			workouts.POST("/:id/attendance", workoutsWrite, a.handleMarkAttendance)
 		}
 
-		workouts := api.Group("/workouts", a.AuthMiddleware())
+		exercises := api.Group("/exercises", a.AuthMiddleware(), a.OrgMiddleware())
 		{
-			workouts.GET("", a.handleGetWorkouts)
-			workouts.POST("", a.handleCreateWorkout)
-			workouts.GET("/:id", a.handleGetWorkoutByID)
-			workouts.PUT("/:id", a.handleUpdateWorkout)
-			workouts.DELETE("/:id", a.handleDeleteWorkout)
+			exercises.GET("", workoutsRead, a.handleGetExercises)
+			exercises.POST("", workoutsWrite, a.handleCreateExercise)
+			exercises.PUT("/:id", workoutsWrite, a.handleUpdateExercise)
+			exercises.DELETE("/:id", workoutsWrite, a.handleDeleteExercise)
 		}
 
-		clients := api.Group("/clients", a.AuthMiddleware())
+		workoutTypes := api.Group("/workout-types", a.AuthMiddleware(), a.OrgMiddleware())
+		{
+			workoutTypes.GET("", workoutsRead, a.handleGetWorkoutTypes)
+			workoutTypes.POST("", workoutsWrite, a.handleCreateWorkoutType)
+			workoutTypes.PUT("/:id", workoutsWrite, a.handleUpdateWorkoutType)
+			workoutTypes.DELETE("/:id", workoutsWrite, a.handleDeleteWorkoutType)
+		}
+
+		templates := api.Group("/workout-templates", a.AuthMiddleware(), a.OrgMiddleware())
+		{
+			templates.GET("", workoutsRead, a.handleGetTemplates)
+			templates.POST("", workoutsWrite, a.handleCreateTemplate)
+			templates.GET("/:id", workoutsRead, a.handleGetTemplate)
+			templates.PUT("/:id", workoutsWrite, a.handleUpdateTemplate)
+			templates.DELETE("/:id", workoutsWrite, a.handleDeleteTemplate)
+			templates.POST("/:id/workouts", workoutsWrite, a.handleCreateWorkoutFromTemplate)
+		}
+
+		clientsRead := a.RequireScope(apikey.ScopeClientsRead)
+		clientsWrite := a.RequireScope(apikey.ScopeClientsWrite)
+
+		clients := api.Group("/clients", a.AuthMiddleware(), a.OrgMiddleware())
 		{
-			clients.GET("", a.handleGetClients)
-			clients.POST("", a.handleCreateClient)
+			clients.GET("", clientsRead, a.handleGetClients)
+			clients.POST("", clientsWrite, a.handleCreateClient)
+			clients.GET("/export", clientsRead, a.handleExportClients)
+			clients.GET("/:id", clientsRead, a.handleGetClientByID)
+			clients.PATCH("/:id", clientsWrite, a.handleUpdateClient)
+			clients.DELETE("/:id", clientsWrite, a.handleDeleteClient)
+			clients.POST("/:id/restore", clientsWrite, a.handleRestoreClient)
+			clients.GET("/:id/log", clientsRead, workoutsRead, a.handleGetClientLog)
+			clients.GET("/:id/attendance", clientsRead, workoutsRead, a.handleGetClientAttendance)
+			clients.GET("/attendance", clientsRead, workoutsRead, a.handleGetAttendanceReport)
 		}
 	}
 }
@@ -0,0 +1,153 @@
+package app
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"traindesk/internal/user"
+)
+
+const (
+	accessTokenTTL  = 15 * time.Minute
+	refreshTokenTTL = 30 * 24 * time.Hour
+	mfaTokenTTL     = 5 * time.Minute
+
+	mfaTokenType = "mfa"
+)
+
+// generateToken генерирует случайный непрозрачный токен (256 бит).
+func generateToken() (string, error) {
+	var b [32]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b[:]), nil
+}
+
+// hashToken — хеш токена для хранения в БД. Сами токены не храним.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// newAccessToken подписывает короткоживущий access-токен, привязанный к семейству сессий.
+func (a *App) newAccessToken(userID, familyID uuid.UUID) (string, error) {
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"sub": userID.String(),
+		"sid": familyID.String(),
+		"exp": now.Add(accessTokenTTL).Unix(),
+		"iat": now.Unix(),
+	}
+
+	return a.keys.Sign(claims)
+}
+
+// newMFAToken подписывает короткоживущий токен MFA-челленджа: пароль
+// уже проверен, но для выдачи сессии нужен второй фактор.
+func (a *App) newMFAToken(userID uuid.UUID) (string, error) {
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"sub": userID.String(),
+		"typ": mfaTokenType,
+		"exp": now.Add(mfaTokenTTL).Unix(),
+		"iat": now.Unix(),
+	}
+
+	return a.keys.Sign(claims)
+}
+
+// parseMFAToken проверяет MFA-токен и возвращает ID пользователя.
+func (a *App) parseMFAToken(tokenStr string) (uuid.UUID, error) {
+	token, err := jwt.Parse(tokenStr, a.keys.Keyfunc)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid {
+		return uuid.Nil, jwt.ErrTokenInvalidClaims
+	}
+	if typ, _ := claims["typ"].(string); typ != mfaTokenType {
+		return uuid.Nil, jwt.ErrTokenInvalidClaims
+	}
+
+	sub, _ := claims["sub"].(string)
+	return uuid.Parse(sub)
+}
+
+// createSession сохраняет новый refresh-токен в семействе familyID и возвращает его.
+func createSession(tx *gorm.DB, c *gin.Context, userID, familyID uuid.UUID) (string, error) {
+	refreshToken, err := generateToken()
+	if err != nil {
+		return "", err
+	}
+
+	s := user.Session{
+		ID:        uuid.New(),
+		UserID:    userID,
+		FamilyID:  familyID,
+		TokenHash: hashToken(refreshToken),
+		UserAgent: c.Request.UserAgent(),
+		IP:        c.ClientIP(),
+		ExpiresAt: time.Now().Add(refreshTokenTTL),
+	}
+	if err := tx.Create(&s).Error; err != nil {
+		return "", err
+	}
+
+	return refreshToken, nil
+}
+
+// issueTokens открывает новое семейство сессий (логин) и выдаёт пару токенов.
+func (a *App) issueTokens(c *gin.Context, userID uuid.UUID) (user.TokenResponse, error) {
+	familyID := uuid.New()
+
+	refreshToken, err := createSession(a.db.DB, c, userID, familyID)
+	if err != nil {
+		return user.TokenResponse{}, err
+	}
+
+	accessToken, err := a.newAccessToken(userID, familyID)
+	if err != nil {
+		return user.TokenResponse{}, err
+	}
+
+	return user.TokenResponse{
+		Token:        accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int(accessTokenTTL.Seconds()),
+	}, nil
+}
+
+// revokeSessionFamily отзывает все refresh-токены одного логина.
+func revokeSessionFamily(tx *gorm.DB, familyID uuid.UUID) error {
+	return tx.Model(&user.Session{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", time.Now()).Error
+}
+
+// revokeUserSessions отзывает все сессии пользователя (logout со всех устройств).
+func revokeUserSessions(tx *gorm.DB, userID uuid.UUID) error {
+	return tx.Model(&user.Session{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now()).Error
+}
+
+// isSessionActive проверяет, что семейство сессий не отозвано и не истекло.
+func (a *App) isSessionActive(userID, familyID uuid.UUID) (bool, error) {
+	var cnt int64
+	err := a.db.
+		Model(&user.Session{}).
+		Where("family_id = ? AND user_id = ? AND revoked_at IS NULL AND expires_at > ?", familyID, userID, time.Now()).
+		Count(&cnt).Error
+	return cnt > 0, err
+}
@@ -0,0 +1,97 @@
+package app
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"traindesk/internal/throttle"
+)
+
+var (
+	accountThrottlePolicy = throttle.Policy{
+		FreeAttempts: 5,
+		BaseLockout:  30 * time.Second,
+		MaxLockout:   15 * time.Minute,
+		ResetAfter:   time.Hour,
+	}
+	ipThrottlePolicy = throttle.Policy{
+		FreeAttempts: 20,
+		BaseLockout:  time.Minute,
+		MaxLockout:   time.Hour,
+		ResetAfter:   time.Hour,
+	}
+)
+
+// throttleKey — счётчик в конкретном лимитере.
+type throttleKey struct {
+	limiter *throttle.Limiter
+	key     string
+}
+
+// throttleKeys собирает ключи для действия action: по аккаунту (если известен) и по IP клиента.
+func (a *App) throttleKeys(c *gin.Context, action, account string) []throttleKey {
+	keys := []throttleKey{{a.ipLimiter, action + ":ip:" + c.ClientIP()}}
+	if account != "" {
+		keys = append(keys, throttleKey{a.accountLimiter, action + ":acct:" + strings.ToLower(account)})
+	}
+	return keys
+}
+
+// allowAttempt проверяет блокировки. Если хотя бы один ключ заблокирован —
+// отвечает 429 с Retry-After и возвращает false. Ошибки хранилища не блокируют вход.
+func (a *App) allowAttempt(c *gin.Context, keys []throttleKey) bool {
+	var wait time.Duration
+	for _, k := range keys {
+		d, err := k.limiter.Allow(c.Request.Context(), k.key)
+		if err != nil {
+			log.Printf("throttle: failed to check %s: %v", k.key, err)
+			continue
+		}
+		if d > wait {
+			wait = d
+		}
+	}
+
+	if wait > 0 {
+		abortTooManyRequests(c, wait, "too many failed attempts, try again later")
+		return false
+	}
+	return true
+}
+
+// recordFailure учитывает неудачную попытку по всем ключам.
+func (a *App) recordFailure(c *gin.Context, keys []throttleKey) {
+	for _, k := range keys {
+		if _, err := k.limiter.Fail(c.Request.Context(), k.key); err != nil {
+			log.Printf("throttle: failed to record failure for %s: %v", k.key, err)
+		}
+	}
+}
+
+// resetAttempts сбрасывает счётчики по аккаунту после успешной попытки.
+// Счётчик по IP не сбрасываем: иначе с одного адреса можно чередовать аккаунты.
+func (a *App) resetAttempts(c *gin.Context, keys []throttleKey) {
+	for _, k := range keys {
+		if k.limiter != a.accountLimiter {
+			continue
+		}
+		if err := k.limiter.Reset(c.Request.Context(), k.key); err != nil {
+			log.Printf("throttle: failed to reset %s: %v", k.key, err)
+		}
+	}
+}
+
+// abortTooManyRequests отвечает 429 с заголовком Retry-After (в секундах, с округлением вверх).
+func abortTooManyRequests(c *gin.Context, wait time.Duration, msg string) {
+	secs := int((wait + time.Second - 1) / time.Second)
+	c.Header("Retry-After", strconv.Itoa(secs))
+	c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+		"error":       msg,
+		"retry_after": secs,
+	})
+}
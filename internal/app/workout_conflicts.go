@@ -0,0 +1,168 @@
+package app
+
+import (
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"traindesk/internal/tz"
+	"traindesk/internal/workout"
+)
+
+// maxConflicts — предел пересечений в ответе: для серии их может быть много.
+const maxConflicts = 100
+
+// freeAttendance — клиенты с этими статусами на тренировку не придут
+// и время не занимают.
+var freeAttendance = []workout.AttendanceStatus{workout.AttendanceCancelled, workout.AttendanceCancelledLate}
+
+// conflictError — тренировка пересекается с уже назначенными; откатывает транзакцию.
+type conflictError struct {
+	conflicts []workout.ConflictResponse
+}
+
+func (e *conflictError) Error() string { return "schedule conflict" }
+
+// conflictRow — строка выборки пересечений.
+type conflictRow struct {
+	Kind       workout.ConflictKind `gorm:"-"`
+	At         time.Time
+	AtTimeZone string
+	WorkoutID  uuid.UUID
+	StartsAt   time.Time
+	EndsAt     time.Time
+	TimeZone   string
+	ClientID   *uuid.UUID
+	Room       string
+}
+
+// forceSchedule — ?force=true: пересечения намеренные, не проверять.
+func forceSchedule(c *gin.Context) bool {
+	return c.Query("force") == "true"
+}
+
+// scheduledWorkoutIDs — тренировки, которые надо проверить после сохранения w:
+// она сама и, если она из серии, ещё не прошедшие повторения серии.
+func scheduledWorkoutIDs(tx *gorm.DB, w workout.Workout) ([]uuid.UUID, error) {
+	if w.SeriesID == nil {
+		return []uuid.UUID{w.ID}, nil
+	}
+	var ids []uuid.UUID
+	if err := tx.Model(&workout.Workout{}).
+		Where("series_id = ? AND (id = ? OR ends_at > ?)", *w.SeriesID, w.ID, time.Now()).
+		Pluck("id", &ids).Error; err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// checkConflicts ищет пересечения тренировок ids с другими тренировками
+// организации: тот же тренер, тот же клиент или тот же зал в одно время.
+// Тренировки на весь день время не занимают. Вызывается в транзакции после
+// сохранения; при пересечениях возвращает *conflictError.
+func checkConflicts(tx *gorm.DB, ids []uuid.UUID) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	overlapping := func() *gorm.DB {
+		return tx.Table("workouts AS a").
+			Joins("JOIN workouts AS b ON b.organization_id = a.organization_id"+
+				" AND b.starts_at < a.ends_at AND b.ends_at > a.starts_at AND NOT b.all_day").
+			Where("a.id IN ? AND b.id NOT IN ? AND NOT a.all_day", ids, ids).
+			Order("a.starts_at, b.starts_at").
+			Limit(maxConflicts)
+	}
+	const base = "a.starts_at AS at, a.time_zone AS at_time_zone, b.id AS workout_id, b.starts_at, b.ends_at, b.time_zone"
+
+	var rows []conflictRow
+	collect := func(kind workout.ConflictKind, q *gorm.DB) error {
+		var found []conflictRow
+		if err := q.Scan(&found).Error; err != nil {
+			return err
+		}
+		for _, r := range found {
+			r.Kind = kind
+			rows = append(rows, r)
+		}
+		return nil
+	}
+
+	if err := collect(workout.ConflictTrainer, overlapping().
+		Select(base).
+		Where("b.user_id = a.user_id")); err != nil {
+		return err
+	}
+	if err := collect(workout.ConflictClient, overlapping().
+		Select(base+", ca.client_id").
+		Joins("JOIN workout_clients AS ca ON ca.workout_id = a.id").
+		Joins("JOIN workout_clients AS cb ON cb.workout_id = b.id AND cb.client_id = ca.client_id").
+		Where("ca.attendance NOT IN ? AND cb.attendance NOT IN ?", freeAttendance, freeAttendance)); err != nil {
+		return err
+	}
+	if err := collect(workout.ConflictRoom, overlapping().
+		Select(base+", b.room").
+		Where("a.room <> '' AND lower(b.room) = lower(a.room)")); err != nil {
+		return err
+	}
+
+	if len(rows) == 0 {
+		return nil
+	}
+	sort.SliceStable(rows, func(i, j int) bool { return rows[i].At.Before(rows[j].At) })
+	if len(rows) > maxConflicts {
+		rows = rows[:maxConflicts]
+	}
+
+	conflicts := make([]workout.ConflictResponse, 0, len(rows))
+	for _, r := range rows {
+		loc := tz.LoadOrUTC(r.TimeZone)
+		cr := workout.ConflictResponse{
+			Kind:      r.Kind,
+			At:        r.At.In(tz.LoadOrUTC(r.AtTimeZone)).Format(time.RFC3339),
+			WorkoutID: r.WorkoutID.String(),
+			StartsAt:  r.StartsAt.In(loc).Format(time.RFC3339),
+			EndsAt:    r.EndsAt.In(loc).Format(time.RFC3339),
+			Room:      r.Room,
+		}
+		if r.ClientID != nil {
+			cr.ClientID = r.ClientID.String()
+		}
+		conflicts = append(conflicts, cr)
+	}
+	return &conflictError{conflicts: conflicts}
+}
+
+// checkWorkoutConflicts проверяет сохранённую тренировку w (с её серией),
+// если пересечения не разрешены явно.
+func checkWorkoutConflicts(tx *gorm.DB, w workout.Workout, force bool) error {
+	if force {
+		return nil
+	}
+	ids, err := scheduledWorkoutIDs(tx, w)
+	if err != nil {
+		return err
+	}
+	return checkConflicts(tx, ids)
+}
+
+// respondConflicts отвечает 409 со списком пересечений.
+func respondConflicts(c *gin.Context, e *conflictError) {
+	ids := make([]string, 0, len(e.conflicts))
+	seen := make(map[string]bool, len(e.conflicts))
+	for _, cr := range e.conflicts {
+		if !seen[cr.WorkoutID] {
+			seen[cr.WorkoutID] = true
+			ids = append(ids, cr.WorkoutID)
+		}
+	}
+	c.JSON(http.StatusConflict, gin.H{
+		"error":                   "workout overlaps with other workouts; repeat with force=true to save anyway",
+		"conflicting_workout_ids": ids,
+		"conflicts":               e.conflicts,
+	})
+}
@@ -0,0 +1,541 @@
+package app
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"traindesk/internal/client"
+	"traindesk/internal/exercise"
+	"traindesk/internal/org"
+	"traindesk/internal/recurrence"
+	"traindesk/internal/tz"
+	"traindesk/internal/user"
+	"traindesk/internal/workout"
+)
+
+// maxRoomLen — предел длины названия зала.
+const maxRoomLen = 100
+
+// workoutInput — проверенный запрос на создание или изменение тренировки.
+type workoutInput struct {
+	req         workout.CreateWorkoutRequest
+	wtype       workout.Type
+	schedule    workout.Schedule
+	trainerID   uuid.UUID
+	clientIDs   []uuid.UUID
+	exerciseIDs []uuid.UUID // по одному на req.Exercises
+
+	rule    *recurrence.Rule // задано, если в запросе есть recurrence
+	exDates []time.Time
+}
+
+// apply переносит поля запроса в тренировку.
+func (in workoutInput) apply(w *workout.Workout) {
+	w.UserID = in.trainerID
+	in.schedule.Apply(w)
+	w.TypeID = in.wtype.ID
+	w.Type = workout.WorkoutType(in.wtype.Name)
+	w.Notes = in.req.Notes
+	w.Room = in.req.Room
+}
+
+// bindWorkoutInput разбирает и проверяет тело запроса на создание (existing == nil)
+// или изменение тренировки; при ошибке сам отвечает.
+func (a *App) bindWorkoutInput(c *gin.Context, m member, existing *workout.Workout) (workoutInput, bool) {
+	var req workout.CreateWorkoutRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid JSON"})
+		return workoutInput{}, false
+	}
+	return a.checkWorkoutInput(c, m, existing, req)
+}
+
+// checkWorkoutInput проверяет уже разобранный запрос; при ошибке сам отвечает.
+func (a *App) checkWorkoutInput(c *gin.Context, m member, existing *workout.Workout, req workout.CreateWorkoutRequest) (workoutInput, bool) {
+	in := workoutInput{req: req}
+
+	var ok bool
+	if in.wtype, ok = a.resolveWorkoutType(c, m, in.req.TypeID, in.req.Type); !ok {
+		return in, false
+	}
+	// Без конца и длительности тренировка длится столько, сколько принято для её вида.
+	if !in.req.AllDay && in.req.StartsAt != "" && in.req.EndsAt == "" && in.req.DurationMin == 0 && in.wtype.DefaultDurationMin != nil {
+		in.req.DurationMin = *in.wtype.DefaultDurationMin
+	}
+	req = in.req
+
+	in.req.Room = strings.TrimSpace(req.Room)
+	if utf8.RuneCountInString(in.req.Room) > maxRoomLen {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("room must be at most %d characters", maxRoomLen)})
+		return in, false
+	}
+
+	fallbackTrainer, workoutID := m.UserID, uuid.Nil
+	if existing != nil {
+		fallbackTrainer, workoutID = existing.UserID, existing.ID
+	}
+
+	if in.trainerID, ok = a.resolveTrainer(c, m, req.TrainerID, fallbackTrainer); !ok {
+		return in, false
+	}
+
+	// Время без смещения — в поясе тренера, который ведёт тренировку.
+	schedule, err := workout.ResolveSchedule(req, a.userTimeZone(in.trainerID))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return in, false
+	}
+	in.schedule = schedule
+
+	if req.Recurrence != nil {
+		rule, exDates, err := workout.ResolveRecurrence(*req.Recurrence, schedule)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return in, false
+		}
+		in.rule, in.exDates = &rule, exDates
+	}
+
+	// Парсим client_ids в UUID и проверяем, что все клиенты из организации.
+	if in.clientIDs, ok = a.parseWorkoutClients(c, m, workoutID, req.ClientIDs); !ok {
+		return in, false
+	}
+
+	if in.exerciseIDs, ok = a.parseWorkoutExercises(c, m, req.Exercises); !ok {
+		return in, false
+	}
+
+	return in, true
+}
+
+// userTimeZone — пояс IANA пользователя; если его не найти — UTC.
+func (a *App) userTimeZone(userID uuid.UUID) string {
+	var zones []string
+	if err := a.db.Model(&user.User{}).Where("id = ?", userID).Pluck("time_zone", &zones).Error; err != nil ||
+		len(zones) == 0 || zones[0] == "" {
+		return tz.Default
+	}
+	return zones[0]
+}
+
+// parseWorkoutClients разбирает client_ids и проверяет, что все клиенты из организации участника.
+// Архивных клиентов в тренировку добавить нельзя, но уже записанные в неё
+// (workoutID) остаются — иначе старые тренировки было бы не отредактировать.
+func (a *App) parseWorkoutClients(c *gin.Context, m member, workoutID uuid.UUID, ids []string) ([]uuid.UUID, bool) {
+	clientUUIDs := make([]uuid.UUID, 0, len(ids))
+	for _, cidStr := range ids {
+		cid, err := uuid.Parse(cidStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid client_id: " + cidStr})
+			return nil, false
+		}
+		clientUUIDs = append(clientUUIDs, cid)
+	}
+
+	if len(clientUUIDs) > 0 {
+		var cnt int64
+		if err := a.db.
+			Model(&client.Client{}).
+			Where("organization_id = ? AND id IN ?", m.OrgID, clientUUIDs).
+			Where("archived_at IS NULL OR id IN (?)",
+				a.db.Model(&workout.WorkoutClient{}).Select("client_id").Where("workout_id = ?", workoutID)).
+			Count(&cnt).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to validate clients"})
+			return nil, false
+		}
+		if cnt != int64(len(clientUUIDs)) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "one or more client_ids do not belong to the organization or are archived",
+			})
+			return nil, false
+		}
+	}
+
+	return clientUUIDs, true
+}
+
+// parseWorkoutExercises проверяет структуру тренировки и то, что все упражнения
+// есть в общем справочнике или в справочнике организации.
+func (a *App) parseWorkoutExercises(c *gin.Context, m member, blocks []workout.ExerciseBlockInput) ([]uuid.UUID, bool) {
+	if err := workout.ValidateExercises(blocks); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, false
+	}
+
+	ids := make([]uuid.UUID, 0, len(blocks))
+	unique := make(map[uuid.UUID]bool)
+	for _, b := range blocks {
+		id, err := uuid.Parse(b.ExerciseID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid exercise_id: " + b.ExerciseID})
+			return nil, false
+		}
+		ids = append(ids, id)
+		unique[id] = true
+	}
+
+	if len(unique) > 0 {
+		uniqueIDs := make([]uuid.UUID, 0, len(unique))
+		for id := range unique {
+			uniqueIDs = append(uniqueIDs, id)
+		}
+
+		var cnt int64
+		if err := a.db.Model(&exercise.Exercise{}).
+			Where("id IN ?", uniqueIDs).
+			Where("organization_id IS NULL OR organization_id = ?", m.OrgID).
+			Count(&cnt).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to validate exercises"})
+			return nil, false
+		}
+		if cnt != int64(len(uniqueIDs)) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "one or more exercises not found"})
+			return nil, false
+		}
+	}
+
+	return ids, true
+}
+
+// resolveTrainer определяет тренера, ведущего тренировку. Назначить
+// тренировку другому тренеру может только роль с правом на все тренировки.
+func (a *App) resolveTrainer(c *gin.Context, m member, trainerIDStr string, fallback uuid.UUID) (uuid.UUID, bool) {
+	if trainerIDStr == "" {
+		return fallback, true
+	}
+
+	trainerID, err := uuid.Parse(trainerIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid trainer_id"})
+		return uuid.Nil, false
+	}
+	if trainerID == fallback {
+		return trainerID, true
+	}
+
+	if !m.can(org.PermWorkoutsWriteAll) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "not allowed to assign workouts to other trainers"})
+		return uuid.Nil, false
+	}
+
+	isMember, err := a.isOrgMember(m.OrgID, trainerID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to validate trainer"})
+		return uuid.Nil, false
+	}
+	if !isMember {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "trainer_id is not a member of the organization"})
+		return uuid.Nil, false
+	}
+
+	return trainerID, true
+}
+
+// saveWorkoutContent приводит клиентов и упражнения тренировки к переданным во входе.
+// Связи с клиентами не пересоздаются, а сравниваются: у оставшихся участников
+// сохраняются их личные итоги и результаты по подходам.
+func saveWorkoutContent(tx *gorm.DB, workoutID uuid.UUID, in workoutInput) error {
+	if err := syncWorkoutClients(tx, workoutID, in.clientIDs); err != nil {
+		return err
+	}
+	if err := replaceWorkoutBlocks(tx, workoutID, in.req.Exercises, in.exerciseIDs); err != nil {
+		return err
+	}
+	return pruneSetResults(tx, workoutID)
+}
+
+// syncWorkoutClients удаляет выбывших участников (вместе с их результатами) и добавляет новых.
+func syncWorkoutClients(tx *gorm.DB, workoutID uuid.UUID, clientIDs []uuid.UUID) error {
+	var current []uuid.UUID
+	if err := tx.Model(&workout.WorkoutClient{}).Where("workout_id = ?", workoutID).Pluck("client_id", &current).Error; err != nil {
+		return err
+	}
+
+	want := make(map[uuid.UUID]bool, len(clientIDs))
+	for _, id := range clientIDs {
+		want[id] = true
+	}
+	have := make(map[uuid.UUID]bool, len(current))
+	var removed []uuid.UUID
+	for _, id := range current {
+		have[id] = true
+		if !want[id] {
+			removed = append(removed, id)
+		}
+	}
+
+	if len(removed) > 0 {
+		if err := tx.Where("workout_id = ? AND client_id IN ?", workoutID, removed).
+			Delete(&workout.ClientSetResult{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("workout_id = ? AND client_id IN ?", workoutID, removed).
+			Delete(&workout.WorkoutClient{}).Error; err != nil {
+			return err
+		}
+	}
+
+	now := time.Now()
+	var added []workout.WorkoutClient
+	for _, id := range clientIDs {
+		if !have[id] {
+			added = append(added, workout.WorkoutClient{
+				WorkoutID:  workoutID,
+				ClientID:   id,
+				Attendance: workout.AttendanceBooked,
+				BookedAt:   &now,
+			})
+			have[id] = true
+		}
+	}
+	if len(added) > 0 {
+		return tx.Create(&added).Error
+	}
+	return nil
+}
+
+// pruneSetResults удаляет результаты клиентов по подходам, которых в тренировке
+// больше нет или на месте которых теперь другое упражнение.
+func pruneSetResults(tx *gorm.DB, workoutID uuid.UUID) error {
+	return tx.Where("workout_id = ?", workoutID).
+		Where(`NOT EXISTS (
+			SELECT 1 FROM workout_blocks b JOIN workout_sets s ON s.block_id = b.id
+			WHERE b.workout_id = client_set_results.workout_id
+			  AND b.position = client_set_results.block_position
+			  AND b.exercise_id = client_set_results.exercise_id
+			  AND s.position = client_set_results.set_position)`).
+		Delete(&workout.ClientSetResult{}).Error
+}
+
+// replaceWorkoutBlocks заменяет упражнения и подходы тренировки.
+func replaceWorkoutBlocks(tx *gorm.DB, workoutID uuid.UUID, blocks []workout.ExerciseBlockInput, exerciseIDs []uuid.UUID) error {
+	if err := deleteWorkoutBlocks(tx, []uuid.UUID{workoutID}); err != nil {
+		return err
+	}
+	if len(blocks) == 0 {
+		return nil
+	}
+
+	blockRows := make([]workout.WorkoutBlock, 0, len(blocks))
+	var setRows []workout.WorkoutSet
+	for i, b := range blocks {
+		block := workout.WorkoutBlock{
+			ID:         uuid.New(),
+			WorkoutID:  workoutID,
+			Position:   i,
+			ExerciseID: exerciseIDs[i],
+			Notes:      b.Notes,
+		}
+		blockRows = append(blockRows, block)
+
+		for j, s := range b.Sets {
+			setRows = append(setRows, workout.WorkoutSet{
+				ID:          uuid.New(),
+				BlockID:     block.ID,
+				Position:    j,
+				Reps:        s.Reps,
+				WeightKg:    s.WeightKg,
+				DurationSec: s.DurationSec,
+				DistanceM:   s.DistanceM,
+				RestSec:     s.RestSec,
+				RPE:         s.RPE,
+			})
+		}
+	}
+
+	if err := tx.Create(&blockRows).Error; err != nil {
+		return err
+	}
+	if len(setRows) > 0 {
+		return tx.Create(&setRows).Error
+	}
+	return nil
+}
+
+// deleteWorkoutBlocks удаляет упражнения и подходы тренировок.
+func deleteWorkoutBlocks(tx *gorm.DB, workoutIDs []uuid.UUID) error {
+	if len(workoutIDs) == 0 {
+		return nil
+	}
+
+	blockIDs := tx.Model(&workout.WorkoutBlock{}).Select("id").Where("workout_id IN ?", workoutIDs)
+	if err := tx.Where("block_id IN (?)", blockIDs).Delete(&workout.WorkoutSet{}).Error; err != nil {
+		return err
+	}
+	return tx.Where("workout_id IN ?", workoutIDs).Delete(&workout.WorkoutBlock{}).Error
+}
+
+// deleteWorkouts удаляет тренировки вместе с участниками, их результатами и упражнениями.
+func deleteWorkouts(tx *gorm.DB, workoutIDs []uuid.UUID) error {
+	if len(workoutIDs) == 0 {
+		return nil
+	}
+
+	if err := deleteWorkoutBlocks(tx, workoutIDs); err != nil {
+		return err
+	}
+	if err := tx.Where("workout_id IN ?", workoutIDs).Delete(&workout.ClientSetResult{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("workout_id IN ?", workoutIDs).Delete(&workout.WorkoutClient{}).Error; err != nil {
+		return err
+	}
+	return tx.Where("id IN ?", workoutIDs).Delete(&workout.Workout{}).Error
+}
+
+// workoutEmbeds — какие вложенные данные отдавать вместе с тренировкой.
+type workoutEmbeds struct {
+	clients   bool
+	exercises bool
+}
+
+// parseWorkoutEmbeds разбирает параметр embed=clients,exercises.
+func parseWorkoutEmbeds(c *gin.Context) workoutEmbeds {
+	var e workoutEmbeds
+	for _, v := range strings.Split(c.Query("embed"), ",") {
+		switch strings.TrimSpace(v) {
+		case "clients":
+			e.clients = true
+		case "exercises":
+			e.exercises = true
+		}
+	}
+	return e
+}
+
+// workoutDetails — клиенты и упражнения набора тренировок, сгруппированные по тренировке.
+type workoutDetails struct {
+	embeds    workoutEmbeds
+	clientIDs map[uuid.UUID][]string
+	clients   map[uuid.UUID][]workout.ClientRef
+	exercises map[uuid.UUID][]workout.ExerciseBlockResponse
+}
+
+// loadWorkoutDetails подтягивает клиентов (одним запросом для всего набора)
+// и, если нужно, упражнения с подходами.
+func (a *App) loadWorkoutDetails(workoutIDs []uuid.UUID, e workoutEmbeds) (workoutDetails, error) {
+	d := workoutDetails{
+		embeds:    e,
+		clientIDs: make(map[uuid.UUID][]string),
+		clients:   make(map[uuid.UUID][]workout.ClientRef),
+		exercises: make(map[uuid.UUID][]workout.ExerciseBlockResponse),
+	}
+	if len(workoutIDs) == 0 {
+		return d, nil
+	}
+
+	var rows []struct {
+		WorkoutID  uuid.UUID
+		ClientID   uuid.UUID
+		FirstName  string
+		LastName   string
+		ArchivedAt *time.Time
+	}
+	q := a.db.Table("workout_clients").Where("workout_clients.workout_id IN ?", workoutIDs)
+	if e.clients {
+		q = q.Select("workout_clients.workout_id, workout_clients.client_id, clients.first_name, clients.last_name, clients.archived_at").
+			Joins("JOIN clients ON clients.id = workout_clients.client_id").
+			Order("clients.last_name, clients.first_name")
+	} else {
+		q = q.Select("workout_clients.workout_id, workout_clients.client_id")
+	}
+	if err := q.Scan(&rows).Error; err != nil {
+		return workoutDetails{}, err
+	}
+
+	for _, r := range rows {
+		d.clientIDs[r.WorkoutID] = append(d.clientIDs[r.WorkoutID], r.ClientID.String())
+		if e.clients {
+			d.clients[r.WorkoutID] = append(d.clients[r.WorkoutID], workout.ClientRef{
+				ID:        r.ClientID.String(),
+				FirstName: r.FirstName,
+				LastName:  r.LastName,
+				Archived:  r.ArchivedAt != nil,
+			})
+		}
+	}
+
+	if e.exercises {
+		var err error
+		if d.exercises, err = a.loadWorkoutBlocks(workoutIDs); err != nil {
+			return workoutDetails{}, err
+		}
+	}
+
+	return d, nil
+}
+
+// loadWorkoutBlocks загружает упражнения и подходы тренировок тремя запросами на весь набор.
+func (a *App) loadWorkoutBlocks(workoutIDs []uuid.UUID) (map[uuid.UUID][]workout.ExerciseBlockResponse, error) {
+	result := make(map[uuid.UUID][]workout.ExerciseBlockResponse)
+
+	var blocks []workout.WorkoutBlock
+	if err := a.db.Where("workout_id IN ?", workoutIDs).Order("workout_id, position").Find(&blocks).Error; err != nil {
+		return nil, err
+	}
+	if len(blocks) == 0 {
+		return result, nil
+	}
+
+	blockIDs := make([]uuid.UUID, 0, len(blocks))
+	exerciseIDs := make([]uuid.UUID, 0, len(blocks))
+	for _, b := range blocks {
+		blockIDs = append(blockIDs, b.ID)
+		exerciseIDs = append(exerciseIDs, b.ExerciseID)
+	}
+
+	var sets []workout.WorkoutSet
+	if err := a.db.Where("block_id IN ?", blockIDs).Order("block_id, position").Find(&sets).Error; err != nil {
+		return nil, err
+	}
+	setsByBlock := make(map[uuid.UUID][]workout.SetData)
+	for _, s := range sets {
+		setsByBlock[s.BlockID] = append(setsByBlock[s.BlockID], s.ToSetData())
+	}
+
+	var exercises []exercise.Exercise
+	if err := a.db.Where("id IN ?", exerciseIDs).Find(&exercises).Error; err != nil {
+		return nil, err
+	}
+	names := make(map[uuid.UUID]string, len(exercises))
+	for _, ex := range exercises {
+		names[ex.ID] = ex.Name
+	}
+
+	for _, b := range blocks {
+		blockSets := setsByBlock[b.ID]
+		if blockSets == nil {
+			blockSets = []workout.SetData{}
+		}
+		result[b.WorkoutID] = append(result[b.WorkoutID], workout.ExerciseBlockResponse{
+			ExerciseID:   b.ExerciseID.String(),
+			ExerciseName: names[b.ExerciseID],
+			Notes:        b.Notes,
+			Sets:         blockSets,
+		})
+	}
+
+	return result, nil
+}
+
+// response собирает ответ по тренировке с её клиентами и упражнениями.
+func (d workoutDetails) response(w workout.Workout) workout.WorkoutResponse {
+	resp := toWorkoutResponse(w, d.clientIDs[w.ID])
+	if resp.ClientIDs == nil {
+		resp.ClientIDs = []string{}
+	}
+	if d.embeds.clients {
+		resp.Clients = d.clients[w.ID]
+	}
+	if d.embeds.exercises {
+		resp.Exercises = d.exercises[w.ID]
+	}
+	return resp
+}
@@ -0,0 +1,583 @@
+package app
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"traindesk/internal/client"
+	"traindesk/internal/exercise"
+	"traindesk/internal/org"
+	"traindesk/internal/recurrence"
+	"traindesk/internal/tz"
+	"traindesk/internal/workout"
+)
+
+const (
+	// seriesHorizon — на сколько вперёд создаются повторения серий.
+	seriesHorizon = 90 * 24 * time.Hour
+
+	// maxMaterializeBatch — предел повторений, создаваемых за один раз;
+	// остальные досоздаст фоновая задача.
+	maxMaterializeBatch = 500
+)
+
+var (
+	errSeriesStart  = errors.New("the new series start does not match the recurrence rule")
+	errSeriesShift  = errors.New("moving occurrences to another day with BYMONTHDAY or numbered BYDAY requires a new recurrence rule")
+	errSeriesMarked = errors.New("occurrences with marked attendance or results cannot be rebuilt by a new recurrence rule; change it with scope=following from a later occurrence")
+)
+
+// parseEditScope читает ?scope=this|following|all; при ошибке сам отвечает.
+func parseEditScope(c *gin.Context, w workout.Workout) (workout.EditScope, bool) {
+	scope := workout.EditScope(c.DefaultQuery("scope", string(workout.ScopeThis)))
+	switch scope {
+	case workout.ScopeThis:
+		return scope, true
+	case workout.ScopeFollowing, workout.ScopeAll:
+		if w.SeriesID == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "workout is not part of a series"})
+			return "", false
+		}
+		return scope, true
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"error": "scope must be this, following or all"})
+	return "", false
+}
+
+// applySeries переносит шаблон из запроса в серию.
+func (in workoutInput) applySeries(s *workout.WorkoutSeries) {
+	s.UserID = in.trainerID
+	s.StartsAt = in.schedule.StartsAt
+	s.AllDay = in.schedule.AllDay
+	s.TimeZone = in.schedule.TimeZone
+	s.DurationMin = in.schedule.DurationMin
+	s.TypeID = in.wtype.ID
+	s.Type = workout.WorkoutType(in.wtype.Name)
+	s.Notes = in.req.Notes
+	s.Room = in.req.Room
+	s.ClientIDs = in.clientIDs
+	s.Exercises = in.req.Exercises
+}
+
+// applyOccurrence переносит шаблон серии в её повторение со временем occ.
+func applyOccurrence(s workout.WorkoutSeries, w *workout.Workout, occ time.Time) {
+	occ = occ.UTC()
+	seriesID := s.ID
+
+	w.OrganizationID = s.OrganizationID
+	w.UserID = s.UserID
+	w.StartsAt = occ
+	w.EndsAt = occ.Add(time.Duration(s.DurationMin) * time.Minute)
+	if s.AllDay {
+		w.EndsAt = occ.AddDate(0, 0, 1)
+	}
+	w.AllDay = s.AllDay
+	w.TimeZone = s.TimeZone
+	w.DurationMin = s.DurationMin
+	w.TypeID = s.TypeID
+	w.Type = s.Type
+	w.Notes = s.Notes
+	w.Room = s.Room
+	w.SeriesID = &seriesID
+	w.OccurrenceAt = &occ
+}
+
+// startSeries делает сохранённую тренировку w первым повторением новой серии
+// по правилу из запроса и создаёт следующие повторения.
+func startSeries(tx *gorm.DB, w *workout.Workout, in workoutInput) error {
+	s := workout.WorkoutSeries{
+		ID:             uuid.New(),
+		OrganizationID: w.OrganizationID,
+		RRule:          in.rule.String(),
+		ExDates:        in.exDates,
+	}
+	in.applySeries(&s)
+	if err := tx.Create(&s).Error; err != nil {
+		return err
+	}
+
+	start := w.StartsAt
+	w.SeriesID, w.OccurrenceAt, w.Detached = &s.ID, &start, false
+	if err := tx.Model(w).Updates(map[string]interface{}{
+		"series_id":     s.ID,
+		"occurrence_at": start,
+		"detached":      false,
+	}).Error; err != nil {
+		return err
+	}
+
+	return materializeSeries(tx, &s, s.StartsAt, time.Now().Add(seriesHorizon))
+}
+
+// seriesContent — клиенты и упражнения для новых повторений серии. Клиенты,
+// которых с тех пор архивировали или удалили, и удалённые упражнения пропускаются.
+func seriesContent(tx *gorm.DB, s workout.WorkoutSeries) ([]uuid.UUID, []workout.ExerciseBlockInput, []uuid.UUID, error) {
+	var clientIDs []uuid.UUID
+	if len(s.ClientIDs) > 0 {
+		if err := tx.Model(&client.Client{}).
+			Where("id IN ? AND organization_id = ? AND archived_at IS NULL", s.ClientIDs, s.OrganizationID).
+			Pluck("id", &clientIDs).Error; err != nil {
+			return nil, nil, nil, err
+		}
+	}
+
+	if len(s.Exercises) == 0 {
+		return clientIDs, nil, nil, nil
+	}
+
+	ids := make([]uuid.UUID, 0, len(s.Exercises))
+	for _, b := range s.Exercises {
+		id, err := uuid.Parse(b.ExerciseID)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		ids = append(ids, id)
+	}
+	var existing []uuid.UUID
+	if err := tx.Model(&exercise.Exercise{}).Where("id IN ?", ids).Pluck("id", &existing).Error; err != nil {
+		return nil, nil, nil, err
+	}
+	exists := make(map[uuid.UUID]bool, len(existing))
+	for _, id := range existing {
+		exists[id] = true
+	}
+
+	blocks := make([]workout.ExerciseBlockInput, 0, len(s.Exercises))
+	exerciseIDs := make([]uuid.UUID, 0, len(s.Exercises))
+	for i, b := range s.Exercises {
+		if exists[ids[i]] {
+			blocks = append(blocks, b)
+			exerciseIDs = append(exerciseIDs, ids[i])
+		}
+	}
+	return clientIDs, blocks, exerciseIDs, nil
+}
+
+// materializeSeries создаёт недостающие повторения серии в [from, until).
+// Уже существующие (в том числе изменённые отдельно) не трогает.
+func materializeSeries(tx *gorm.DB, s *workout.WorkoutSeries, from, until time.Time) error {
+	set, err := s.Set()
+	if err != nil {
+		return err
+	}
+
+	var existing []time.Time
+	if err := tx.Model(&workout.Workout{}).
+		Where("series_id = ? AND occurrence_at >= ? AND occurrence_at < ?", s.ID, from, until).
+		Pluck("occurrence_at", &existing).Error; err != nil {
+		return err
+	}
+	have := make(map[int64]bool, len(existing))
+	for _, t := range existing {
+		have[t.Unix()] = true
+	}
+
+	clientIDs, blocks, exerciseIDs, err := seriesContent(tx, *s)
+	if err != nil {
+		return err
+	}
+
+	reached := until
+	created := 0
+	for _, occ := range set.Between(from, until) {
+		if have[occ.Unix()] {
+			continue
+		}
+		if created == maxMaterializeBatch {
+			reached = occ
+			break
+		}
+
+		w := workout.Workout{ID: uuid.New()}
+		applyOccurrence(*s, &w, occ)
+		if err := tx.Create(&w).Error; err != nil {
+			return err
+		}
+		if err := syncWorkoutClients(tx, w.ID, clientIDs); err != nil {
+			return err
+		}
+		if err := replaceWorkoutBlocks(tx, w.ID, blocks, exerciseIDs); err != nil {
+			return err
+		}
+		created++
+	}
+
+	s.MaterializedUntil = reached
+	return tx.Model(s).Update("materialized_until", reached).Error
+}
+
+// occurrenceShift переносит повторения серии так же, как пользователь
+// перенёс одно из них: на столько же дней и на новое время суток.
+type occurrenceShift struct {
+	fromLoc, toLoc *time.Location
+	days           int
+	hour, min, sec int
+}
+
+func newOccurrenceShift(origin time.Time, fromLoc *time.Location, target time.Time, toLoc *time.Location) occurrenceShift {
+	o, t := origin.In(fromLoc), target.In(toLoc)
+	od := time.Date(o.Year(), o.Month(), o.Day(), 0, 0, 0, 0, time.UTC)
+	td := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	return occurrenceShift{
+		fromLoc: fromLoc,
+		toLoc:   toLoc,
+		days:    int(td.Sub(od) / (24 * time.Hour)),
+		hour:    t.Hour(),
+		min:     t.Minute(),
+		sec:     t.Second(),
+	}
+}
+
+func (s occurrenceShift) apply(t time.Time) time.Time {
+	l := t.In(s.fromLoc)
+	wall := time.Date(l.Year(), l.Month(), l.Day()+s.days, s.hour, s.min, s.sec, 0, time.UTC)
+	return tz.ResolveLenient(wall, s.toLoc).UTC()
+}
+
+// splitExDates делит исключения серии на те, что раньше t, и остальные.
+func splitExDates(exDates []time.Time, t time.Time) (before, after []time.Time) {
+	for _, ex := range exDates {
+		if ex.Before(t) {
+			before = append(before, ex)
+		} else {
+			after = append(after, ex)
+		}
+	}
+	return before, after
+}
+
+// truncateSeries обрывает серию перед повторением split.
+func truncateSeries(tx *gorm.DB, s *workout.WorkoutSeries, rule recurrence.Rule, split time.Time) error {
+	rule.Count, rule.Until, rule.UntilDate = 0, split.Add(-time.Second), false
+	s.RRule = rule.String()
+	s.ExDates, _ = splitExDates(s.ExDates, split)
+	return tx.Save(s).Error
+}
+
+// updateSeries применяет изменение повторения w ко всей серии (scope=all) или
+// к нему и всем следующим (scope=following). Повторения, изменённые отдельно,
+// сохраняют своё время и состав. Если правило не меняется, повторения
+// обновляются на месте вместе с отметками и результатами клиентов; новое
+// правило пересоздаёт их, поэтому отказывает, если у них уже есть отметки
+// или результаты. Возвращает w после изменения (nil, если по новому
+// правилу такого повторения больше нет) и серию, в которую вошли повторения.
+func updateSeries(tx *gorm.DB, w workout.Workout, in workoutInput, scope workout.EditScope) (*workout.Workout, uuid.UUID, error) {
+	var s workout.WorkoutSeries
+	if err := tx.Where("id = ?", *w.SeriesID).First(&s).Error; err != nil {
+		return nil, uuid.Nil, err
+	}
+	oldSet, err := s.Set()
+	if err != nil {
+		return nil, uuid.Nil, err
+	}
+
+	origin := *w.OccurrenceAt
+	split := s.StartsAt
+	if scope == workout.ScopeFollowing {
+		split = origin
+	}
+
+	newLoc := time.UTC
+	if !in.schedule.AllDay {
+		newLoc = tz.LoadOrUTC(in.schedule.TimeZone)
+	}
+	shift := newOccurrenceShift(origin, s.Location(), in.schedule.StartsAt, newLoc)
+
+	_, tailExDates := splitExDates(s.ExDates, split)
+	rule, ok := oldSet.Rule.ShiftDays(shift.days)
+	if in.rule != nil {
+		rule = *in.rule
+	} else if !ok {
+		return nil, uuid.Nil, errSeriesShift
+	}
+
+	target := s
+	if split.After(s.StartsAt) {
+		if in.rule == nil && rule.Count > 0 {
+			rule.Count -= oldSet.CountBefore(split)
+		}
+		if err := truncateSeries(tx, &s, oldSet.Rule, split); err != nil {
+			return nil, uuid.Nil, err
+		}
+		target = workout.WorkoutSeries{ID: uuid.New(), OrganizationID: s.OrganizationID}
+	}
+
+	in.applySeries(&target)
+	target.StartsAt = shift.apply(split)
+	target.RRule = rule.String()
+	if in.rule != nil {
+		target.ExDates = in.exDates
+	} else {
+		target.ExDates = make([]time.Time, 0, len(tailExDates))
+		for _, ex := range tailExDates {
+			target.ExDates = append(target.ExDates, shift.apply(ex))
+		}
+	}
+	if !workout.IsSeriesStart(rule, target.StartsAt, target.AllDay, newLoc) {
+		return nil, uuid.Nil, errSeriesStart
+	}
+	if err := tx.Save(&target).Error; err != nil {
+		return nil, uuid.Nil, err
+	}
+
+	var occs []workout.Workout
+	q := tx.Where("series_id = ?", s.ID)
+	if scope == workout.ScopeFollowing {
+		q = q.Where("occurrence_at >= ?", split)
+	}
+	if err := q.Find(&occs).Error; err != nil {
+		return nil, uuid.Nil, err
+	}
+	ids := make([]uuid.UUID, 0, len(occs))
+	for _, o := range occs {
+		ids = append(ids, o.ID)
+	}
+
+	if in.rule == nil {
+		// Сначала снимаем исходное время со всех: при сдвиге повторения
+		// занимают места друг друга, а пара (серия, время) уникальна.
+		if len(ids) > 0 {
+			if err := tx.Model(&workout.Workout{}).Where("id IN ?", ids).Update("occurrence_at", nil).Error; err != nil {
+				return nil, uuid.Nil, err
+			}
+		}
+		for _, o := range occs {
+			occAt := shift.apply(*o.OccurrenceAt)
+			if o.ID == w.ID || !o.Detached {
+				o.Detached = false
+				applyOccurrence(target, &o, occAt)
+			} else {
+				o.SeriesID, o.OccurrenceAt = &target.ID, &occAt
+			}
+			if err := tx.Save(&o).Error; err != nil {
+				return nil, uuid.Nil, err
+			}
+			if !o.Detached {
+				if err := saveWorkoutContent(tx, o.ID, in); err != nil {
+					return nil, uuid.Nil, err
+				}
+			}
+		}
+	} else {
+		newSet, err := target.Set()
+		if err != nil {
+			return nil, uuid.Nil, err
+		}
+		editedStart := in.schedule.StartsAt
+		keepEdited := len(newSet.Between(editedStart, editedStart.Add(time.Second))) == 1
+
+		var drop, unlink []uuid.UUID
+		for _, o := range occs {
+			switch {
+			case o.ID == w.ID && keepEdited:
+			case o.Detached && o.ID != w.ID:
+				unlink = append(unlink, o.ID)
+			default:
+				drop = append(drop, o.ID)
+			}
+		}
+		if marked, err := hasMarkedOccurrences(tx, drop); err != nil {
+			return nil, uuid.Nil, err
+		} else if marked {
+			return nil, uuid.Nil, errSeriesMarked
+		}
+		if err := deleteWorkouts(tx, drop); err != nil {
+			return nil, uuid.Nil, err
+		}
+		if len(unlink) > 0 {
+			if err := tx.Model(&workout.Workout{}).Where("id IN ?", unlink).Updates(map[string]interface{}{
+				"series_id":     nil,
+				"occurrence_at": nil,
+				"detached":      false,
+			}).Error; err != nil {
+				return nil, uuid.Nil, err
+			}
+		}
+		if keepEdited {
+			edited := w
+			edited.Detached = false
+			applyOccurrence(target, &edited, editedStart)
+			if err := tx.Save(&edited).Error; err != nil {
+				return nil, uuid.Nil, err
+			}
+			if err := saveWorkoutContent(tx, edited.ID, in); err != nil {
+				return nil, uuid.Nil, err
+			}
+		}
+	}
+
+	horizon := time.Now().Add(seriesHorizon)
+	if target.MaterializedUntil.After(horizon) {
+		horizon = target.MaterializedUntil
+	}
+	if err := materializeSeries(tx, &target, target.StartsAt, horizon); err != nil {
+		return nil, uuid.Nil, err
+	}
+
+	var updated workout.Workout
+	if err := tx.Where("id = ?", w.ID).First(&updated).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, target.ID, nil
+		}
+		return nil, uuid.Nil, err
+	}
+	return &updated, target.ID, nil
+}
+
+// hasMarkedOccurrences — есть ли среди тренировок ids отметки посещения
+// или результаты клиентов, которые пропали бы при пересоздании.
+func hasMarkedOccurrences(tx *gorm.DB, ids []uuid.UUID) (bool, error) {
+	if len(ids) == 0 {
+		return false, nil
+	}
+	var n int64
+	if err := tx.Model(&workout.WorkoutClient{}).
+		Where("workout_id IN ? AND attendance <> ?", ids, workout.AttendanceBooked).
+		Count(&n).Error; err != nil || n > 0 {
+		return n > 0, err
+	}
+	if err := tx.Model(&workout.ClientSetResult{}).
+		Where("workout_id IN ?", ids).
+		Count(&n).Error; err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// deleteSeriesWorkouts удаляет повторение w (scope=this), его и все следующие
+// (scope=following) или всю серию (scope=all). Удалённое отдельное повторение
+// запоминается в EXDATE, чтобы его не создало снова.
+func deleteSeriesWorkouts(tx *gorm.DB, w workout.Workout, scope workout.EditScope) error {
+	if w.SeriesID == nil {
+		return deleteWorkouts(tx, []uuid.UUID{w.ID})
+	}
+
+	var s workout.WorkoutSeries
+	if err := tx.Where("id = ?", *w.SeriesID).First(&s).Error; err != nil {
+		return err
+	}
+
+	if scope == workout.ScopeThis {
+		if w.OccurrenceAt != nil {
+			s.ExDates = append(s.ExDates, w.OccurrenceAt.UTC())
+			if err := tx.Save(&s).Error; err != nil {
+				return err
+			}
+		}
+		return deleteWorkouts(tx, []uuid.UUID{w.ID})
+	}
+
+	split := s.StartsAt
+	if scope == workout.ScopeFollowing && w.OccurrenceAt != nil {
+		split = *w.OccurrenceAt
+	}
+
+	var ids []uuid.UUID
+	q := tx.Model(&workout.Workout{}).Where("series_id = ?", s.ID)
+	if split.After(s.StartsAt) {
+		q = q.Where("occurrence_at >= ?", split)
+	}
+	if err := q.Pluck("id", &ids).Error; err != nil {
+		return err
+	}
+	if err := deleteWorkouts(tx, ids); err != nil {
+		return err
+	}
+
+	if !split.After(s.StartsAt) {
+		return tx.Delete(&s).Error
+	}
+	set, err := s.Set()
+	if err != nil {
+		return err
+	}
+	return truncateSeries(tx, &s, set.Rule, split)
+}
+
+// extendSeries досоздаёт повторения серий на горизонт вперёд.
+func (a *App) extendSeries() {
+	horizon := time.Now().Add(seriesHorizon)
+
+	var series []workout.WorkoutSeries
+	if err := a.db.Where("materialized_until < ?", horizon).Find(&series).Error; err != nil {
+		log.Printf("maintenance: failed to load workout series: %v", err)
+		return
+	}
+
+	for i := range series {
+		s := &series[i]
+		if err := a.db.Transaction(func(tx *gorm.DB) error {
+			return materializeSeries(tx, s, s.MaterializedUntil, horizon)
+		}); err != nil {
+			log.Printf("maintenance: failed to extend series %s: %v", s.ID, err)
+		}
+	}
+}
+
+func toSeriesResponse(s workout.WorkoutSeries) workout.SeriesResponse {
+	loc := s.Location()
+	exDates := make([]string, 0, len(s.ExDates))
+	for _, ex := range s.ExDates {
+		exDates = append(exDates, ex.In(loc).Format(time.RFC3339))
+	}
+	clientIDs := make([]string, 0, len(s.ClientIDs))
+	for _, id := range s.ClientIDs {
+		clientIDs = append(clientIDs, id.String())
+	}
+
+	return workout.SeriesResponse{
+		ID:                s.ID.String(),
+		RRule:             s.RRule,
+		StartsAt:          s.StartsAt.In(loc).Format(time.RFC3339),
+		ExDates:           exDates,
+		AllDay:            s.AllDay,
+		TimeZone:          s.TimeZone,
+		DurationMin:       s.DurationMin,
+		TypeID:            s.TypeID.String(),
+		Type:              string(s.Type),
+		Notes:             s.Notes,
+		Room:              s.Room,
+		TrainerID:         s.UserID.String(),
+		ClientIDs:         clientIDs,
+		MaterializedUntil: s.MaterializedUntil.UTC().Format(time.RFC3339),
+	}
+}
+
+// handleGetSeries — серия повторяющихся тренировок.
+func (a *App) handleGetSeries(c *gin.Context) {
+	m, ok := currentMember(c)
+	if !ok {
+		return
+	}
+	if !requirePermission(c, m, org.PermWorkoutsReadOwn) {
+		return
+	}
+
+	seriesID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid series id"})
+		return
+	}
+
+	q := a.db.Where("id = ? AND organization_id = ?", seriesID, m.OrgID)
+	if !m.can(org.PermWorkoutsReadAll) {
+		q = q.Where("user_id = ?", m.UserID)
+	}
+	var s workout.WorkoutSeries
+	if err := q.First(&s).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "series not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load series"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, toSeriesResponse(s))
+}
@@ -0,0 +1,24 @@
+package calendar
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Feed — подписка на расписание в формате iCalendar по секретной ссылке
+// /calendar/<token>.ics. Без ClientID это расписание тренера (UserID),
+// с ClientID — только тренировки этого клиента. В БД хранится хеш токена.
+type Feed struct {
+	ID             uuid.UUID  `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	OrganizationID uuid.UUID  `gorm:"type:uuid;not null;index"`
+	UserID         uuid.UUID  `gorm:"type:uuid;not null;index"` // кто выпустил ссылку; лента видит то же, что он
+	ClientID       *uuid.UUID `gorm:"type:uuid;index"`
+
+	TokenHash string `gorm:"size:64;not null;uniqueIndex"`
+
+	LastUsedAt *time.Time
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
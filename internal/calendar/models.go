@@ -0,0 +1,23 @@
+package calendar
+
+// CreateFeedRequest — тело запроса на создание ленты.
+type CreateFeedRequest struct {
+	ClientID string `json:"client_id"` // пусто — расписание самого тренера
+}
+
+// FeedResponse — описание ленты без токена.
+type FeedResponse struct {
+	ID         string  `json:"id"`
+	ClientID   *string `json:"client_id"`
+	LastUsedAt *string `json:"last_used_at"`
+	CreatedAt  string  `json:"created_at"`
+	UpdatedAt  string  `json:"updated_at"`
+}
+
+// FeedTokenResponse — ответ при создании ленты и смене ссылки;
+// токен показывается один раз.
+type FeedTokenResponse struct {
+	FeedResponse
+	Token string `json:"token"`
+	Path  string `json:"path"` // /calendar/<token>.ics
+}
@@ -6,15 +6,60 @@ import (
 	"github.com/google/uuid"
 )
 
+// Gender — пол клиента; пустое значение — не указан.
+type Gender string
+
+const (
+	GenderMale   Gender = "male"
+	GenderFemale Gender = "female"
+	GenderOther  Gender = "other"
+)
+
+// ValidGenders — список допустимых значений пола.
+var ValidGenders = []Gender{
+	GenderMale,
+	GenderFemale,
+	GenderOther,
+}
+
 // Client — сущность клиента тренера.
 type Client struct {
-	ID     uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
-	UserID uuid.UUID `gorm:"type:uuid;not null;index"`
+	ID             uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	OrganizationID uuid.UUID `gorm:"type:uuid;index"`
+	UserID         uuid.UUID `gorm:"type:uuid;not null;index"` // тренер, добавивший клиента
 
 	FirstName string `gorm:"not null"`
 	LastName  string `gorm:"not null"`
-	// TODO: phone/email/notes при необходимости.
+
+	Phone     string     `gorm:"size:32"`
+	Email     string     `gorm:"size:255"`
+	BirthDate *time.Time `gorm:"type:date"`
+	Gender    Gender     `gorm:"type:varchar(16)"`
+
+	EmergencyContactName  string
+	EmergencyContactPhone string `gorm:"size:32"`
+
+	// Данные о здоровье — чувствительные, в списках по умолчанию не отдаются.
+	Injuries          string `gorm:"type:text"`
+	Contraindications string `gorm:"type:text"`
+
+	Goals string `gorm:"type:text"`
+	Notes string `gorm:"type:text"`
+
+	// ArchivedAt — клиент ушёл: в списках не показывается, но остаётся
+	// в истории тренировок и может быть восстановлен.
+	ArchivedAt *time.Time `gorm:"index"`
 
 	CreatedAt time.Time
 	UpdatedAt time.Time
 }
+
+// ClientTag — метка клиента («утро», «реабилитация», «абонемент»…).
+type ClientTag struct {
+	ClientID uuid.UUID `gorm:"type:uuid;primaryKey"`
+	Tag      string    `gorm:"size:64;primaryKey;index"`
+}
+
+// SearchExpr — выражение, по которому идёт полнотекстовый поиск клиентов.
+// По нему же построен триграммный индекс, поэтому менять его нужно синхронно с миграцией.
+const SearchExpr = "(coalesce(first_name, '') || ' ' || coalesce(last_name, '') || ' ' || coalesce(email, '') || ' ' || coalesce(phone, ''))"
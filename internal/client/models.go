@@ -4,7 +4,45 @@ package client
 type CreateClientRequest struct {
 	FirstName string `json:"first_name"`
 	LastName  string `json:"last_name"`
-	// TODO: при необходимости добавить phone/email/notes.
+
+	Phone     string `json:"phone"`
+	Email     string `json:"email"`
+	BirthDate string `json:"birth_date"` // YYYY-MM-DD
+	Gender    string `json:"gender"`
+
+	EmergencyContactName  string `json:"emergency_contact_name"`
+	EmergencyContactPhone string `json:"emergency_contact_phone"`
+
+	Injuries          string `json:"injuries"`
+	Contraindications string `json:"contraindications"`
+
+	Goals string `json:"goals"`
+	Notes string `json:"notes"`
+
+	Tags []string `json:"tags"`
+}
+
+// UpdateClientRequest — частичное обновление клиента; nil-поля не меняются.
+type UpdateClientRequest struct {
+	FirstName *string `json:"first_name"`
+	LastName  *string `json:"last_name"`
+
+	Phone     *string `json:"phone"`
+	Email     *string `json:"email"`
+	BirthDate *string `json:"birth_date"`
+	Gender    *string `json:"gender"`
+
+	EmergencyContactName  *string `json:"emergency_contact_name"`
+	EmergencyContactPhone *string `json:"emergency_contact_phone"`
+
+	Injuries          *string `json:"injuries"`
+	Contraindications *string `json:"contraindications"`
+
+	Goals *string `json:"goals"`
+	Notes *string `json:"notes"`
+
+	// Tags, если передан, заменяет весь набор меток.
+	Tags *[]string `json:"tags"`
 }
 
 // ClientResponse — то, что возвращаем клиенту во всех клиентских эндпоинтах.
@@ -12,5 +50,27 @@ type ClientResponse struct {
 	ID        string `json:"id"`
 	FirstName string `json:"first_name"`
 	LastName  string `json:"last_name"`
-	// TODO: добавить дату рождения и тд.
+
+	Phone     string  `json:"phone"`
+	Email     string  `json:"email"`
+	BirthDate *string `json:"birth_date"`
+	Gender    string  `json:"gender"`
+
+	EmergencyContactName  string `json:"emergency_contact_name"`
+	EmergencyContactPhone string `json:"emergency_contact_phone"`
+
+	Goals string   `json:"goals"`
+	Notes string   `json:"notes"`
+	Tags  []string `json:"tags"`
+
+	// Health заполняется только в карточке клиента или по include=health.
+	Health *HealthResponse `json:"health,omitempty"`
+
+	ArchivedAt *string `json:"archived_at,omitempty"`
+}
+
+// HealthResponse — чувствительные сведения о здоровье клиента.
+type HealthResponse struct {
+	Injuries          string `json:"injuries"`
+	Contraindications string `json:"contraindications"`
 }
@@ -0,0 +1,238 @@
+package client
+
+import (
+	"fmt"
+	"net/mail"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	maxNameLen = 100
+	maxTextLen = 4000
+	maxTags    = 20
+	maxTagLen  = 64
+)
+
+// ValidationError — ошибка в конкретном поле запроса.
+type ValidationError struct {
+	Field   string
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return e.Field + ": " + e.Message
+}
+
+func invalid(field, msg string) error {
+	return &ValidationError{Field: field, Message: msg}
+}
+
+// IsValidGender проверяет, что строка — пустая или одно из известных значений.
+func IsValidGender(g string) bool {
+	if g == "" {
+		return true
+	}
+	for _, v := range ValidGenders {
+		if Gender(g) == v {
+			return true
+		}
+	}
+	return false
+}
+
+// NormalizePhone убирает из номера пробелы, скобки и дефисы.
+// Допускается ведущий «+» и от 7 до 15 цифр (E.164).
+func NormalizePhone(s string) (string, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return "", nil
+	}
+
+	var b strings.Builder
+	for i, r := range s {
+		switch {
+		case r >= '0' && r <= '9':
+			b.WriteRune(r)
+		case r == '+' && i == 0:
+			b.WriteRune(r)
+		case r == ' ' || r == '-' || r == '(' || r == ')':
+		default:
+			return "", fmt.Errorf("unexpected character %q", r)
+		}
+	}
+
+	digits := strings.TrimPrefix(b.String(), "+")
+	if len(digits) < 7 || len(digits) > 15 {
+		return "", fmt.Errorf("must contain 7 to 15 digits")
+	}
+	return b.String(), nil
+}
+
+// NormalizeEmail проверяет адрес и возвращает его без лишних пробелов.
+func NormalizeEmail(s string) (string, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return "", nil
+	}
+
+	addr, err := mail.ParseAddress(s)
+	if err != nil || addr.Address != s {
+		return "", fmt.Errorf("invalid email address")
+	}
+	return s, nil
+}
+
+// ParseBirthDate разбирает дату рождения в формате YYYY-MM-DD.
+// Пустая строка означает «не указана».
+func ParseBirthDate(s string) (*time.Time, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, nil
+	}
+
+	d, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid date format, expected YYYY-MM-DD")
+	}
+	if d.After(time.Now()) || d.Year() < 1900 {
+		return nil, fmt.Errorf("date is out of range")
+	}
+	return &d, nil
+}
+
+// NormalizeTags приводит метки к нижнему регистру, убирает пустые и дубли.
+func NormalizeTags(tags []string) ([]string, error) {
+	seen := make(map[string]bool, len(tags))
+	out := make([]string, 0, len(tags))
+	for _, t := range tags {
+		t = strings.ToLower(strings.TrimSpace(t))
+		if t == "" || seen[t] {
+			continue
+		}
+		if utf8.RuneCountInString(t) > maxTagLen {
+			return nil, fmt.Errorf("tag must be at most %d characters", maxTagLen)
+		}
+		seen[t] = true
+		out = append(out, t)
+	}
+	if len(out) > maxTags {
+		return nil, fmt.Errorf("at most %d tags are allowed", maxTags)
+	}
+	return out, nil
+}
+
+// Validate нормализует поля запроса и проверяет их.
+func (r *CreateClientRequest) Validate() error {
+	r.FirstName = strings.TrimSpace(r.FirstName)
+	r.LastName = strings.TrimSpace(r.LastName)
+	if r.FirstName == "" || r.LastName == "" {
+		return invalid("first_name", "first_name and last_name are required")
+	}
+
+	for _, f := range []struct {
+		name  string
+		value string
+		max   int
+	}{
+		{"first_name", r.FirstName, maxNameLen},
+		{"last_name", r.LastName, maxNameLen},
+		{"emergency_contact_name", r.EmergencyContactName, maxNameLen},
+		{"injuries", r.Injuries, maxTextLen},
+		{"contraindications", r.Contraindications, maxTextLen},
+		{"goals", r.Goals, maxTextLen},
+		{"notes", r.Notes, maxTextLen},
+	} {
+		if utf8.RuneCountInString(f.value) > f.max {
+			return invalid(f.name, fmt.Sprintf("must be at most %d characters", f.max))
+		}
+	}
+
+	var err error
+	if r.Phone, err = NormalizePhone(r.Phone); err != nil {
+		return invalid("phone", err.Error())
+	}
+	if r.EmergencyContactPhone, err = NormalizePhone(r.EmergencyContactPhone); err != nil {
+		return invalid("emergency_contact_phone", err.Error())
+	}
+	if r.Email, err = NormalizeEmail(r.Email); err != nil {
+		return invalid("email", err.Error())
+	}
+	if _, err := ParseBirthDate(r.BirthDate); err != nil {
+		return invalid("birth_date", err.Error())
+	}
+	if !IsValidGender(r.Gender) {
+		return invalid("gender", "must be one of male, female, other")
+	}
+
+	if r.Tags, err = NormalizeTags(r.Tags); err != nil {
+		return invalid("tags", err.Error())
+	}
+
+	r.EmergencyContactName = strings.TrimSpace(r.EmergencyContactName)
+	return nil
+}
+
+// Apply переносит проверенный запрос в сущность клиента.
+func (r CreateClientRequest) Apply(cl *Client) {
+	cl.FirstName = r.FirstName
+	cl.LastName = r.LastName
+	cl.Phone = r.Phone
+	cl.Email = r.Email
+	cl.BirthDate, _ = ParseBirthDate(r.BirthDate)
+	cl.Gender = Gender(r.Gender)
+	cl.EmergencyContactName = r.EmergencyContactName
+	cl.EmergencyContactPhone = r.EmergencyContactPhone
+	cl.Injuries = r.Injuries
+	cl.Contraindications = r.Contraindications
+	cl.Goals = r.Goals
+	cl.Notes = r.Notes
+}
+
+// RequestFromClient — текущее состояние клиента в виде запроса на создание;
+// на него накладывается UpdateClientRequest перед повторной проверкой.
+func RequestFromClient(cl Client, tags []string) CreateClientRequest {
+	r := CreateClientRequest{
+		FirstName:             cl.FirstName,
+		LastName:              cl.LastName,
+		Phone:                 cl.Phone,
+		Email:                 cl.Email,
+		Gender:                string(cl.Gender),
+		EmergencyContactName:  cl.EmergencyContactName,
+		EmergencyContactPhone: cl.EmergencyContactPhone,
+		Injuries:              cl.Injuries,
+		Contraindications:     cl.Contraindications,
+		Goals:                 cl.Goals,
+		Notes:                 cl.Notes,
+		Tags:                  tags,
+	}
+	if cl.BirthDate != nil {
+		r.BirthDate = cl.BirthDate.Format("2006-01-02")
+	}
+	return r
+}
+
+// Merge накладывает заданные поля частичного обновления на r.
+func (u UpdateClientRequest) Merge(r *CreateClientRequest) {
+	set := func(dst *string, src *string) {
+		if src != nil {
+			*dst = *src
+		}
+	}
+	set(&r.FirstName, u.FirstName)
+	set(&r.LastName, u.LastName)
+	set(&r.Phone, u.Phone)
+	set(&r.Email, u.Email)
+	set(&r.BirthDate, u.BirthDate)
+	set(&r.Gender, u.Gender)
+	set(&r.EmergencyContactName, u.EmergencyContactName)
+	set(&r.EmergencyContactPhone, u.EmergencyContactPhone)
+	set(&r.Injuries, u.Injuries)
+	set(&r.Contraindications, u.Contraindications)
+	set(&r.Goals, u.Goals)
+	set(&r.Notes, u.Notes)
+	if u.Tags != nil {
+		r.Tags = *u.Tags
+	}
+}
@@ -3,6 +3,7 @@ package config
 import (
 	"log"
 	"os"
+	"strings"
 
 	"github.com/joho/godotenv"
 )
@@ -16,6 +17,18 @@ type Config struct {
 
 	JWTSecret string
 	HTTPPort  string
+
+	// JWTKeysDir — каталог с ключами подписи (<kid>.pem, <kid>.pub.pem).
+	// Если не задан, токены подписываются HS256 с JWTSecret.
+	JWTKeysDir   string
+	JWTActiveKID string
+
+	// ThrottleStore — где хранить счётчики неудачных попыток: "memory" или "postgres".
+	ThrottleStore string
+
+	// TrustedProxies — адреса и подсети прокси, которым можно верить в
+	// X-Forwarded-For. Пусто — заголовок игнорируется, IP берётся из соединения.
+	TrustedProxies []string
 }
 
 type SMTPConfig struct {
@@ -40,6 +53,21 @@ func Load() Config {
 		DBName:     os.Getenv("DB_NAME"),
 		JWTSecret:  os.Getenv("JWT_SECRET"),
 		HTTPPort:   os.Getenv("HTTP_PORT"),
+
+		JWTKeysDir:   os.Getenv("JWT_KEYS_DIR"),
+		JWTActiveKID: os.Getenv("JWT_ACTIVE_KID"),
+
+		ThrottleStore: os.Getenv("THROTTLE_STORE"),
+	}
+
+	for _, p := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			cfg.TrustedProxies = append(cfg.TrustedProxies, p)
+		}
+	}
+
+	if cfg.ThrottleStore == "" {
+		cfg.ThrottleStore = "memory"
 	}
 
 	if cfg.JWTSecret == "dev-secret-key" {
@@ -7,8 +7,13 @@ import (
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 
+	"traindesk/internal/apikey"
+	"traindesk/internal/calendar"
 	"traindesk/internal/client"
 	"traindesk/internal/config"
+	"traindesk/internal/exercise"
+	"traindesk/internal/org"
+	"traindesk/internal/throttle"
 	"traindesk/internal/user"
 	"traindesk/internal/workout"
 )
@@ -49,11 +54,60 @@ func NewDB() (*DB, error) {
 }
 
 func autoMigrate(gormDB *gorm.DB) error {
-	return gormDB.AutoMigrate(
+	// До AutoMigrate: он не сможет добавить NOT NULL starts_at к непустой таблице.
+	if err := migrateWorkoutTimes(gormDB); err != nil {
+		return err
+	}
+	// Тоже до AutoMigrate: type_id обязателен и заполняется по старой колонке type.
+	if err := migrateWorkoutTypes(gormDB); err != nil {
+		return err
+	}
+	// И здесь: exercise_id у результатов обязателен и берётся из упражнений тренировки.
+	if err := migrateSetResultExercises(gormDB); err != nil {
+		return err
+	}
+
+	err := gormDB.AutoMigrate(
 		&user.User{},
 		&client.Client{},
 		&workout.Workout{},
 		&workout.WorkoutClient{},
 		&user.EmailVerification{},
+		&user.Session{},
+		&user.PasswordReset{},
+		&throttle.Attempt{},
+		&user.RecoveryCode{},
+		&apikey.APIKey{},
+		&user.EmailChange{},
+		&user.DataExport{},
+		&org.Organization{},
+		&org.Membership{},
+		&org.Invitation{},
+		&client.ClientTag{},
+		&exercise.Exercise{},
+		&workout.WorkoutBlock{},
+		&workout.WorkoutSet{},
+		&workout.ClientSetResult{},
+		&workout.WorkoutSeries{},
+		&calendar.Feed{},
+		&workout.Type{},
+		&workout.Template{},
 	)
+	if err != nil {
+		return err
+	}
+
+	if err := backfillOrganizations(gormDB); err != nil {
+		return err
+	}
+
+	if err := migrateAttendance(gormDB); err != nil {
+		return err
+	}
+
+	if err := createClientSearchIndex(gormDB); err != nil {
+		return err
+	}
+
+	return exercise.SeedGlobal(gormDB)
 }
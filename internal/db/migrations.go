@@ -0,0 +1,237 @@
+package db
+
+import (
+	"gorm.io/gorm"
+
+	"traindesk/internal/client"
+	"traindesk/internal/org"
+	"traindesk/internal/user"
+	"traindesk/internal/workout"
+)
+
+// backfillOrganizations создаёт личную организацию каждому тренеру, у которого
+// её ещё нет (аккаунты до появления организаций), и переносит в неё его
+// клиентов и тренировки.
+func backfillOrganizations(gormDB *gorm.DB) error {
+	var users []user.User
+	if err := gormDB.
+		Where("NOT EXISTS (SELECT 1 FROM memberships m WHERE m.user_id = users.id)").
+		Find(&users).Error; err != nil {
+		return err
+	}
+
+	for _, u := range users {
+		err := gormDB.Transaction(func(tx *gorm.DB) error {
+			o, err := org.CreatePersonal(tx, u.ID, u.TrainerName)
+			if err != nil {
+				return err
+			}
+
+			if err := tx.Model(&client.Client{}).
+				Where("user_id = ? AND organization_id IS NULL", u.ID).
+				Update("organization_id", o.ID).Error; err != nil {
+				return err
+			}
+			return tx.Model(&workout.Workout{}).
+				Where("user_id = ? AND organization_id IS NULL", u.ID).
+				Update("organization_id", o.ID).Error
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// createClientSearchIndex включает pg_trgm и строит триграммный индекс
+// по client.SearchExpr, чтобы ILIKE '%...%' не читал всю таблицу.
+func createClientSearchIndex(gormDB *gorm.DB) error {
+	if err := gormDB.Exec("CREATE EXTENSION IF NOT EXISTS pg_trgm").Error; err != nil {
+		return err
+	}
+	return gormDB.Exec(
+		"CREATE INDEX IF NOT EXISTS idx_clients_search_trgm ON clients USING gin (" +
+			client.SearchExpr + " gin_trgm_ops)",
+	).Error
+}
+
+// migrateAttendance переносит отметки из старой колонки attended (true/false)
+// в статус посещения и заполняет время записи для связей без него.
+func migrateAttendance(gormDB *gorm.DB) error {
+	if gormDB.Migrator().HasColumn("workout_clients", "attended") {
+		err := gormDB.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Exec(`UPDATE workout_clients
+				SET attendance = CASE WHEN attended THEN 'attended' ELSE 'no_show' END,
+				    attendance_marked_at = now()
+				WHERE attended IS NOT NULL`).Error; err != nil {
+				return err
+			}
+			return tx.Migrator().DropColumn("workout_clients", "attended")
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return gormDB.Exec(`UPDATE workout_clients wc SET booked_at = w.created_at
+		FROM workouts w
+		WHERE w.id = wc.workout_id AND wc.booked_at IS NULL`).Error
+}
+
+// migrateWorkoutTimes переводит тренировки со старой колонки date (полночь UTC)
+// на starts_at/ends_at. Старые тренировки становятся тренировками на весь день:
+// время у них не задавалось, а дата должна остаться той же.
+func migrateWorkoutTimes(gormDB *gorm.DB) error {
+	m := gormDB.Migrator()
+	if !m.HasTable("workouts") || !m.HasColumn("workouts", "date") || m.HasColumn("workouts", "starts_at") {
+		return nil
+	}
+
+	return gormDB.Transaction(func(tx *gorm.DB) error {
+		for _, stmt := range []string{
+			`ALTER TABLE workouts
+				ADD COLUMN starts_at timestamptz,
+				ADD COLUMN ends_at timestamptz,
+				ADD COLUMN all_day boolean NOT NULL DEFAULT false,
+				ADD COLUMN time_zone varchar(64) NOT NULL DEFAULT 'UTC'`,
+			`UPDATE workouts SET
+				starts_at = date_trunc('day', date AT TIME ZONE 'UTC') AT TIME ZONE 'UTC',
+				ends_at = (date_trunc('day', date AT TIME ZONE 'UTC') + interval '1 day') AT TIME ZONE 'UTC',
+				all_day = true`,
+			`ALTER TABLE workouts
+				ALTER COLUMN starts_at SET NOT NULL,
+				ALTER COLUMN ends_at SET NOT NULL,
+				DROP COLUMN date`,
+		} {
+			if err := tx.Exec(stmt).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// migrateWorkoutTypes переводит тренировки и серии со строкового типа на
+// справочник видов: заводит таблицу с общими видами, добавляет type_id и
+// заполняет его по названию. Названия, которых нет среди общих видов,
+// становятся видами своей организации. В схеме до появления организаций
+// колонки organization_id ещё нет — тогда типы были фиксированными,
+// и хватает общих видов.
+func migrateWorkoutTypes(gormDB *gorm.DB) error {
+	if err := gormDB.AutoMigrate(&workout.Type{}); err != nil {
+		return err
+	}
+	if err := workout.SeedGlobalTypes(gormDB); err != nil {
+		return err
+	}
+
+	m := gormDB.Migrator()
+	for _, table := range []string{"workouts", "workout_series"} {
+		if !m.HasTable(table) || m.HasColumn(table, "type_id") {
+			continue
+		}
+		stmts := []string{`ALTER TABLE ` + table + ` ADD COLUMN type_id uuid`}
+		if m.HasColumn(table, "organization_id") {
+			stmts = append(stmts,
+				`INSERT INTO workout_types (id, organization_id, name, color, created_at, updated_at)
+					SELECT uuid_generate_v4(), x.organization_id, x.type, '', now(), now()
+					FROM (SELECT DISTINCT organization_id, type FROM `+table+`) x
+					WHERE x.organization_id IS NOT NULL AND NOT EXISTS (
+						SELECT 1 FROM workout_types t
+						WHERE lower(t.name) = lower(x.type)
+						  AND (t.organization_id IS NULL OR t.organization_id = x.organization_id))`,
+				`UPDATE `+table+` w SET type_id = t.id
+					FROM workout_types t
+					WHERE lower(t.name) = lower(w.type)
+					  AND (t.organization_id IS NULL OR t.organization_id = w.organization_id)`,
+			)
+		} else {
+			stmts = append(stmts,
+				`UPDATE `+table+` w SET type_id = t.id
+					FROM workout_types t
+					WHERE lower(t.name) = lower(w.type) AND t.organization_id IS NULL`,
+			)
+		}
+		stmts = append(stmts, `ALTER TABLE `+table+` ALTER COLUMN type_id SET NOT NULL`)
+
+		err := gormDB.Transaction(func(tx *gorm.DB) error {
+			for _, stmt := range stmts {
+				if err := tx.Exec(stmt).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return createWorkoutTypeNameIndex(gormDB)
+}
+
+// createWorkoutTypeNameIndex делает названия видов уникальными без учёта
+// регистра: в общем справочнике и внутри организации. Дубли, успевшие
+// появиться до индекса, сливаются в самый ранний вид.
+func createWorkoutTypeNameIndex(gormDB *gorm.DB) error {
+	m := gormDB.Migrator()
+	if m.HasIndex(&workout.Type{}, "idx_workout_types_org_name") {
+		return nil
+	}
+
+	const dups = `SELECT id, first_value(id) OVER (
+			PARTITION BY organization_id, lower(name) ORDER BY created_at, id) AS keep
+		FROM workout_types`
+	return gormDB.Transaction(func(tx *gorm.DB) error {
+		for _, table := range []string{"workouts", "workout_series", "workout_templates"} {
+			if !m.HasTable(table) {
+				continue
+			}
+			if err := tx.Exec(`UPDATE ` + table + ` x SET type_id = d.keep
+				FROM (` + dups + `) d
+				WHERE x.type_id = d.id AND d.id <> d.keep`).Error; err != nil {
+				return err
+			}
+		}
+		for _, stmt := range []string{
+			`DELETE FROM workout_types t USING (` + dups + `) d
+				WHERE t.id = d.id AND d.id <> d.keep`,
+			`CREATE UNIQUE INDEX idx_workout_types_global_name
+				ON workout_types (lower(name)) WHERE organization_id IS NULL`,
+			`CREATE UNIQUE INDEX idx_workout_types_org_name
+				ON workout_types (organization_id, lower(name)) WHERE organization_id IS NOT NULL`,
+		} {
+			if err := tx.Exec(stmt).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// migrateSetResultExercises добавляет результатам подходов упражнение,
+// к которому они относятся, беря его из текущего состава тренировки.
+// Результаты без упражнения на своей позиции уже ни к чему не относятся
+// и удаляются.
+func migrateSetResultExercises(gormDB *gorm.DB) error {
+	m := gormDB.Migrator()
+	if !m.HasTable("client_set_results") || m.HasColumn("client_set_results", "exercise_id") {
+		return nil
+	}
+
+	return gormDB.Transaction(func(tx *gorm.DB) error {
+		for _, stmt := range []string{
+			`ALTER TABLE client_set_results ADD COLUMN exercise_id uuid`,
+			`UPDATE client_set_results r SET exercise_id = b.exercise_id
+				FROM workout_blocks b
+				WHERE b.workout_id = r.workout_id AND b.position = r.block_position`,
+			`DELETE FROM client_set_results WHERE exercise_id IS NULL`,
+			`ALTER TABLE client_set_results ALTER COLUMN exercise_id SET NOT NULL`,
+		} {
+			if err := tx.Exec(stmt).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
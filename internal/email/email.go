@@ -4,7 +4,9 @@ package email
 
 import (
 	"fmt"
+	"mime"
 	"net/smtp"
+	"strings"
 
 	"traindesk/internal/config"
 )
@@ -34,10 +36,50 @@ func NewSender() *Sender {
 }
 
 func (s *Sender) SendVerificationEmail(toEmail, code string) error {
-	// Простейшее текстовое письмо.
 	subject := "TrainDesk: подтверждение почты"
 	body := fmt.Sprintf("Ваш код подтверждения: %s", code)
 
+	return s.send(toEmail, subject, body)
+}
+
+// SendPasswordResetEmail отправляет одноразовый код для сброса пароля.
+func (s *Sender) SendPasswordResetEmail(toEmail, code string) error {
+	subject := "TrainDesk: сброс пароля"
+	body := fmt.Sprintf(
+		"Ваш код для сброса пароля: %s\r\nЕсли вы не запрашивали сброс, просто проигнорируйте это письмо.",
+		code,
+	)
+
+	return s.send(toEmail, subject, body)
+}
+
+// SendEmailChangeEmail отправляет код подтверждения на новый адрес при смене e-mail.
+func (s *Sender) SendEmailChangeEmail(toEmail, code string) error {
+	subject := "TrainDesk: смена почты"
+	body := fmt.Sprintf("Код для подтверждения нового адреса: %s", code)
+
+	return s.send(toEmail, subject, body)
+}
+
+// SendInvitationEmail отправляет приглашение в организацию с токеном для принятия.
+func (s *Sender) SendInvitationEmail(toEmail, orgName, token string) error {
+	subject := "TrainDesk: приглашение в " + orgName
+	body := fmt.Sprintf(
+		"Вас пригласили в организацию «%s».\r\nТокен приглашения: %s\r\nВойдите в TrainDesk под этим адресом и примите приглашение.",
+		orgName, token,
+	)
+
+	return s.send(toEmail, subject, body)
+}
+
+// headerBreaks — переводы строк, которыми в заголовок можно было бы дописать свои.
+var headerBreaks = strings.NewReplacer("\r", " ", "\n", " ")
+
+// send отправляет простейшее текстовое письмо. Тема кодируется по RFC 2047:
+// в ней бывают данные пользователей (название организации), и они не должны
+// попасть в заголовки письма.
+func (s *Sender) send(toEmail, subject, body string) error {
+	subject = mime.QEncoding.Encode("utf-8", headerBreaks.Replace(subject))
 	msg := []byte(
 		"To: " + toEmail + "\r\n" +
 			"Subject: " + subject + "\r\n" +
@@ -0,0 +1,94 @@
+package exercise
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MuscleGroup — группа мышц, на которую направлено упражнение.
+type MuscleGroup string
+
+const (
+	MuscleChest      MuscleGroup = "chest"
+	MuscleBack       MuscleGroup = "back"
+	MuscleShoulders  MuscleGroup = "shoulders"
+	MuscleBiceps     MuscleGroup = "biceps"
+	MuscleTriceps    MuscleGroup = "triceps"
+	MuscleForearms   MuscleGroup = "forearms"
+	MuscleCore       MuscleGroup = "core"
+	MuscleGlutes     MuscleGroup = "glutes"
+	MuscleQuadriceps MuscleGroup = "quadriceps"
+	MuscleHamstrings MuscleGroup = "hamstrings"
+	MuscleCalves     MuscleGroup = "calves"
+	MuscleFullBody   MuscleGroup = "full_body"
+	MuscleCardio     MuscleGroup = "cardio"
+)
+
+// ValidMuscleGroups — список допустимых групп мышц.
+var ValidMuscleGroups = []MuscleGroup{
+	MuscleChest, MuscleBack, MuscleShoulders, MuscleBiceps, MuscleTriceps, MuscleForearms,
+	MuscleCore, MuscleGlutes, MuscleQuadriceps, MuscleHamstrings, MuscleCalves,
+	MuscleFullBody, MuscleCardio,
+}
+
+// IsValidMuscleGroup проверяет, что строка — одна из известных групп мышц.
+func IsValidMuscleGroup(g string) bool {
+	for _, v := range ValidMuscleGroups {
+		if MuscleGroup(g) == v {
+			return true
+		}
+	}
+	return false
+}
+
+// Equipment — необходимый инвентарь.
+type Equipment string
+
+const (
+	EquipmentNone       Equipment = "none"
+	EquipmentBarbell    Equipment = "barbell"
+	EquipmentDumbbell   Equipment = "dumbbell"
+	EquipmentKettlebell Equipment = "kettlebell"
+	EquipmentMachine    Equipment = "machine"
+	EquipmentCable      Equipment = "cable"
+	EquipmentBand       Equipment = "band"
+	EquipmentOther      Equipment = "other"
+)
+
+// ValidEquipment — список допустимого инвентаря.
+var ValidEquipment = []Equipment{
+	EquipmentNone, EquipmentBarbell, EquipmentDumbbell, EquipmentKettlebell,
+	EquipmentMachine, EquipmentCable, EquipmentBand, EquipmentOther,
+}
+
+// IsValidEquipment проверяет, что строка — один из известных видов инвентаря.
+func IsValidEquipment(e string) bool {
+	for _, v := range ValidEquipment {
+		if Equipment(e) == v {
+			return true
+		}
+	}
+	return false
+}
+
+// Exercise — упражнение из справочника.
+// Глобальные упражнения (OrganizationID == nil) общие для всех и не редактируются;
+// остальные заводят тренеры своей организации.
+type Exercise struct {
+	ID             uuid.UUID  `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	OrganizationID *uuid.UUID `gorm:"type:uuid;index"`
+	CreatedBy      *uuid.UUID `gorm:"type:uuid"`
+
+	Name         string        `gorm:"not null"`
+	MuscleGroups []MuscleGroup `gorm:"type:jsonb;serializer:json"`
+	Equipment    Equipment     `gorm:"type:varchar(32);not null"`
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// IsGlobal — упражнение из общего справочника.
+func (e Exercise) IsGlobal() bool {
+	return e.OrganizationID == nil
+}
@@ -0,0 +1,17 @@
+package exercise
+
+// CreateExerciseRequest — тело запроса для создания упражнения организации.
+type CreateExerciseRequest struct {
+	Name         string   `json:"name"`
+	MuscleGroups []string `json:"muscle_groups"`
+	Equipment    string   `json:"equipment"`
+}
+
+// ExerciseResponse — упражнение справочника.
+type ExerciseResponse struct {
+	ID           string   `json:"id"`
+	Name         string   `json:"name"`
+	MuscleGroups []string `json:"muscle_groups"`
+	Equipment    string   `json:"equipment"`
+	Global       bool     `json:"global"`
+}
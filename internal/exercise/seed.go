@@ -0,0 +1,69 @@
+package exercise
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type seedExercise struct {
+	name      string
+	groups    []MuscleGroup
+	equipment Equipment
+}
+
+// globalSeed — базовый справочник, доступный всем организациям.
+var globalSeed = []seedExercise{
+	{"Приседания со штангой", []MuscleGroup{MuscleQuadriceps, MuscleGlutes, MuscleHamstrings}, EquipmentBarbell},
+	{"Становая тяга", []MuscleGroup{MuscleBack, MuscleGlutes, MuscleHamstrings}, EquipmentBarbell},
+	{"Жим штанги лёжа", []MuscleGroup{MuscleChest, MuscleTriceps, MuscleShoulders}, EquipmentBarbell},
+	{"Жим штанги стоя", []MuscleGroup{MuscleShoulders, MuscleTriceps}, EquipmentBarbell},
+	{"Тяга штанги в наклоне", []MuscleGroup{MuscleBack, MuscleBiceps}, EquipmentBarbell},
+	{"Подтягивания", []MuscleGroup{MuscleBack, MuscleBiceps}, EquipmentNone},
+	{"Отжимания", []MuscleGroup{MuscleChest, MuscleTriceps}, EquipmentNone},
+	{"Отжимания на брусьях", []MuscleGroup{MuscleChest, MuscleTriceps}, EquipmentNone},
+	{"Выпады с гантелями", []MuscleGroup{MuscleQuadriceps, MuscleGlutes}, EquipmentDumbbell},
+	{"Румынская тяга", []MuscleGroup{MuscleHamstrings, MuscleGlutes}, EquipmentBarbell},
+	{"Жим гантелей сидя", []MuscleGroup{MuscleShoulders, MuscleTriceps}, EquipmentDumbbell},
+	{"Сгибание рук с гантелями", []MuscleGroup{MuscleBiceps}, EquipmentDumbbell},
+	{"Разгибание рук на блоке", []MuscleGroup{MuscleTriceps}, EquipmentCable},
+	{"Тяга верхнего блока", []MuscleGroup{MuscleBack, MuscleBiceps}, EquipmentCable},
+	{"Жим ногами", []MuscleGroup{MuscleQuadriceps, MuscleGlutes}, EquipmentMachine},
+	{"Подъём на носки", []MuscleGroup{MuscleCalves}, EquipmentMachine},
+	{"Махи гирей", []MuscleGroup{MuscleGlutes, MuscleHamstrings, MuscleFullBody}, EquipmentKettlebell},
+	{"Планка", []MuscleGroup{MuscleCore}, EquipmentNone},
+	{"Скручивания", []MuscleGroup{MuscleCore}, EquipmentNone},
+	{"Бёрпи", []MuscleGroup{MuscleFullBody, MuscleCardio}, EquipmentNone},
+	{"Бег", []MuscleGroup{MuscleCardio}, EquipmentNone},
+	{"Гребля на тренажёре", []MuscleGroup{MuscleCardio, MuscleBack}, EquipmentMachine},
+	{"Велотренажёр", []MuscleGroup{MuscleCardio, MuscleQuadriceps}, EquipmentMachine},
+}
+
+// SeedGlobal добавляет недостающие упражнения базового справочника.
+// Безопасно вызывать при каждом старте.
+func SeedGlobal(db *gorm.DB) error {
+	var existing []string
+	if err := db.Model(&Exercise{}).Where("organization_id IS NULL").Pluck("name", &existing).Error; err != nil {
+		return err
+	}
+	have := make(map[string]bool, len(existing))
+	for _, n := range existing {
+		have[n] = true
+	}
+
+	var missing []Exercise
+	for _, s := range globalSeed {
+		if have[s.name] {
+			continue
+		}
+		missing = append(missing, Exercise{
+			ID:           uuid.New(),
+			Name:         s.name,
+			MuscleGroups: s.groups,
+			Equipment:    s.equipment,
+		})
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	return db.Create(&missing).Error
+}
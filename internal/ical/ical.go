@@ -0,0 +1,166 @@
+// Package ical — запись календаря в формате iCalendar (RFC 5545): только то,
+// что нужно для подписки на расписание — VCALENDAR с событиями VEVENT.
+package ical
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	utcLayout  = "20060102T150405Z"
+	dateLayout = "20060102"
+
+	// maxLineOctets — предел длины строки без CRLF; длинные строки переносятся.
+	maxLineOctets = 75
+)
+
+// Calendar — календарь с событиями.
+type Calendar struct {
+	ProdID  string        // идентификатор программы, например "-//traindesk//schedule//RU"
+	Name    string        // название в приложении календаря (X-WR-CALNAME)
+	Refresh time.Duration // как часто обновлять подписку; 0 — на усмотрение клиента
+	Events  []Event
+}
+
+// Event — событие календаря. Время пишется в UTC, поэтому описания
+// поясов (VTIMEZONE) не нужны; событие на весь день — датой.
+type Event struct {
+	UID          string
+	Stamp        time.Time // DTSTAMP — когда событие последний раз менялось
+	Start        time.Time
+	Duration     time.Duration
+	AllDay       bool // Start — полночь даты в UTC, Duration — целые сутки
+	Summary      string
+	Description  string
+	Location     string
+	Cancelled    bool
+	LastModified time.Time
+}
+
+// Write пишет календарь в w.
+func Write(w io.Writer, cal Calendar) error {
+	bw := bufio.NewWriter(w)
+	lw := lineWriter{w: bw}
+
+	lw.line("BEGIN", "VCALENDAR")
+	lw.line("VERSION", "2.0")
+	lw.line("PRODID", cal.ProdID)
+	lw.line("CALSCALE", "GREGORIAN")
+	lw.line("METHOD", "PUBLISH")
+	if cal.Name != "" {
+		lw.line("X-WR-CALNAME", Escape(cal.Name))
+	}
+	if cal.Refresh > 0 {
+		d := FormatDuration(cal.Refresh)
+		lw.line("REFRESH-INTERVAL;VALUE=DURATION", d)
+		lw.line("X-PUBLISHED-TTL", d)
+	}
+
+	for _, e := range cal.Events {
+		lw.line("BEGIN", "VEVENT")
+		lw.line("UID", Escape(e.UID))
+		lw.line("DTSTAMP", e.Stamp.UTC().Format(utcLayout))
+		if e.AllDay {
+			lw.line("DTSTART;VALUE=DATE", e.Start.UTC().Format(dateLayout))
+		} else {
+			lw.line("DTSTART", e.Start.UTC().Format(utcLayout))
+		}
+		lw.line("DURATION", FormatDuration(e.Duration))
+		lw.line("SUMMARY", Escape(e.Summary))
+		if e.Description != "" {
+			lw.line("DESCRIPTION", Escape(e.Description))
+		}
+		if e.Location != "" {
+			lw.line("LOCATION", Escape(e.Location))
+		}
+		if e.Cancelled {
+			lw.line("STATUS", "CANCELLED")
+		} else {
+			lw.line("STATUS", "CONFIRMED")
+		}
+		if e.AllDay {
+			lw.line("TRANSP", "TRANSPARENT")
+		}
+		if !e.LastModified.IsZero() {
+			lw.line("LAST-MODIFIED", e.LastModified.UTC().Format(utcLayout))
+		}
+		lw.line("END", "VEVENT")
+	}
+
+	lw.line("END", "VCALENDAR")
+	if lw.err != nil {
+		return lw.err
+	}
+	return bw.Flush()
+}
+
+// Escape экранирует значение типа TEXT (RFC 5545, 3.3.11).
+func Escape(s string) string {
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	r := strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`, "\r", `\n`)
+	return r.Replace(s)
+}
+
+// FormatDuration записывает длительность как DURATION (RFC 5545, 3.3.6):
+// P1D, PT1H30M и т. п. Отрицательные и дробные секунды не нужны и отбрасываются.
+func FormatDuration(d time.Duration) string {
+	if d <= 0 {
+		return "PT0S"
+	}
+	secs := int64(d / time.Second)
+	days := secs / 86400
+	secs %= 86400
+	h, m, s := secs/3600, secs%3600/60, secs%60
+
+	var b strings.Builder
+	b.WriteString("P")
+	if days > 0 {
+		fmt.Fprintf(&b, "%dD", days)
+	}
+	if h > 0 || m > 0 || s > 0 {
+		b.WriteString("T")
+		if h > 0 {
+			fmt.Fprintf(&b, "%dH", h)
+		}
+		if m > 0 {
+			fmt.Fprintf(&b, "%dM", m)
+		}
+		if s > 0 {
+			fmt.Fprintf(&b, "%dS", s)
+		}
+	}
+	return b.String()
+}
+
+// lineWriter пишет строки содержимого с переносом по 75 октетов (RFC 5545, 3.1),
+// не разрывая символы UTF-8. Первая ошибка запоминается, остальное пропускается.
+type lineWriter struct {
+	w   *bufio.Writer
+	err error
+}
+
+func (lw *lineWriter) line(name, value string) {
+	if lw.err != nil {
+		return
+	}
+	s := name + ":" + value
+	limit := maxLineOctets
+	for len(s) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		if _, lw.err = lw.w.WriteString(s[:cut] + "\r\n "); lw.err != nil {
+			return
+		}
+		s = s[cut:]
+		// Продолжение начинается с пробела, он тоже считается.
+		limit = maxLineOctets - 1
+	}
+	_, lw.err = lw.w.WriteString(s + "\r\n")
+}
@@ -0,0 +1,137 @@
+package ical
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestEscape(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"plain", "plain"},
+		{`a\b`, `a\\b`},
+		{"a;b,c", `a\;b\,c`},
+		{"line1\nline2", `line1\nline2`},
+		{"line1\r\nline2", `line1\nline2`},
+		{"line1\rline2", `line1\nline2`},
+	}
+	for _, tt := range tests {
+		if got := Escape(tt.in); got != tt.want {
+			t.Errorf("Escape(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestFormatDuration(t *testing.T) {
+	tests := []struct {
+		in   time.Duration
+		want string
+	}{
+		{0, "PT0S"},
+		{-time.Hour, "PT0S"},
+		{45 * time.Minute, "PT45M"},
+		{90 * time.Minute, "PT1H30M"},
+		{24 * time.Hour, "P1D"},
+		{26*time.Hour + 5*time.Second, "P1DT2H5S"},
+		{time.Minute + 1500*time.Millisecond, "PT1M1S"},
+	}
+	for _, tt := range tests {
+		if got := FormatDuration(tt.in); got != tt.want {
+			t.Errorf("FormatDuration(%v) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestWrite(t *testing.T) {
+	start := time.Date(2025, 3, 10, 7, 30, 0, 0, time.UTC)
+	cal := Calendar{
+		ProdID:  "-//traindesk//test//RU",
+		Name:    "Расписание",
+		Refresh: time.Hour,
+		Events: []Event{
+			{
+				UID:      "w1@traindesk",
+				Stamp:    start,
+				Start:    start,
+				Duration: time.Hour,
+				Summary:  "Силовая; зал 2",
+				Location: "Зал 2",
+			},
+			{
+				UID:       "w2@traindesk",
+				Stamp:     start,
+				Start:     time.Date(2025, 3, 11, 0, 0, 0, 0, time.UTC),
+				Duration:  24 * time.Hour,
+				AllDay:    true,
+				Summary:   "Соревнования",
+				Cancelled: true,
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := Write(&buf, cal); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+
+	for _, want := range []string{
+		"BEGIN:VCALENDAR\r\n",
+		"X-WR-CALNAME:Расписание\r\n",
+		"REFRESH-INTERVAL;VALUE=DURATION:PT1H\r\n",
+		"DTSTART:20250310T073000Z\r\n",
+		"DURATION:PT1H\r\n",
+		`SUMMARY:Силовая\; зал 2` + "\r\n",
+		"STATUS:CONFIRMED\r\n",
+		"DTSTART;VALUE=DATE:20250311\r\n",
+		"STATUS:CANCELLED\r\n",
+		"TRANSP:TRANSPARENT\r\n",
+		"END:VCALENDAR\r\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output has no %q:\n%s", want, out)
+		}
+	}
+	if n := strings.Count(out, "BEGIN:VEVENT"); n != 2 {
+		t.Errorf("got %d events, want 2", n)
+	}
+}
+
+func TestWriteFoldsLongLines(t *testing.T) {
+	desc := strings.Repeat("Жим лёжа, ", 40)
+	var buf bytes.Buffer
+	err := Write(&buf, Calendar{ProdID: "-//t//t//RU", Events: []Event{{
+		UID: "w1", Start: time.Unix(0, 0), Duration: time.Hour, Description: desc,
+	}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\r\n"), "\r\n")
+	var unfolded []string
+	for _, l := range lines {
+		if len(l) > maxLineOctets {
+			t.Fatalf("line is %d octets: %q", len(l), l)
+		}
+		if !utf8.ValidString(l) {
+			t.Fatalf("line splits a UTF-8 character: %q", l)
+		}
+		if strings.HasPrefix(l, " ") {
+			unfolded[len(unfolded)-1] += l[1:]
+		} else {
+			unfolded = append(unfolded, l)
+		}
+	}
+
+	want := "DESCRIPTION:" + Escape(desc)
+	for _, l := range unfolded {
+		if l == want {
+			return
+		}
+	}
+	t.Fatalf("unfolded output has no %q", want)
+}
@@ -0,0 +1,409 @@
+package ical
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"traindesk/internal/tz"
+)
+
+// ErrNotCalendar — во входных данных нет VCALENDAR.
+var ErrNotCalendar = errors.New("not an iCalendar file: BEGIN:VCALENDAR expected")
+
+// maxEvents — предел событий в одном файле.
+const maxEvents = 50000
+
+// VEvent — событие, прочитанное из файла. Если событие разобрать не удалось,
+// заполнены Line, UID и Err.
+type VEvent struct {
+	Line int // номер строки BEGIN:VEVENT (после склейки перенесённых строк)
+
+	UID         string
+	Summary     string
+	Description string
+	Location    string
+	Status      string // CONFIRMED, TENTATIVE, CANCELLED или пусто
+
+	Start    time.Time
+	End      time.Time // нулевое — у события без даты нет ни DTEND, ни DURATION
+	AllDay   bool
+	ZoneName string // пояс IANA из TZID или календаря; пусто — время в UTC
+
+	RRule        string
+	ExDates      []time.Time
+	RecurrenceID *time.Time // изменённое повторение серии UID
+
+	Attendees []Attendee
+
+	Err error
+}
+
+// Attendee — участник события.
+type Attendee struct {
+	Name  string // CN
+	Email string
+}
+
+// property — строка содержимого: NAME;PARAM=VALUE:value.
+type property struct {
+	name   string
+	params map[string]string
+	value  string
+}
+
+// Parse читает события календаря. Время без пояса (floating) и время с
+// неизвестным TZID считается в поясе календаря (X-WR-TIMEZONE), а без него —
+// в defaultZone.
+func Parse(r io.Reader, defaultZone string) ([]VEvent, error) {
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		events    []VEvent
+		cur       *VEvent
+		depth     int // вложенные компоненты внутри VEVENT (VALARM)
+		seenCal   bool
+		calZone   = defaultZone
+		evStart   int
+		evProps   []property
+		inTZBlock int
+	)
+	for i, raw := range lines {
+		if raw == "" {
+			continue
+		}
+		p, err := parseProperty(raw)
+		if err != nil {
+			if cur != nil && cur.Err == nil {
+				cur.Err = fmt.Errorf("line %d: %w", i+1, err)
+			}
+			continue
+		}
+
+		switch {
+		case p.name == "BEGIN" && strings.EqualFold(p.value, "VCALENDAR"):
+			seenCal = true
+		case p.name == "BEGIN" && strings.EqualFold(p.value, "VTIMEZONE"):
+			inTZBlock++
+		case p.name == "END" && strings.EqualFold(p.value, "VTIMEZONE"):
+			inTZBlock--
+		case inTZBlock > 0:
+		case p.name == "BEGIN" && strings.EqualFold(p.value, "VEVENT"):
+			if len(events) == maxEvents {
+				return nil, fmt.Errorf("too many events, at most %d", maxEvents)
+			}
+			cur, depth, evStart, evProps = &VEvent{}, 0, i+1, nil
+		case cur != nil && p.name == "BEGIN":
+			depth++
+		case cur != nil && p.name == "END" && depth > 0:
+			depth--
+		case cur != nil && p.name == "END" && strings.EqualFold(p.value, "VEVENT"):
+			ev := buildEvent(evProps, calZone)
+			ev.Line = evStart
+			if cur.Err != nil && ev.Err == nil {
+				ev.Err = cur.Err
+			}
+			events = append(events, ev)
+			cur = nil
+		case cur != nil && depth == 0:
+			evProps = append(evProps, p)
+		case cur == nil && p.name == "X-WR-TIMEZONE":
+			if _, err := tz.Load(p.value); err == nil {
+				calZone = p.value
+			}
+		}
+	}
+	if !seenCal {
+		return nil, ErrNotCalendar
+	}
+	return events, nil
+}
+
+// buildEvent собирает событие из его свойств.
+func buildEvent(props []property, calZone string) VEvent {
+	var ev VEvent
+	var duration time.Duration
+	hasEnd, hasDuration := false, false
+
+	fail := func(name string, err error) {
+		if ev.Err == nil {
+			ev.Err = fmt.Errorf("%s: %w", name, err)
+		}
+	}
+
+	for _, p := range props {
+		switch p.name {
+		case "UID":
+			ev.UID = p.value
+		case "SUMMARY":
+			ev.Summary = Unescape(p.value)
+		case "DESCRIPTION":
+			ev.Description = Unescape(p.value)
+		case "LOCATION":
+			ev.Location = Unescape(p.value)
+		case "STATUS":
+			ev.Status = strings.ToUpper(p.value)
+		case "DTSTART":
+			t, allDay, zone, err := parseDateTime(p, calZone)
+			if err != nil {
+				fail("DTSTART", err)
+				continue
+			}
+			ev.Start, ev.AllDay, ev.ZoneName = t, allDay, zone
+		case "DTEND":
+			t, _, _, err := parseDateTime(p, calZone)
+			if err != nil {
+				fail("DTEND", err)
+				continue
+			}
+			ev.End, hasEnd = t, true
+		case "DURATION":
+			d, err := ParseDuration(p.value)
+			if err != nil {
+				fail("DURATION", err)
+				continue
+			}
+			duration, hasDuration = d, true
+		case "RRULE":
+			ev.RRule = p.value
+		case "EXDATE":
+			for _, v := range strings.Split(p.value, ",") {
+				t, _, _, err := parseDateTime(property{name: p.name, params: p.params, value: v}, calZone)
+				if err != nil {
+					fail("EXDATE", err)
+					continue
+				}
+				ev.ExDates = append(ev.ExDates, t)
+			}
+		case "RECURRENCE-ID":
+			t, _, _, err := parseDateTime(p, calZone)
+			if err != nil {
+				fail("RECURRENCE-ID", err)
+				continue
+			}
+			ev.RecurrenceID = &t
+		case "ATTENDEE":
+			a := Attendee{Name: strings.TrimSpace(p.params["CN"])}
+			if v := p.value; len(v) > 7 && strings.EqualFold(v[:7], "mailto:") {
+				a.Email = strings.TrimSpace(v[7:])
+			}
+			if a.Name != "" || a.Email != "" {
+				ev.Attendees = append(ev.Attendees, a)
+			}
+		}
+	}
+
+	if ev.Err != nil {
+		return ev
+	}
+	if ev.Start.IsZero() {
+		ev.Err = errors.New("DTSTART is required")
+		return ev
+	}
+	switch {
+	case hasEnd:
+	case hasDuration:
+		ev.End = ev.Start.Add(duration)
+	case ev.AllDay:
+		ev.End = ev.Start.AddDate(0, 0, 1)
+	}
+	if !ev.End.IsZero() && ev.End.Before(ev.Start) {
+		ev.Err = errors.New("DTEND is before DTSTART")
+	}
+	return ev
+}
+
+// parseDateTime разбирает DATE или DATE-TIME с учётом TZID. Дата возвращается
+// полуночью в UTC, время — моментом; zone — имя пояса, в котором оно задано.
+func parseDateTime(p property, calZone string) (t time.Time, allDay bool, zone string, err error) {
+	v := strings.TrimSpace(p.value)
+	if strings.EqualFold(p.params["VALUE"], "DATE") || len(v) == len(dateLayout) {
+		t, err = time.Parse(dateLayout, v)
+		return t, true, "", err
+	}
+	if strings.HasSuffix(v, "Z") {
+		t, err = time.Parse(utcLayout, v)
+		return t, false, "", err
+	}
+
+	wall, err := time.Parse("20060102T150405", v)
+	if err != nil {
+		return time.Time{}, false, "", err
+	}
+	zone = calZone
+	if id := strings.TrimPrefix(p.params["TZID"], "/"); id != "" {
+		if _, err := tz.Load(id); err == nil {
+			zone = id
+		}
+	}
+	if zone == "" {
+		return wall, false, "", nil
+	}
+	loc, err := tz.Load(zone)
+	if err != nil {
+		return time.Time{}, false, "", err
+	}
+	return tz.ResolveLenient(wall, loc), false, zone, nil
+}
+
+// ParseDuration разбирает значение DURATION (RFC 5545, 3.3.6).
+func ParseDuration(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	neg := false
+	switch {
+	case strings.HasPrefix(s, "-"):
+		neg, s = true, s[1:]
+	case strings.HasPrefix(s, "+"):
+		s = s[1:]
+	}
+	if !strings.HasPrefix(s, "P") || len(s) < 3 {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+
+	var d time.Duration
+	inTime := false
+	num := ""
+	for _, r := range s[1:] {
+		switch {
+		case r >= '0' && r <= '9':
+			num += string(r)
+			continue
+		case r == 'T':
+			inTime = true
+			continue
+		}
+		n, err := strconv.Atoi(num)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+		num = ""
+		switch {
+		case r == 'W' && !inTime:
+			d += time.Duration(n) * 7 * 24 * time.Hour
+		case r == 'D' && !inTime:
+			d += time.Duration(n) * 24 * time.Hour
+		case r == 'H' && inTime:
+			d += time.Duration(n) * time.Hour
+		case r == 'M' && inTime:
+			d += time.Duration(n) * time.Minute
+		case r == 'S' && inTime:
+			d += time.Duration(n) * time.Second
+		default:
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+	}
+	if num != "" {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+	if neg {
+		d = -d
+	}
+	return d, nil
+}
+
+// Unescape снимает экранирование значения типа TEXT.
+func Unescape(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 == len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch s[i] {
+		case 'n', 'N':
+			b.WriteByte('\n')
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String()
+}
+
+// unfold читает строки и склеивает перенесённые (начинающиеся с пробела или табуляции).
+func unfold(r io.Reader) ([]string, error) {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	var lines []string
+	first := true
+	for sc.Scan() {
+		line := strings.TrimRight(sc.Text(), "\r")
+		if first {
+			line = strings.TrimPrefix(line, "\ufeff")
+			first = false
+		}
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines, sc.Err()
+}
+
+// parseProperty разбирает строку содержимого. Значения параметров могут быть
+// в кавычках и содержать «:» и «;».
+func parseProperty(line string) (property, error) {
+	p := property{params: map[string]string{}}
+	inQuotes := false
+	colon := -1
+	for i := 0; i < len(line); i++ {
+		switch line[i] {
+		case '"':
+			inQuotes = !inQuotes
+		case ':':
+			if !inQuotes {
+				colon = i
+			}
+		}
+		if colon >= 0 {
+			break
+		}
+	}
+	if colon < 0 {
+		return p, fmt.Errorf("malformed line %q", line)
+	}
+
+	head := line[:colon]
+	p.value = line[colon+1:]
+
+	parts := splitParams(head)
+	p.name = strings.ToUpper(parts[0])
+	for _, param := range parts[1:] {
+		k, v, ok := strings.Cut(param, "=")
+		if !ok {
+			continue
+		}
+		p.params[strings.ToUpper(k)] = strings.Trim(v, `"`)
+	}
+	return p, nil
+}
+
+// splitParams делит имя свойства и параметры по «;» вне кавычек.
+func splitParams(s string) []string {
+	var parts []string
+	inQuotes := false
+	start := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '"':
+			inQuotes = !inQuotes
+		case ';':
+			if !inQuotes {
+				parts = append(parts, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, s[start:])
+}
@@ -0,0 +1,285 @@
+package ical
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func parseString(t *testing.T, s, zone string) []VEvent {
+	t.Helper()
+	events, err := Parse(strings.NewReader(strings.ReplaceAll(s, "\n", "\r\n")), zone)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	return events
+}
+
+func TestParseEvent(t *testing.T) {
+	events := parseString(t, `BEGIN:VCALENDAR
+VERSION:2.0
+X-WR-TIMEZONE:Europe/Moscow
+BEGIN:VTIMEZONE
+TZID:Europe/Berlin
+BEGIN:STANDARD
+DTSTART:19701025T030000
+END:STANDARD
+END:VTIMEZONE
+BEGIN:VEVENT
+UID:e1
+SUMMARY:Силовая\, верх
+DESCRIPTION:Жим\nТяга
+LOCATION:Зал 1
+DTSTART;TZID=Europe/Berlin:20250310T100000
+DTEND;TZID=Europe/Berlin:20250310T113000
+ATTENDEE;CN=Иван Петров:mailto:ivan@example.com
+ATTENDEE;CN=Мария Сидорова:mailto:
+BEGIN:VALARM
+ACTION:DISPLAY
+DTSTART:20000101T000000Z
+END:VALARM
+STATUS:confirmed
+END:VEVENT
+BEGIN:VEVENT
+UID:e2
+DTSTART:20250311T090000
+DURATION:PT45M
+END:VEVENT
+END:VCALENDAR
+`, "UTC")
+
+	if len(events) != 2 {
+		t.Fatalf("got %d events, want 2", len(events))
+	}
+
+	e := events[0]
+	if e.Err != nil {
+		t.Fatal(e.Err)
+	}
+	if e.UID != "e1" || e.Summary != "Силовая, верх" || e.Description != "Жим\nТяга" || e.Location != "Зал 1" {
+		t.Errorf("text fields: %+v", e)
+	}
+	if e.Status != "CONFIRMED" {
+		t.Errorf("Status = %q", e.Status)
+	}
+	if e.ZoneName != "Europe/Berlin" || !e.Start.Equal(time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)) ||
+		!e.End.Equal(time.Date(2025, 3, 10, 10, 30, 0, 0, time.UTC)) {
+		t.Errorf("time: %v – %v in %q", e.Start.UTC(), e.End.UTC(), e.ZoneName)
+	}
+	if len(e.Attendees) != 2 || e.Attendees[0] != (Attendee{Name: "Иван Петров", Email: "ivan@example.com"}) ||
+		e.Attendees[1] != (Attendee{Name: "Мария Сидорова"}) {
+		t.Errorf("Attendees = %+v", e.Attendees)
+	}
+	if e.Line != 10 {
+		t.Errorf("Line = %d, want 10", e.Line)
+	}
+
+	// Время без пояса — в поясе календаря (X-WR-TIMEZONE).
+	e = events[1]
+	if e.Err != nil {
+		t.Fatal(e.Err)
+	}
+	if e.ZoneName != "Europe/Moscow" || !e.Start.Equal(time.Date(2025, 3, 11, 6, 0, 0, 0, time.UTC)) ||
+		e.End.Sub(e.Start) != 45*time.Minute {
+		t.Errorf("time: %v – %v in %q", e.Start.UTC(), e.End.UTC(), e.ZoneName)
+	}
+}
+
+func TestParseEnd(t *testing.T) {
+	tests := []struct {
+		name    string
+		props   string
+		wantEnd time.Time
+		allDay  bool
+		wantErr bool
+	}{
+		{
+			name:    "explicit end",
+			props:   "DTSTART:20250310T100000Z\nDTEND:20250310T110000Z",
+			wantEnd: time.Date(2025, 3, 10, 11, 0, 0, 0, time.UTC),
+		},
+		{
+			name:    "duration",
+			props:   "DTSTART:20250310T100000Z\nDURATION:PT1H30M",
+			wantEnd: time.Date(2025, 3, 10, 11, 30, 0, 0, time.UTC),
+		},
+		{
+			name:  "no end",
+			props: "DTSTART:20250310T100000Z",
+		},
+		{
+			name:    "all day without end lasts one day",
+			props:   "DTSTART;VALUE=DATE:20250310",
+			wantEnd: time.Date(2025, 3, 11, 0, 0, 0, 0, time.UTC),
+			allDay:  true,
+		},
+		{
+			name:    "end before start",
+			props:   "DTSTART:20250310T100000Z\nDTEND:20250310T090000Z",
+			wantErr: true,
+		},
+		{
+			name:    "no start",
+			props:   "SUMMARY:x",
+			wantErr: true,
+		},
+		{
+			name:    "bad duration",
+			props:   "DTSTART:20250310T100000Z\nDURATION:1H",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events := parseString(t, "BEGIN:VCALENDAR\nBEGIN:VEVENT\nUID:x\n"+tt.props+"\nEND:VEVENT\nEND:VCALENDAR\n", "UTC")
+			if len(events) != 1 {
+				t.Fatalf("got %d events", len(events))
+			}
+			e := events[0]
+			if tt.wantErr {
+				if e.Err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if e.Err != nil {
+				t.Fatal(e.Err)
+			}
+			if !e.End.Equal(tt.wantEnd) || e.AllDay != tt.allDay {
+				t.Fatalf("End = %v, AllDay = %v; want %v, %v", e.End, e.AllDay, tt.wantEnd, tt.allDay)
+			}
+		})
+	}
+}
+
+func TestParseRecurrence(t *testing.T) {
+	events := parseString(t, `BEGIN:VCALENDAR
+BEGIN:VEVENT
+UID:s1
+DTSTART;TZID=Europe/Berlin:20250303T180000
+DTEND;TZID=Europe/Berlin:20250303T190000
+RRULE:FREQ=WEEKLY;BYDAY=MO
+EXDATE;TZID=Europe/Berlin:20250310T180000,20250317T180000
+END:VEVENT
+BEGIN:VEVENT
+UID:s1
+RECURRENCE-ID;TZID=Europe/Berlin:20250324T180000
+DTSTART;TZID=Europe/Berlin:20250325T180000
+DTEND;TZID=Europe/Berlin:20250325T190000
+END:VEVENT
+END:VCALENDAR
+`, "UTC")
+
+	if len(events) != 2 || events[0].Err != nil || events[1].Err != nil {
+		t.Fatalf("events: %+v", events)
+	}
+	if events[0].RRule != "FREQ=WEEKLY;BYDAY=MO" {
+		t.Errorf("RRule = %q", events[0].RRule)
+	}
+	wantEx := []time.Time{
+		time.Date(2025, 3, 10, 17, 0, 0, 0, time.UTC),
+		time.Date(2025, 3, 17, 17, 0, 0, 0, time.UTC),
+	}
+	if len(events[0].ExDates) != 2 || !events[0].ExDates[0].Equal(wantEx[0]) || !events[0].ExDates[1].Equal(wantEx[1]) {
+		t.Errorf("ExDates = %v", events[0].ExDates)
+	}
+	if rid := events[1].RecurrenceID; rid == nil || !rid.Equal(time.Date(2025, 3, 24, 17, 0, 0, 0, time.UTC)) {
+		t.Errorf("RecurrenceID = %v", rid)
+	}
+}
+
+func TestParseUnfoldsLines(t *testing.T) {
+	events := parseString(t, "BEGIN:VCALENDAR\nBEGIN:VEVENT\nUID:x\nDTSTART:20250310T100000Z\n"+
+		"SUMMARY:Очень длинное\n  название\n\tтренировки\nEND:VEVENT\nEND:VCALENDAR\n", "UTC")
+	if len(events) != 1 || events[0].Summary != "Очень длинное названиетренировки" {
+		t.Fatalf("Summary = %q", events[0].Summary)
+	}
+}
+
+func TestParseNotCalendar(t *testing.T) {
+	_, err := Parse(strings.NewReader("date,start\n2025-01-01,10:00\n"), "UTC")
+	if !errors.Is(err, ErrNotCalendar) {
+		t.Fatalf("err = %v, want ErrNotCalendar", err)
+	}
+}
+
+func TestWriteParseRoundTrip(t *testing.T) {
+	start := time.Date(2025, 3, 10, 7, 30, 0, 0, time.UTC)
+	in := Event{
+		UID:         "w1@traindesk",
+		Stamp:       start,
+		Start:       start,
+		Duration:    90 * time.Minute,
+		Summary:     "Силовая; верх, низ",
+		Description: strings.Repeat("Присед\\жим\n", 20),
+		Location:    "Зал 2",
+		Cancelled:   true,
+	}
+	var buf bytes.Buffer
+	if err := Write(&buf, Calendar{ProdID: "-//t//t//RU", Events: []Event{in}}); err != nil {
+		t.Fatal(err)
+	}
+
+	events, err := Parse(&buf, "UTC")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 || events[0].Err != nil {
+		t.Fatalf("events: %+v", events)
+	}
+	e := events[0]
+	if e.UID != in.UID || e.Summary != in.Summary || e.Description != in.Description || e.Location != in.Location ||
+		e.Status != "CANCELLED" || !e.Start.Equal(in.Start) || e.End.Sub(e.Start) != in.Duration {
+		t.Fatalf("round trip changed the event: %+v", e)
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"PT45M", 45 * time.Minute, false},
+		{"PT1H30M", 90 * time.Minute, false},
+		{"P1D", 24 * time.Hour, false},
+		{"P1W", 7 * 24 * time.Hour, false},
+		{"P1DT2H3M4S", 26*time.Hour + 3*time.Minute + 4*time.Second, false},
+		{"+PT5M", 5 * time.Minute, false},
+		{"-PT15M", -15 * time.Minute, false},
+		{"PT", 0, true},
+		{"1H", 0, true},
+		{"PT1D", 0, true},
+		{"P1H", 0, true},
+		{"PT10", 0, true},
+		{"PTM", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseDuration(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseDuration(%q) = %v, %v; want %v, error %v", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestUnescape(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"plain", "plain"},
+		{`a\,b\;c`, "a,b;c"},
+		{`a\nb\Nc`, "a\nb\nc"},
+		{`a\\n`, `a\n`},
+		{`trailing\`, `trailing\`},
+	}
+	for _, tt := range tests {
+		if got := Unescape(tt.in); got != tt.want {
+			t.Errorf("Unescape(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+		if tt.in != `trailing\` && Unescape(Escape(tt.want)) != tt.want {
+			t.Errorf("Escape/Unescape changed %q", tt.want)
+		}
+	}
+}
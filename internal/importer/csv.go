@@ -0,0 +1,298 @@
+package importer
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	dateLayouts     = []string{"2006-01-02", "2.1.2006", "2006/01/02"}
+	timeLayouts     = []string{"15:04", "15:04:05"}
+	dateTimeLayouts = []string{
+		"2006-01-02T15:04:05", "2006-01-02T15:04", "2006-01-02 15:04:05", "2006-01-02 15:04",
+		"2.1.2006 15:04:05", "2.1.2006 15:04",
+	}
+)
+
+// headerAliases — названия колонок, которые узнаются без явного сопоставления.
+var headerAliases = map[string][]string{
+	"date":      {"date", "дата", "day"},
+	"start":     {"start", "starts_at", "start time", "time", "начало", "время", "время начала"},
+	"end":       {"end", "ends_at", "end time", "конец", "окончание", "время окончания"},
+	"duration":  {"duration", "duration_min", "minutes", "длительность", "минуты"},
+	"type":      {"type", "workout type", "тип", "вид", "вид тренировки"},
+	"clients":   {"clients", "client", "attendees", "participants", "клиенты", "клиент", "участники"},
+	"notes":     {"notes", "note", "description", "comment", "заметки", "описание", "комментарий"},
+	"room":      {"room", "location", "зал", "место"},
+	"time_zone": {"time_zone", "timezone", "tz", "часовой пояс", "пояс"},
+}
+
+// DetectMapping сопоставляет колонки по их заголовкам.
+func DetectMapping(header []string) Mapping {
+	find := func(field string) string {
+		for _, h := range header {
+			norm := strings.ToLower(strings.TrimSpace(h))
+			for _, alias := range headerAliases[field] {
+				if norm == alias {
+					return h
+				}
+			}
+		}
+		return ""
+	}
+	return Mapping{
+		Date:     find("date"),
+		Start:    find("start"),
+		End:      find("end"),
+		Duration: find("duration"),
+		Type:     find("type"),
+		Clients:  find("clients"),
+		Notes:    find("notes"),
+		Room:     find("room"),
+		TimeZone: find("time_zone"),
+	}
+}
+
+// columns — номера колонок по сопоставлению; -1 — колонки нет.
+type columns struct {
+	date, start, end, duration, typ, clients, notes, room, timeZone int
+}
+
+func (m Mapping) columns(header []string) (columns, error) {
+	index := make(map[string]int, len(header))
+	for i, h := range header {
+		index[strings.TrimSpace(h)] = i
+	}
+	var errs []string
+	col := func(field, name string) int {
+		if name == "" {
+			return -1
+		}
+		i, ok := index[strings.TrimSpace(name)]
+		if !ok {
+			errs = append(errs, fmt.Sprintf("%s: no column %q", field, name))
+			return -1
+		}
+		return i
+	}
+	c := columns{
+		date:     col("date", m.Date),
+		start:    col("start", m.Start),
+		end:      col("end", m.End),
+		duration: col("duration", m.Duration),
+		typ:      col("type", m.Type),
+		clients:  col("clients", m.Clients),
+		notes:    col("notes", m.Notes),
+		room:     col("room", m.Room),
+		timeZone: col("time_zone", m.TimeZone),
+	}
+	if len(errs) > 0 {
+		return c, errors.New("mapping: " + strings.Join(errs, "; "))
+	}
+	if c.date < 0 && c.start < 0 {
+		return c, errors.New("mapping: date or start column is required")
+	}
+	return c, nil
+}
+
+// ParseCSV читает тренировки из CSV с заголовком. Разделитель (запятая, точка
+// с запятой или табуляция) определяется по первой строке. Если mapping nil,
+// колонки сопоставляются по заголовкам; применённое сопоставление возвращается.
+// Время без смещения считается в поясе из колонки time_zone, а без неё — в zone.
+func ParseCSV(data []byte, mapping *Mapping, zone string) ([]Item, Mapping, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+
+	r := csv.NewReader(bytes.NewReader(data))
+	r.Comma = detectDelimiter(data)
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+	r.TrimLeadingSpace = true
+
+	header, err := r.Read()
+	if err != nil {
+		return nil, Mapping{}, errors.New("csv: header row is required")
+	}
+	m := DetectMapping(header)
+	if mapping != nil {
+		m = *mapping
+	}
+	cols, err := m.columns(header)
+	if err != nil {
+		return nil, m, err
+	}
+
+	var items []Item
+	for {
+		rec, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			var pe *csv.ParseError
+			if errors.As(err, &pe) {
+				items = append(items, Item{Row: pe.Line, Err: pe.Err})
+				continue
+			}
+			return nil, m, err
+		}
+		line, _ := r.FieldPos(0)
+		if isBlank(rec) {
+			continue
+		}
+		if len(items) == maxItems {
+			return nil, m, fmt.Errorf("too many rows, at most %d", maxItems)
+		}
+		items = append(items, parseRecord(rec, cols, zone, line))
+	}
+	return items, m, nil
+}
+
+// parseRecord превращает строку CSV в тренировку.
+func parseRecord(rec []string, cols columns, zone string, line int) Item {
+	get := func(i int) string {
+		if i < 0 || i >= len(rec) {
+			return ""
+		}
+		return strings.TrimSpace(rec[i])
+	}
+
+	it := Item{Row: line}
+	req := &it.Req
+	req.Type = strings.ToLower(get(cols.typ))
+	req.Notes = get(cols.notes)
+	req.Room = get(cols.room)
+	req.TimeZone = zone
+	if v := get(cols.timeZone); v != "" {
+		req.TimeZone = v
+	}
+
+	date, start, end := get(cols.date), get(cols.start), get(cols.end)
+	var day time.Time
+	if date != "" {
+		d, err := parseFirst(dateLayouts, date)
+		if err != nil {
+			it.Err = fmt.Errorf("invalid date %q", date)
+			return it
+		}
+		day = d
+	}
+
+	switch {
+	case start == "":
+		if day.IsZero() {
+			it.Err = errors.New("date or start is required")
+			return it
+		}
+		req.Date = day.Format("2006-01-02")
+	default:
+		s, err := combine(day, start)
+		if err != nil {
+			it.Err = fmt.Errorf("invalid start %q", start)
+			return it
+		}
+		req.StartsAt = s.Format("2006-01-02T15:04:05")
+		if end != "" {
+			if day.IsZero() {
+				day = s
+			}
+			e, err := combine(day, end)
+			if err != nil {
+				it.Err = fmt.Errorf("invalid end %q", end)
+				return it
+			}
+			// Конец раньше начала — тренировка заканчивается после полуночи.
+			if !e.After(s) && !hasDate(end) {
+				e = e.AddDate(0, 0, 1)
+			}
+			req.EndsAt = e.Format("2006-01-02T15:04:05")
+		}
+	}
+
+	if v := get(cols.duration); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			it.Err = fmt.Errorf("invalid duration %q", v)
+			return it
+		}
+		req.DurationMin = n
+	}
+
+	for _, name := range splitList(get(cols.clients)) {
+		if strings.Contains(name, "@") {
+			it.Attendees = append(it.Attendees, Attendee{Email: name})
+		} else {
+			it.Attendees = append(it.Attendees, Attendee{Name: name})
+		}
+	}
+	return it
+}
+
+// combine — время v в день day; если v уже с датой, day не нужен.
+func combine(day time.Time, v string) (time.Time, error) {
+	if hasDate(v) {
+		return parseFirst(dateTimeLayouts, v)
+	}
+	if day.IsZero() {
+		return time.Time{}, errors.New("date is required")
+	}
+	t, err := parseFirst(timeLayouts, v)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Date(day.Year(), day.Month(), day.Day(), t.Hour(), t.Minute(), t.Second(), 0, time.UTC), nil
+}
+
+func hasDate(v string) bool {
+	return strings.ContainsAny(v, "-./") || len(v) > len("15:04:05")
+}
+
+func parseFirst(layouts []string, v string) (time.Time, error) {
+	for _, l := range layouts {
+		if t, err := time.Parse(l, v); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognized value %q", v)
+}
+
+// splitList делит список участников по «;», «,» или «|».
+func splitList(s string) []string {
+	parts := strings.FieldsFunc(s, func(r rune) bool { return r == ';' || r == ',' || r == '|' || r == '\n' })
+	out := parts[:0]
+	for _, p := range parts {
+		if p = strings.TrimSpace(p); p != "" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// detectDelimiter выбирает самый частый разделитель в первой строке.
+func detectDelimiter(data []byte) rune {
+	first := data
+	if i := bytes.IndexByte(data, '\n'); i >= 0 {
+		first = data[:i]
+	}
+	best, bestCount := ',', bytes.Count(first, []byte(","))
+	for _, d := range []rune{';', '\t'} {
+		if n := bytes.Count(first, []byte(string(d))); n > bestCount {
+			best, bestCount = d, n
+		}
+	}
+	return best
+}
+
+func isBlank(rec []string) bool {
+	for _, v := range rec {
+		if strings.TrimSpace(v) != "" {
+			return false
+		}
+	}
+	return true
+}
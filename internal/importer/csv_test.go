@@ -0,0 +1,145 @@
+package importer
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseCSV(t *testing.T) {
+	data := "\xef\xbb\xbfДата;Начало;Конец;Вид;Клиенты;Зал;Заметки\n" +
+		"10.03.2025;10:00;11:30;Силовая;Иван Петров, maria@example.com;Зал 1;верх\n" +
+		";;;;;;\n" +
+		"2025-03-11;23:30;00:30;кардио;;;\n" +
+		"2025-03-12;;;кардио;;;\n" +
+		"32.03.2025;10:00;;;;;\n"
+
+	items, m, err := ParseCSV([]byte(data), nil, "Europe/Moscow")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Date != "Дата" || m.Start != "Начало" || m.End != "Конец" || m.Type != "Вид" ||
+		m.Clients != "Клиенты" || m.Room != "Зал" || m.Notes != "Заметки" || m.Duration != "" {
+		t.Errorf("detected mapping = %+v", m)
+	}
+	if len(items) != 4 {
+		t.Fatalf("got %d items, want 4 (blank row skipped)", len(items))
+	}
+
+	it := items[0]
+	r := it.Req
+	if it.Err != nil || it.Row != 2 {
+		t.Fatalf("row %d: %v", it.Row, it.Err)
+	}
+	if r.StartsAt != "2025-03-10T10:00:00" || r.EndsAt != "2025-03-10T11:30:00" || r.TimeZone != "Europe/Moscow" {
+		t.Errorf("time: %q – %q in %q", r.StartsAt, r.EndsAt, r.TimeZone)
+	}
+	if r.Type != "силовая" || r.Room != "Зал 1" || r.Notes != "верх" {
+		t.Errorf("Req = %+v", r)
+	}
+	if len(it.Attendees) != 2 || it.Attendees[0] != (Attendee{Name: "Иван Петров"}) ||
+		it.Attendees[1] != (Attendee{Email: "maria@example.com"}) {
+		t.Errorf("Attendees = %+v", it.Attendees)
+	}
+
+	if r := items[1].Req; r.EndsAt != "2025-03-12T00:30:00" {
+		t.Errorf("end after midnight: %q – %q", r.StartsAt, r.EndsAt)
+	}
+	if r := items[2].Req; r.Date != "2025-03-12" || r.StartsAt != "" {
+		t.Errorf("date-only row: %+v", r)
+	}
+	if it := items[3]; it.Err == nil || it.Row != 6 {
+		t.Errorf("invalid date: row %d, err %v", it.Row, it.Err)
+	}
+}
+
+func TestParseCSVRecords(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    func(it Item) bool
+		wantErr bool
+	}{
+		{
+			name: "comma delimiter with date and time in start",
+			data: "start,end,type\n2025-03-10 10:00,2025-03-10 11:00,cardio\n",
+			want: func(it Item) bool {
+				return it.Req.StartsAt == "2025-03-10T10:00:00" && it.Req.EndsAt == "2025-03-10T11:00:00"
+			},
+		},
+		{
+			name: "tab delimiter and duration",
+			data: "date\ttime\tduration\n2025-03-10\t10:00\t45\n",
+			want: func(it Item) bool {
+				return it.Req.StartsAt == "2025-03-10T10:00:00" && it.Req.DurationMin == 45
+			},
+		},
+		{
+			name: "time zone column",
+			data: "date,start,tz\n2025-03-10,10:00,Europe/Berlin\n",
+			want: func(it Item) bool { return it.Req.TimeZone == "Europe/Berlin" },
+		},
+		{
+			name:    "invalid duration",
+			data:    "date,start,duration\n2025-03-10,10:00,час\n",
+			wantErr: true,
+		},
+		{
+			name:    "invalid start",
+			data:    "date,start\n2025-03-10,25:00\n",
+			wantErr: true,
+		},
+		{
+			name:    "time without date",
+			data:    "date,start\n,10:00\n",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			items, _, err := ParseCSV([]byte(tt.data), nil, "UTC")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(items) != 1 {
+				t.Fatalf("got %d items", len(items))
+			}
+			it := items[0]
+			if tt.wantErr {
+				if it.Err == nil {
+					t.Fatalf("expected a row error, got %+v", it.Req)
+				}
+				return
+			}
+			if it.Err != nil || !tt.want(it) {
+				t.Fatalf("unexpected item: %+v, %v", it.Req, it.Err)
+			}
+		})
+	}
+}
+
+func TestParseCSVMapping(t *testing.T) {
+	data := []byte("Когда;Кто\n10.03.2025;Иван\n")
+
+	if _, _, err := ParseCSV(data, nil, "UTC"); err == nil {
+		t.Fatal("file without date or start column accepted")
+	}
+
+	items, m, err := ParseCSV(data, &Mapping{Date: "Когда", Clients: "Кто"}, "UTC")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Date != "Когда" || len(items) != 1 || items[0].Req.Date != "2025-03-10" || len(items[0].Attendees) != 1 {
+		t.Fatalf("explicit mapping: %+v, %+v", m, items)
+	}
+
+	_, _, err = ParseCSV(data, &Mapping{Date: "Когда", Room: "Зал"}, "UTC")
+	if err == nil || !strings.Contains(err.Error(), `no column "Зал"`) {
+		t.Fatalf("missing mapped column: %v", err)
+	}
+}
+
+func TestParseCSVNoHeader(t *testing.T) {
+	if _, _, err := ParseCSV(nil, nil, "UTC"); err == nil {
+		t.Fatal("empty file accepted")
+	}
+}
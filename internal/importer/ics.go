@@ -0,0 +1,126 @@
+package importer
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"traindesk/internal/ical"
+	"traindesk/internal/recurrence"
+	"traindesk/internal/tz"
+)
+
+// FromEvents превращает события календаря в тренировки. Повторяющиеся
+// события разворачиваются в отдельные тренировки до until; изменённые
+// повторения (RECURRENCE-ID) заменяют исходные. Время без пояса — в zone.
+func FromEvents(events []ical.VEvent, zone string, until time.Time) ([]Item, error) {
+	// Изменённые повторения: их исходное время не берём из правила серии.
+	overridden := make(map[string]map[int64]bool)
+	for _, ev := range events {
+		if ev.Err == nil && ev.RecurrenceID != nil && ev.UID != "" {
+			if overridden[ev.UID] == nil {
+				overridden[ev.UID] = make(map[int64]bool)
+			}
+			overridden[ev.UID][ev.RecurrenceID.Unix()] = true
+		}
+	}
+
+	var items []Item
+	add := func(it Item) error {
+		if len(items) == maxItems {
+			return fmt.Errorf("too many events, at most %d", maxItems)
+		}
+		items = append(items, it)
+		return nil
+	}
+
+	for _, ev := range events {
+		if ev.Err != nil {
+			if err := add(Item{Row: ev.Line, Summary: ev.Summary, Err: ev.Err}); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		starts := []time.Time{ev.Start}
+		if ev.RRule != "" && ev.RecurrenceID == nil {
+			var err error
+			if starts, err = expand(ev, zone, until, overridden[ev.UID]); err != nil {
+				if err := add(Item{Row: ev.Line, Summary: ev.Summary, Err: err}); err != nil {
+					return nil, err
+				}
+				continue
+			}
+		}
+
+		for _, start := range starts {
+			if err := add(eventItem(ev, start, zone)); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return items, nil
+}
+
+// expand — начала повторений события по RRULE и EXDATE. Отдельного предела
+// на серию нет: число тренировок в файле и так ограничено maxItems.
+func expand(ev ical.VEvent, zone string, until time.Time, overridden map[int64]bool) ([]time.Time, error) {
+	rule, err := recurrence.Parse(ev.RRule)
+	if err != nil {
+		return nil, fmt.Errorf("RRULE: %w", err)
+	}
+	loc := time.UTC
+	if !ev.AllDay {
+		name := ev.ZoneName
+		if name == "" {
+			name = zone
+		}
+		loc = tz.LoadOrUTC(name)
+	}
+
+	set := recurrence.Set{Rule: rule, Start: ev.Start, Location: loc, ExDates: ev.ExDates}
+	var starts []time.Time
+	for _, t := range set.Between(ev.Start, until) {
+		if overridden[t.Unix()] {
+			continue
+		}
+		starts = append(starts, t)
+	}
+	return starts, nil
+}
+
+// eventItem — тренировка для события (или его повторения) с началом start.
+func eventItem(ev ical.VEvent, start time.Time, zone string) Item {
+	it := Item{Row: ev.Line, Summary: ev.Summary}
+	if ev.Status == "CANCELLED" {
+		it.Skip = "event is cancelled"
+	}
+
+	req := &it.Req
+	req.Room = ev.Location
+	req.Notes = strings.TrimSpace(ev.Description)
+	if ev.Summary != "" {
+		req.Notes = strings.TrimSpace(ev.Summary + "\n\n" + req.Notes)
+	}
+
+	if ev.AllDay {
+		req.AllDay = true
+		req.Date = start.UTC().Format("2006-01-02")
+	} else {
+		req.TimeZone = ev.ZoneName
+		if req.TimeZone == "" {
+			req.TimeZone = zone
+		}
+		loc := tz.LoadOrUTC(req.TimeZone)
+		req.StartsAt = start.In(loc).Format(time.RFC3339)
+		// Без конца в событии длительность возьмётся из вида тренировки.
+		if !ev.End.IsZero() {
+			req.EndsAt = start.Add(ev.End.Sub(ev.Start)).In(loc).Format(time.RFC3339)
+		}
+	}
+
+	for _, a := range ev.Attendees {
+		it.Attendees = append(it.Attendees, Attendee{Name: a.Name, Email: a.Email})
+	}
+	return it
+}
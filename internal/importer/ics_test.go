@@ -0,0 +1,167 @@
+package importer
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"traindesk/internal/ical"
+)
+
+func TestFromEvents(t *testing.T) {
+	start := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC) // 10:00 в Берлине
+	until := time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		ev    ical.VEvent
+		check func(t *testing.T, it Item)
+	}{
+		{
+			name: "timed event in its zone",
+			ev: ical.VEvent{
+				Summary: "Силовая", Description: "верх", Location: "Зал 1",
+				Start: start, End: start.Add(90 * time.Minute), ZoneName: "Europe/Berlin",
+				Attendees: []ical.Attendee{{Name: "Иван Петров", Email: "ivan@example.com"}},
+			},
+			check: func(t *testing.T, it Item) {
+				r := it.Req
+				if r.StartsAt != "2025-03-10T10:00:00+01:00" || r.EndsAt != "2025-03-10T11:30:00+01:00" || r.TimeZone != "Europe/Berlin" {
+					t.Errorf("time: %q – %q in %q", r.StartsAt, r.EndsAt, r.TimeZone)
+				}
+				if r.Notes != "Силовая\n\nверх" || r.Room != "Зал 1" {
+					t.Errorf("Notes = %q, Room = %q", r.Notes, r.Room)
+				}
+				if len(it.Attendees) != 1 || it.Attendees[0] != (Attendee{Name: "Иван Петров", Email: "ivan@example.com"}) {
+					t.Errorf("Attendees = %+v", it.Attendees)
+				}
+			},
+		},
+		{
+			name: "floating time uses the import zone",
+			ev:   ical.VEvent{Start: start, End: start.Add(time.Hour)},
+			check: func(t *testing.T, it Item) {
+				if it.Req.TimeZone != "Europe/Moscow" || it.Req.StartsAt != "2025-03-10T12:00:00+03:00" {
+					t.Errorf("StartsAt = %q in %q", it.Req.StartsAt, it.Req.TimeZone)
+				}
+			},
+		},
+		{
+			name: "no end leaves duration to the workout type",
+			ev:   ical.VEvent{Start: start, ZoneName: "UTC"},
+			check: func(t *testing.T, it Item) {
+				if it.Req.StartsAt == "" || it.Req.EndsAt != "" {
+					t.Errorf("StartsAt = %q, EndsAt = %q", it.Req.StartsAt, it.Req.EndsAt)
+				}
+			},
+		},
+		{
+			name: "all day",
+			ev: ical.VEvent{
+				Start: time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC),
+				End:   time.Date(2025, 3, 11, 0, 0, 0, 0, time.UTC), AllDay: true,
+			},
+			check: func(t *testing.T, it Item) {
+				if !it.Req.AllDay || it.Req.Date != "2025-03-10" || it.Req.StartsAt != "" {
+					t.Errorf("Req = %+v", it.Req)
+				}
+			},
+		},
+		{
+			name: "cancelled is skipped",
+			ev:   ical.VEvent{Start: start, End: start.Add(time.Hour), Status: "CANCELLED"},
+			check: func(t *testing.T, it Item) {
+				if it.Skip == "" {
+					t.Error("cancelled event is not skipped")
+				}
+			},
+		},
+		{
+			name: "parse error is kept",
+			ev:   ical.VEvent{Line: 7, Summary: "x", Err: errors.New("DTSTART is required")},
+			check: func(t *testing.T, it Item) {
+				if it.Row != 7 || it.Err == nil {
+					t.Errorf("Row = %d, Err = %v", it.Row, it.Err)
+				}
+			},
+		},
+		{
+			name: "bad RRULE fails the event",
+			ev:   ical.VEvent{Start: start, End: start.Add(time.Hour), RRule: "FREQ=YEARLY"},
+			check: func(t *testing.T, it Item) {
+				if it.Err == nil || !strings.Contains(it.Err.Error(), "RRULE") {
+					t.Errorf("Err = %v", it.Err)
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			items, err := FromEvents([]ical.VEvent{tt.ev}, "Europe/Moscow", until)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(items) != 1 {
+				t.Fatalf("got %d items, want 1", len(items))
+			}
+			tt.check(t, items[0])
+		})
+	}
+}
+
+func TestFromEventsSeries(t *testing.T) {
+	start := time.Date(2025, 3, 3, 17, 0, 0, 0, time.UTC)
+	moved := time.Date(2025, 3, 24, 17, 0, 0, 0, time.UTC)
+	events := []ical.VEvent{
+		{
+			UID: "s1", Start: start, End: start.Add(time.Hour), ZoneName: "UTC",
+			RRule:   "FREQ=WEEKLY;BYDAY=MO",
+			ExDates: []time.Time{time.Date(2025, 3, 10, 17, 0, 0, 0, time.UTC)},
+		},
+		{
+			UID: "s1", Start: moved.AddDate(0, 0, 1), End: moved.AddDate(0, 0, 1).Add(time.Hour), ZoneName: "UTC",
+			RecurrenceID: &moved,
+		},
+	}
+
+	items, err := FromEvents(events, "UTC", time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, it := range items {
+		got = append(got, it.Req.StartsAt[:10])
+	}
+	// 10 марта исключено, 24-е перенесено на 25-е, 31-е — последнее до until.
+	want := "2025-03-03 2025-03-17 2025-03-31 2025-03-25"
+	if strings.Join(got, " ") != want {
+		t.Fatalf("occurrences = %v, want %s", got, want)
+	}
+}
+
+func TestFromEventsLimits(t *testing.T) {
+	start := time.Date(2018, 1, 1, 10, 0, 0, 0, time.UTC)
+	until := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// Три раза в неделю за семь лет — больше тысячи повторений одной серии.
+	items, err := FromEvents([]ical.VEvent{{
+		UID: "s", Start: start, End: start.Add(time.Hour), ZoneName: "UTC", RRule: "FREQ=WEEKLY;BYDAY=MO,WE,FR",
+	}}, "UTC", until)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) < 1000 || items[0].Err != nil {
+		t.Fatalf("got %d items (first error %v), want the whole series", len(items), items[0].Err)
+	}
+
+	// А предел файла по-прежнему действует: каждый день за пятнадцать лет.
+	daily := time.Date(2010, 1, 1, 10, 0, 0, 0, time.UTC)
+	_, err = FromEvents([]ical.VEvent{{
+		UID: "d", Start: daily, End: daily.Add(time.Hour), ZoneName: "UTC", RRule: "FREQ=DAILY",
+	}}, "UTC", until)
+	if err == nil {
+		t.Fatalf("more than %d items accepted", maxItems)
+	}
+}
@@ -0,0 +1,28 @@
+// Package importer — разбор файлов с историей тренировок (CSV из таблиц,
+// iCalendar из календарей) и сопоставление участников с клиентами.
+package importer
+
+import (
+	"traindesk/internal/workout"
+)
+
+// maxItems — предел тренировок в одном файле (с учётом повторений серий).
+const maxItems = 5000
+
+// Item — тренировка, прочитанная из файла. Тип может быть пустым: его
+// определяет вызывающий по Type, Summary или типу по умолчанию.
+type Item struct {
+	Row       int
+	Req       workout.CreateWorkoutRequest // время, тип, заметки, зал; без клиентов
+	Summary   string                       // название события ICS
+	Attendees []Attendee
+
+	Skip string // причина пропуска, например отменённое событие
+	Err  error
+}
+
+// Attendee — участник тренировки из файла.
+type Attendee struct {
+	Name  string
+	Email string
+}
@@ -0,0 +1,162 @@
+package importer
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+// Candidate — клиент, с которым сопоставляются участники из файла.
+type Candidate struct {
+	ID        uuid.UUID
+	FirstName string
+	LastName  string
+	Email     string
+}
+
+type candidate struct {
+	Candidate
+	names []string // «имя фамилия» и «фамилия имя» после нормализации
+	email string
+}
+
+// Matcher сопоставляет имена из файла с клиентами: сначала по почте, потом
+// по точному имени, потом по расстоянию Левенштейна.
+type Matcher struct {
+	cands []candidate
+}
+
+// NewMatcher готовит сопоставление с набором клиентов.
+func NewMatcher(cs []Candidate) *Matcher {
+	m := &Matcher{cands: make([]candidate, 0, len(cs))}
+	for _, c := range cs {
+		m.Add(c)
+	}
+	return m
+}
+
+// Add добавляет клиента, например созданного по ходу импорта.
+func (m *Matcher) Add(c Candidate) {
+	first, last := NormalizeName(c.FirstName), NormalizeName(c.LastName)
+	m.cands = append(m.cands, candidate{
+		Candidate: c,
+		names:     []string{strings.TrimSpace(first + " " + last), strings.TrimSpace(last + " " + first)},
+		email:     strings.ToLower(strings.TrimSpace(c.Email)),
+	})
+}
+
+// Match ищет клиента для участника. Для MatchNone и MatchAmbiguous
+// клиент не возвращается.
+func (m *Matcher) Match(name, email string) (Candidate, MatchKind, int) {
+	if email = strings.ToLower(strings.TrimSpace(email)); email != "" {
+		for _, c := range m.cands {
+			if c.email == email {
+				return c.Candidate, MatchEmail, 0
+			}
+		}
+	}
+
+	norm := NormalizeName(name)
+	if norm == "" {
+		return Candidate{}, MatchNone, 0
+	}
+
+	best, bestDist, ties := -1, 0, 0
+	for i, c := range m.cands {
+		d := -1
+		for _, n := range c.names {
+			if v := Levenshtein(norm, n); d < 0 || v < d {
+				d = v
+			}
+		}
+		switch {
+		case best < 0 || d < bestDist:
+			best, bestDist, ties = i, d, 1
+		case d == bestDist && m.cands[best].ID != c.ID:
+			ties++
+		}
+	}
+
+	switch {
+	case best < 0 || bestDist > maxDistance(norm):
+		return Candidate{}, MatchNone, 0
+	case ties > 1:
+		return Candidate{}, MatchAmbiguous, bestDist
+	case bestDist == 0:
+		return m.cands[best].Candidate, MatchExact, 0
+	}
+	return m.cands[best].Candidate, MatchFuzzy, bestDist
+}
+
+// maxDistance — сколько правок допускается для имени: одна на пять букв,
+// для коротких имён — ни одной, чтобы «Иван» не стал «Иваном» соседа.
+func maxDistance(name string) int {
+	return utf8.RuneCountInString(name) / 5
+}
+
+// NormalizeName приводит имя к виду для сравнения: строчные буквы, «ё» как «е»,
+// без знаков препинания, слова через один пробел.
+func NormalizeName(s string) string {
+	var b strings.Builder
+	space := false
+	for _, r := range strings.ToLower(s) {
+		switch {
+		case r == 'ё':
+			r = 'е'
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+		default:
+			space = b.Len() > 0
+			continue
+		}
+		if space {
+			b.WriteByte(' ')
+			space = false
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// Levenshtein — расстояние редактирования между строками по символам.
+func Levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	if len(ra) == 0 {
+		return len(rb)
+	}
+	if len(rb) == 0 {
+		return len(ra)
+	}
+
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
+
+// SplitName делит полное имя на имя и фамилию: последнее слово — фамилия.
+// «Фамилия, Имя» тоже понимается.
+func SplitName(s string) (first, last string) {
+	if l, f, ok := strings.Cut(s, ","); ok {
+		return strings.TrimSpace(f), strings.TrimSpace(l)
+	}
+	fields := strings.Fields(s)
+	if len(fields) < 2 {
+		return strings.TrimSpace(s), ""
+	}
+	return strings.Join(fields[:len(fields)-1], " "), fields[len(fields)-1]
+}
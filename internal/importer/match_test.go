@@ -0,0 +1,107 @@
+package importer
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestMatcher(t *testing.T) {
+	ivan := Candidate{ID: uuid.New(), FirstName: "Иван", LastName: "Петров", Email: "Ivan@Example.com"}
+	fedor := Candidate{ID: uuid.New(), FirstName: "Фёдор", LastName: "Смирнов"}
+	anna1 := Candidate{ID: uuid.New(), FirstName: "Анна", LastName: "Козлова"}
+	anna2 := Candidate{ID: uuid.New(), FirstName: "Анна", LastName: "Козлова"}
+	m := NewMatcher([]Candidate{ivan, fedor, anna1, anna2})
+
+	tests := []struct {
+		name, email string
+		wantKind    MatchKind
+		wantID      uuid.UUID
+	}{
+		{"", " ivan@example.com ", MatchEmail, ivan.ID},
+		{"Кто-то другой", "ivan@example.com", MatchEmail, ivan.ID},
+		{"Иван Петров", "", MatchExact, ivan.ID},
+		{"Петров Иван", "", MatchExact, ivan.ID},
+		{"ПЕТРОВ, иван", "", MatchExact, ivan.ID},
+		{"Федор Смирнов", "", MatchExact, fedor.ID},
+		{"Фёдор Смирнв", "", MatchFuzzy, fedor.ID},
+		{"Анна Козлова", "", MatchAmbiguous, uuid.Nil},
+		{"Иван", "", MatchNone, uuid.Nil},
+		{"Мария Иванова", "", MatchNone, uuid.Nil},
+		{"", "", MatchNone, uuid.Nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name+tt.email, func(t *testing.T) {
+			c, kind, _ := m.Match(tt.name, tt.email)
+			if kind != tt.wantKind || c.ID != tt.wantID {
+				t.Fatalf("Match = %v, %s; want %v, %s", c.ID, kind, tt.wantID, tt.wantKind)
+			}
+		})
+	}
+}
+
+func TestMatcherAdd(t *testing.T) {
+	m := NewMatcher(nil)
+	if _, kind, _ := m.Match("Ольга Новикова", ""); kind != MatchNone {
+		t.Fatalf("empty matcher: %s", kind)
+	}
+	olga := Candidate{ID: uuid.New(), FirstName: "Ольга", LastName: "Новикова"}
+	m.Add(olga)
+	if c, kind, _ := m.Match("Ольга Новикова", ""); kind != MatchExact || c.ID != olga.ID {
+		t.Fatalf("after Add: %v, %s", c.ID, kind)
+	}
+}
+
+func TestNormalizeName(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"  Иван   Петров ", "иван петров"},
+		{"Петров, Иван", "петров иван"},
+		{"Алёна Ёлкина", "алена елкина"},
+		{"Anna-Maria O'Neil", "anna maria o neil"},
+		{"...", ""},
+	}
+	for _, tt := range tests {
+		if got := NormalizeName(tt.in); got != tt.want {
+			t.Errorf("NormalizeName(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestLevenshtein(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"", "абв", 3},
+		{"кот", "", 3},
+		{"кот", "кот", 0},
+		{"кот", "кит", 1},
+		{"петров", "петрова", 1},
+		{"kitten", "sitting", 3},
+	}
+	for _, tt := range tests {
+		if got := Levenshtein(tt.a, tt.b); got != tt.want {
+			t.Errorf("Levenshtein(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestSplitName(t *testing.T) {
+	tests := []struct {
+		in, first, last string
+	}{
+		{"Иван Петров", "Иван", "Петров"},
+		{"Анна Мария Козлова", "Анна Мария", "Козлова"},
+		{"Петров, Иван", "Иван", "Петров"},
+		{" Иван ", "Иван", ""},
+	}
+	for _, tt := range tests {
+		first, last := SplitName(tt.in)
+		if first != tt.first || last != tt.last {
+			t.Errorf("SplitName(%q) = %q, %q; want %q, %q", tt.in, first, last, tt.first, tt.last)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package importer
+
+// Status — итог обработки строки файла.
+type Status string
+
+const (
+	StatusCreated Status = "created" // тренировка создана (при dry_run — будет создана)
+	StatusSkipped Status = "skipped" // пропущена: уже есть или событие отменено
+	StatusFailed  Status = "failed"  // не удалось разобрать или проверить
+)
+
+// MatchKind — как участник из файла сопоставлен с клиентом.
+type MatchKind string
+
+const (
+	MatchEmail     MatchKind = "email"     // совпала почта
+	MatchExact     MatchKind = "exact"     // совпало имя
+	MatchFuzzy     MatchKind = "fuzzy"     // имя похоже (опечатка, порядок слов)
+	MatchNew       MatchKind = "new"       // создан новый клиент
+	MatchNone      MatchKind = "none"      // подходящего клиента нет
+	MatchAmbiguous MatchKind = "ambiguous" // несколько одинаково похожих клиентов
+)
+
+// Mapping — какие колонки CSV соответствуют полям тренировки.
+// Значение — заголовок колонки; пусто — колонки нет.
+type Mapping struct {
+	Date     string `json:"date"`     // YYYY-MM-DD или DD.MM.YYYY
+	Start    string `json:"start"`    // HH:MM или дата со временем
+	End      string `json:"end"`      // HH:MM или дата со временем
+	Duration string `json:"duration"` // минуты
+	Type     string `json:"type"`
+	Clients  string `json:"clients"` // имена или почта через «;» или «,»
+	Notes    string `json:"notes"`
+	Room     string `json:"room"`
+	TimeZone string `json:"time_zone"`
+}
+
+// ClientMatch — участник из файла и найденный для него клиент.
+type ClientMatch struct {
+	Name     string    `json:"name"`
+	Email    string    `json:"email,omitempty"`
+	Match    MatchKind `json:"match"`
+	ClientID *string   `json:"client_id"`
+	Distance int       `json:"distance,omitempty"` // для fuzzy — число правок
+}
+
+// RowReport — что произошло со строкой CSV или событием (повторением) ICS.
+type RowReport struct {
+	Row       int           `json:"row"` // номер строки в файле
+	Status    Status        `json:"status"`
+	Reason    string        `json:"reason,omitempty"`
+	WorkoutID *string       `json:"workout_id,omitempty"`
+	StartsAt  string        `json:"starts_at,omitempty"`
+	Type      string        `json:"type,omitempty"`
+	Clients   []ClientMatch `json:"clients,omitempty"`
+}
+
+// Report — отчёт об импорте.
+type Report struct {
+	Format         string      `json:"format"` // "ics" или "csv"
+	DryRun         bool        `json:"dry_run"`
+	Created        int         `json:"created"`
+	Skipped        int         `json:"skipped"`
+	Failed         int         `json:"failed"`
+	ClientsCreated int         `json:"clients_created"`
+	Mapping        *Mapping    `json:"mapping,omitempty"` // применённое сопоставление колонок CSV
+	Rows           []RowReport `json:"rows"`
+}
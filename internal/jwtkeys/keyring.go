@@ -0,0 +1,252 @@
+package jwtkeys
+
+// Набор ключей подписи JWT, загружаемый из каталога.
+//
+// Файл <kid>.pem — приватный ключ (RSA или Ed25519): им можно подписывать
+// и проверять. Файл <kid>.pub.pem — только публичный ключ: так хранится
+// выведенный из оборота ключ, пока не истекут выпущенные им токены.
+// Подписывает всегда активный ключ, проверяются токены любым ключом из набора.
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Key — один ключ набора.
+type Key struct {
+	ID      string
+	Method  jwt.SigningMethod
+	Private crypto.PrivateKey // nil, если ключ только для проверки
+	Public  crypto.PublicKey
+}
+
+// KeyRing — потокобезопасный набор ключей с возможностью перечитать каталог.
+type KeyRing struct {
+	dir       string
+	activeKID string
+
+	// legacySecret — HMAC-секрет для токенов HS256, выпущенных до перехода
+	// на асимметричную подпись. Пока он задан, такие токены принимаются.
+	legacySecret []byte
+
+	mu     sync.RWMutex
+	keys   map[string]*Key
+	active *Key
+}
+
+// Load читает ключи из dir. Если dir пуст, набор работает в режиме
+// совместимости: подписывает и проверяет HS256 с legacySecret.
+func Load(dir, activeKID string, legacySecret []byte) (*KeyRing, error) {
+	kr := &KeyRing{
+		dir:          dir,
+		activeKID:    activeKID,
+		legacySecret: legacySecret,
+	}
+	if err := kr.Reload(); err != nil {
+		return nil, err
+	}
+	return kr, nil
+}
+
+// Reload перечитывает каталог с ключами. При ошибке текущий набор не меняется.
+func (kr *KeyRing) Reload() error {
+	if kr.dir == "" {
+		if len(kr.legacySecret) == 0 {
+			return errors.New("jwtkeys: neither keys dir nor legacy secret configured")
+		}
+		return nil
+	}
+
+	keys, err := readDir(kr.dir)
+	if err != nil {
+		return err
+	}
+
+	active, err := pickActive(keys, kr.activeKID)
+	if err != nil {
+		return err
+	}
+
+	kr.mu.Lock()
+	kr.keys = keys
+	kr.active = active
+	kr.mu.Unlock()
+
+	return nil
+}
+
+// Sign подписывает claims активным ключом и проставляет заголовок kid.
+func (kr *KeyRing) Sign(claims jwt.Claims) (string, error) {
+	kr.mu.RLock()
+	active := kr.active
+	kr.mu.RUnlock()
+
+	if active == nil {
+		return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(kr.legacySecret)
+	}
+
+	token := jwt.NewWithClaims(active.Method, claims)
+	token.Header["kid"] = active.ID
+	return token.SignedString(active.Private)
+}
+
+// Keyfunc — ключ проверки подписи для jwt.Parse.
+func (kr *KeyRing) Keyfunc(token *jwt.Token) (interface{}, error) {
+	if _, ok := token.Method.(*jwt.SigningMethodHMAC); ok {
+		if len(kr.legacySecret) == 0 {
+			return nil, jwt.ErrSignatureInvalid
+		}
+		return kr.legacySecret, nil
+	}
+
+	kid, _ := token.Header["kid"].(string)
+
+	kr.mu.RLock()
+	key, ok := kr.keys[kid]
+	kr.mu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("jwtkeys: unknown kid %q", kid)
+	}
+	if token.Method.Alg() != key.Method.Alg() {
+		return nil, jwt.ErrSignatureInvalid
+	}
+	return key.Public, nil
+}
+
+// Keys возвращает все ключи набора, отсортированные по kid.
+func (kr *KeyRing) Keys() []*Key {
+	kr.mu.RLock()
+	defer kr.mu.RUnlock()
+
+	res := make([]*Key, 0, len(kr.keys))
+	for _, k := range kr.keys {
+		res = append(res, k)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].ID < res[j].ID })
+	return res
+}
+
+func readDir(dir string) (map[string]*Key, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("jwtkeys: read dir: %w", err)
+	}
+
+	keys := make(map[string]*Key)
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasSuffix(name, ".pem") {
+			continue
+		}
+
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return nil, fmt.Errorf("jwtkeys: read %s: %w", name, err)
+		}
+
+		kid := strings.TrimSuffix(name, ".pem")
+		publicOnly := strings.HasSuffix(kid, ".pub")
+		kid = strings.TrimSuffix(kid, ".pub")
+
+		key, err := parseKey(kid, data, publicOnly)
+		if err != nil {
+			return nil, fmt.Errorf("jwtkeys: %s: %w", name, err)
+		}
+
+		// Если есть и приватный, и публичный файл, приватный важнее.
+		if prev, ok := keys[kid]; ok && prev.Private != nil {
+			continue
+		}
+		keys[kid] = key
+	}
+
+	return keys, nil
+}
+
+func pickActive(keys map[string]*Key, activeKID string) (*Key, error) {
+	if activeKID != "" {
+		k, ok := keys[activeKID]
+		if !ok || k.Private == nil {
+			return nil, fmt.Errorf("jwtkeys: active key %q not found or has no private part", activeKID)
+		}
+		return k, nil
+	}
+
+	var active *Key
+	for _, k := range keys {
+		if k.Private == nil {
+			continue
+		}
+		if active != nil {
+			return nil, errors.New("jwtkeys: several private keys found, set active kid explicitly")
+		}
+		active = k
+	}
+	if active == nil {
+		return nil, errors.New("jwtkeys: no private key found")
+	}
+	return active, nil
+}
+
+func parseKey(kid string, data []byte, publicOnly bool) (*Key, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block")
+	}
+
+	if publicOnly {
+		pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		return newKey(kid, nil, pub)
+	}
+
+	var priv crypto.PrivateKey
+	var err error
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		priv, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		priv, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	default:
+		err = fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	signer, ok := priv.(crypto.Signer)
+	if !ok {
+		return nil, errors.New("private key cannot sign")
+	}
+	return newKey(kid, priv, signer.Public())
+}
+
+func newKey(kid string, priv crypto.PrivateKey, pub crypto.PublicKey) (*Key, error) {
+	k := &Key{ID: kid, Private: priv, Public: pub}
+
+	switch pub.(type) {
+	case *rsa.PublicKey:
+		k.Method = jwt.SigningMethodRS256
+	case ed25519.PublicKey:
+		k.Method = jwt.SigningMethodEdDSA
+	default:
+		return nil, fmt.Errorf("unsupported key type %T", pub)
+	}
+
+	return k, nil
+}
@@ -0,0 +1,127 @@
+package org
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Role — роль участника организации.
+type Role string
+
+const (
+	RoleOwner     Role = "owner"
+	RoleAdmin     Role = "admin"
+	RoleTrainer   Role = "trainer"
+	RoleAssistant Role = "assistant"
+)
+
+// ValidRoles — список допустимых ролей.
+var ValidRoles = []Role{
+	RoleOwner,
+	RoleAdmin,
+	RoleTrainer,
+	RoleAssistant,
+}
+
+// IsValidRole проверяет, что строка — одна из известных ролей.
+func IsValidRole(r string) bool {
+	for _, v := range ValidRoles {
+		if Role(r) == v {
+			return true
+		}
+	}
+	return false
+}
+
+// Permission — действие, которое роль может выполнять в организации.
+type Permission string
+
+const (
+	PermClientsRead  Permission = "clients:read"
+	PermClientsWrite Permission = "clients:write"
+	// Безвозвратное удаление клиента вместе с историей — только для руководства.
+	PermClientsDelete Permission = "clients:delete"
+
+	// Тренер видит и правит только свои тренировки, *All — любые в организации.
+	PermWorkoutsReadOwn  Permission = "workouts:read_own"
+	PermWorkoutsReadAll  Permission = "workouts:read_all"
+	PermWorkoutsWriteOwn Permission = "workouts:write_own"
+	PermWorkoutsWriteAll Permission = "workouts:write_all"
+
+	// Собственные упражнения организации в справочнике.
+	PermExercisesWrite Permission = "exercises:write"
+	// Собственные виды тренировок организации.
+	PermWorkoutTypesWrite Permission = "workout_types:write"
+
+	PermMembersManage Permission = "members:manage"
+)
+
+var rolePermissions = map[Role][]Permission{
+	RoleOwner: {
+		PermClientsRead, PermClientsWrite, PermClientsDelete,
+		PermWorkoutsReadOwn, PermWorkoutsReadAll, PermWorkoutsWriteOwn, PermWorkoutsWriteAll,
+		PermExercisesWrite, PermWorkoutTypesWrite,
+		PermMembersManage,
+	},
+	RoleAdmin: {
+		PermClientsRead, PermClientsWrite, PermClientsDelete,
+		PermWorkoutsReadOwn, PermWorkoutsReadAll, PermWorkoutsWriteOwn, PermWorkoutsWriteAll,
+		PermExercisesWrite, PermWorkoutTypesWrite,
+		PermMembersManage,
+	},
+	RoleTrainer: {
+		PermClientsRead, PermClientsWrite,
+		PermWorkoutsReadOwn, PermWorkoutsWriteOwn,
+		PermExercisesWrite, PermWorkoutTypesWrite,
+	},
+	RoleAssistant: {
+		PermClientsRead,
+		PermWorkoutsReadOwn, PermWorkoutsReadAll,
+	},
+}
+
+// Can проверяет, есть ли у роли право p.
+func (r Role) Can(p Permission) bool {
+	for _, v := range rolePermissions[r] {
+		if v == p {
+			return true
+		}
+	}
+	return false
+}
+
+// Organization — зал или студия, в которой работают тренеры.
+// Personal-организация создаётся для каждого тренера при регистрации.
+type Organization struct {
+	ID       uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	Name     string    `gorm:"not null"`
+	Personal bool      `gorm:"not null;default:false"`
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// Membership — участие тренера в организации с определённой ролью.
+type Membership struct {
+	OrganizationID uuid.UUID `gorm:"type:uuid;primaryKey"`
+	UserID         uuid.UUID `gorm:"type:uuid;primaryKey;index"`
+	Role           Role      `gorm:"type:varchar(16);not null"`
+
+	CreatedAt time.Time
+}
+
+// Invitation — приглашение в организацию по e-mail.
+type Invitation struct {
+	ID             uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	OrganizationID uuid.UUID `gorm:"type:uuid;not null;index"`
+	Email          string    `gorm:"not null"`
+	Role           Role      `gorm:"type:varchar(16);not null"`
+	TokenHash      string    `gorm:"size:64;not null;uniqueIndex"`
+	InvitedBy      uuid.UUID `gorm:"type:uuid;not null"`
+
+	ExpiresAt  time.Time `gorm:"not null"`
+	AcceptedAt *time.Time
+
+	CreatedAt time.Time
+}
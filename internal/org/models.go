@@ -0,0 +1,69 @@
+package org
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+)
+
+// CreateOrganizationRequest — тело запроса для создания организации.
+type CreateOrganizationRequest struct {
+	Name string `json:"name"`
+}
+
+// Normalize убирает пробелы по краям названия.
+func (r *CreateOrganizationRequest) Normalize() {
+	r.Name = strings.TrimSpace(r.Name)
+}
+
+// Validate проверяет название. Управляющие символы запрещены: название
+// попадает в тему писем-приглашений.
+func (r CreateOrganizationRequest) Validate() error {
+	if r.Name == "" {
+		return errors.New("name is required")
+	}
+	if strings.IndexFunc(r.Name, unicode.IsControl) >= 0 {
+		return errors.New("name must not contain control characters")
+	}
+	return nil
+}
+
+// OrganizationResponse — организация с ролью текущего тренера в ней.
+type OrganizationResponse struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	Personal bool   `json:"personal"`
+	Role     string `json:"role"`
+}
+
+// MemberResponse — участник организации.
+type MemberResponse struct {
+	UserID      string `json:"user_id"`
+	Email       string `json:"email"`
+	TrainerName string `json:"trainer_name"`
+	Role        string `json:"role"`
+}
+
+// UpdateMemberRequest — смена роли участника.
+type UpdateMemberRequest struct {
+	Role string `json:"role"`
+}
+
+// CreateInvitationRequest — пригласить тренера по e-mail.
+type CreateInvitationRequest struct {
+	Email string `json:"email"`
+	Role  string `json:"role"`
+}
+
+// InvitationResponse — приглашение (без токена).
+type InvitationResponse struct {
+	ID        string `json:"id"`
+	Email     string `json:"email"`
+	Role      string `json:"role"`
+	ExpiresAt string `json:"expires_at"`
+}
+
+// AcceptInvitationRequest — принять приглашение по токену из письма.
+type AcceptInvitationRequest struct {
+	Token string `json:"token"`
+}
@@ -0,0 +1,29 @@
+package org
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// CreatePersonal создаёт личную организацию тренера и делает его владельцем.
+func CreatePersonal(tx *gorm.DB, userID uuid.UUID, name string) (Organization, error) {
+	o := Organization{
+		ID:       uuid.New(),
+		Name:     name,
+		Personal: true,
+	}
+	if err := tx.Create(&o).Error; err != nil {
+		return Organization{}, err
+	}
+
+	m := Membership{
+		OrganizationID: o.ID,
+		UserID:         userID,
+		Role:           RoleOwner,
+	}
+	if err := tx.Create(&m).Error; err != nil {
+		return Organization{}, err
+	}
+
+	return o, nil
+}
@@ -0,0 +1,78 @@
+package pagination
+
+// Курсорная (keyset) пагинация: курсор хранит значения ключа сортировки
+// последнего отданного элемента, следующая страница начинается строго после него.
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strconv"
+)
+
+const (
+	DefaultLimit = 50
+	MaxLimit     = 200
+)
+
+var (
+	ErrInvalidCursor = errors.New("invalid cursor")
+	ErrInvalidLimit  = errors.New("invalid limit")
+)
+
+// Page — конверт ответа для постраничных списков.
+type Page[T any] struct {
+	Items      []T     `json:"items"`
+	NextCursor *string `json:"next_cursor"`
+}
+
+type cursor struct {
+	Sort   string   `json:"s"`
+	Values []string `json:"v"`
+}
+
+// EncodeCursor упаковывает ключ сортировки sort и значения последнего элемента.
+func EncodeCursor(sort string, values ...string) string {
+	b, _ := json.Marshal(cursor{Sort: sort, Values: values})
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// DecodeCursor распаковывает курсор и проверяет, что он выдан для той же
+// сортировки и содержит ожидаемое число значений.
+func DecodeCursor(s, sort string, n int) ([]string, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var cur cursor
+	if err := json.Unmarshal(b, &cur); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	if cur.Sort != sort || len(cur.Values) != n {
+		return nil, ErrInvalidCursor
+	}
+	return cur.Values, nil
+}
+
+// ParseLimit разбирает параметр limit; пустое значение — DefaultLimit.
+func ParseLimit(s string) (int, error) {
+	if s == "" {
+		return DefaultLimit, nil
+	}
+
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 1 || n > MaxLimit {
+		return 0, ErrInvalidLimit
+	}
+	return n, nil
+}
+
+// Trim обрезает выборку из limit+1 элементов до limit и сообщает,
+// есть ли следующая страница.
+func Trim[T any](items []T, limit int) ([]T, bool) {
+	if len(items) > limit {
+		return items[:limit], true
+	}
+	return items, false
+}
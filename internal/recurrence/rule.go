@@ -0,0 +1,358 @@
+// Package recurrence — правила повторения RFC 5545 (RRULE) в объёме,
+// нужном для расписания тренировок: DAILY, WEEKLY и MONTHLY с INTERVAL,
+// COUNT, UNTIL, BYDAY и BYMONTHDAY.
+package recurrence
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Frequency — частота повторения (FREQ).
+type Frequency string
+
+const (
+	Daily   Frequency = "DAILY"
+	Weekly  Frequency = "WEEKLY"
+	Monthly Frequency = "MONTHLY"
+)
+
+const (
+	maxInterval = 99
+	maxCount    = 1000
+)
+
+var (
+	// ErrInvalidRule — правило не разобрано или использует неподдерживаемые части.
+	ErrInvalidRule = errors.New("invalid recurrence rule")
+)
+
+var weekdayCodes = map[string]time.Weekday{
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+	"SU": time.Sunday,
+}
+
+var weekdayNames = [...]string{"SU", "MO", "TU", "WE", "TH", "FR", "SA"}
+
+// WeekdayNum — элемент BYDAY: день недели и, для MONTHLY, его номер
+// в месяце (1 — первый, -1 — последний, 0 — каждый).
+type WeekdayNum struct {
+	Weekday time.Weekday
+	N       int
+}
+
+// Rule — разобранное правило RRULE.
+type Rule struct {
+	Freq       Frequency
+	Interval   int
+	Count      int       // 0 — без ограничения по числу
+	Until      time.Time // нулевое — без ограничения по дате
+	UntilDate  bool      // UNTIL задан датой: включительно до конца этого дня
+	ByDay      []WeekdayNum
+	ByMonthDay []int
+}
+
+// Parse разбирает строку вида "FREQ=WEEKLY;BYDAY=MO,TH;COUNT=10".
+// Префикс "RRULE:" допускается.
+func Parse(s string) (Rule, error) {
+	s = strings.TrimSpace(s)
+	s = strings.TrimPrefix(strings.TrimPrefix(s, "RRULE:"), "rrule:")
+	if s == "" {
+		return Rule{}, fmt.Errorf("%w: empty", ErrInvalidRule)
+	}
+
+	r := Rule{Interval: 1}
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(s, ";") {
+		key, value, ok := strings.Cut(part, "=")
+		key = strings.ToUpper(strings.TrimSpace(key))
+		value = strings.ToUpper(strings.TrimSpace(value))
+		if !ok || key == "" || value == "" {
+			return Rule{}, fmt.Errorf("%w: malformed part %q", ErrInvalidRule, part)
+		}
+		if seen[key] {
+			return Rule{}, fmt.Errorf("%w: duplicate %s", ErrInvalidRule, key)
+		}
+		seen[key] = true
+
+		var err error
+		switch key {
+		case "FREQ":
+			r.Freq = Frequency(value)
+			if r.Freq != Daily && r.Freq != Weekly && r.Freq != Monthly {
+				return Rule{}, fmt.Errorf("%w: FREQ must be DAILY, WEEKLY or MONTHLY", ErrInvalidRule)
+			}
+		case "INTERVAL":
+			r.Interval, err = strconv.Atoi(value)
+			if err != nil || r.Interval < 1 || r.Interval > maxInterval {
+				return Rule{}, fmt.Errorf("%w: INTERVAL must be between 1 and %d", ErrInvalidRule, maxInterval)
+			}
+		case "COUNT":
+			r.Count, err = strconv.Atoi(value)
+			if err != nil || r.Count < 1 || r.Count > maxCount {
+				return Rule{}, fmt.Errorf("%w: COUNT must be between 1 and %d", ErrInvalidRule, maxCount)
+			}
+		case "UNTIL":
+			if r.Until, r.UntilDate, err = parseUntil(value); err != nil {
+				return Rule{}, err
+			}
+		case "BYDAY":
+			if r.ByDay, err = parseByDay(value); err != nil {
+				return Rule{}, err
+			}
+		case "BYMONTHDAY":
+			if r.ByMonthDay, err = parseByMonthDay(value); err != nil {
+				return Rule{}, err
+			}
+		case "WKST":
+			if value != "MO" {
+				return Rule{}, fmt.Errorf("%w: only WKST=MO is supported", ErrInvalidRule)
+			}
+		default:
+			return Rule{}, fmt.Errorf("%w: %s is not supported", ErrInvalidRule, key)
+		}
+	}
+
+	if r.Freq == "" {
+		return Rule{}, fmt.Errorf("%w: FREQ is required", ErrInvalidRule)
+	}
+	if r.Count > 0 && !r.Until.IsZero() {
+		return Rule{}, fmt.Errorf("%w: COUNT and UNTIL are mutually exclusive", ErrInvalidRule)
+	}
+	if len(r.ByMonthDay) > 0 && r.Freq != Monthly {
+		return Rule{}, fmt.Errorf("%w: BYMONTHDAY is only supported with FREQ=MONTHLY", ErrInvalidRule)
+	}
+	for _, d := range r.ByDay {
+		if d.N != 0 && r.Freq != Monthly {
+			return Rule{}, fmt.Errorf("%w: numbered BYDAY is only supported with FREQ=MONTHLY", ErrInvalidRule)
+		}
+	}
+	return r, nil
+}
+
+func parseUntil(v string) (time.Time, bool, error) {
+	if t, err := time.Parse("20060102T150405Z", v); err == nil {
+		return t, false, nil
+	}
+	if t, err := time.Parse("20060102", v); err == nil {
+		return t, true, nil
+	}
+	return time.Time{}, false, fmt.Errorf("%w: UNTIL must be YYYYMMDD or YYYYMMDDTHHMMSSZ", ErrInvalidRule)
+}
+
+func parseByDay(v string) ([]WeekdayNum, error) {
+	var out []WeekdayNum
+	for _, item := range strings.Split(v, ",") {
+		item = strings.TrimSpace(item)
+		if len(item) < 2 {
+			return nil, fmt.Errorf("%w: bad BYDAY %q", ErrInvalidRule, item)
+		}
+		wd, ok := weekdayCodes[item[len(item)-2:]]
+		if !ok {
+			return nil, fmt.Errorf("%w: bad BYDAY %q", ErrInvalidRule, item)
+		}
+		n := 0
+		if prefix := item[:len(item)-2]; prefix != "" {
+			var err error
+			n, err = strconv.Atoi(prefix)
+			if err != nil || n == 0 || n < -5 || n > 5 {
+				return nil, fmt.Errorf("%w: bad BYDAY %q", ErrInvalidRule, item)
+			}
+		}
+		out = append(out, WeekdayNum{Weekday: wd, N: n})
+	}
+	return out, nil
+}
+
+func parseByMonthDay(v string) ([]int, error) {
+	var out []int
+	for _, item := range strings.Split(v, ",") {
+		d, err := strconv.Atoi(strings.TrimSpace(item))
+		if err != nil || d == 0 || d < -31 || d > 31 {
+			return nil, fmt.Errorf("%w: bad BYMONTHDAY %q", ErrInvalidRule, item)
+		}
+		out = append(out, d)
+	}
+	return out, nil
+}
+
+// String возвращает правило в каноническом виде RRULE (без префикса).
+func (r Rule) String() string {
+	parts := []string{"FREQ=" + string(r.Freq)}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	if !r.Until.IsZero() {
+		if r.UntilDate {
+			parts = append(parts, "UNTIL="+r.Until.Format("20060102"))
+		} else {
+			parts = append(parts, "UNTIL="+r.Until.UTC().Format("20060102T150405Z"))
+		}
+	}
+	if len(r.ByDay) > 0 {
+		days := make([]string, 0, len(r.ByDay))
+		for _, d := range r.ByDay {
+			code := weekdayNames[d.Weekday]
+			if d.N != 0 {
+				code = strconv.Itoa(d.N) + code
+			}
+			days = append(days, code)
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+	if len(r.ByMonthDay) > 0 {
+		days := make([]string, 0, len(r.ByMonthDay))
+		for _, d := range r.ByMonthDay {
+			days = append(days, strconv.Itoa(d))
+		}
+		parts = append(parts, "BYMONTHDAY="+strings.Join(days, ","))
+	}
+	return strings.Join(parts, ";")
+}
+
+// dates возвращает даты-кандидаты периода k (в UTC, полночь), по возрастанию.
+// start — дата первого повторения (DTSTART).
+func (r Rule) dates(start time.Time, k int) []time.Time {
+	step := k * r.Interval
+	switch r.Freq {
+	case Daily:
+		d := start.AddDate(0, 0, step)
+		if len(r.ByDay) > 0 && !r.hasWeekday(d.Weekday()) {
+			return nil
+		}
+		return []time.Time{d}
+
+	case Weekly:
+		monday := start.AddDate(0, 0, -mondayIndex(start.Weekday())+7*step)
+		if len(r.ByDay) == 0 {
+			return []time.Time{monday.AddDate(0, 0, mondayIndex(start.Weekday()))}
+		}
+		out := make([]time.Time, 0, len(r.ByDay))
+		for _, d := range r.ByDay {
+			out = append(out, monday.AddDate(0, 0, mondayIndex(d.Weekday)))
+		}
+		return sortUnique(out)
+
+	case Monthly:
+		first := time.Date(start.Year(), start.Month()+time.Month(step), 1, 0, 0, 0, 0, time.UTC)
+		last := first.AddDate(0, 1, -1).Day()
+
+		var byMonthDay, byDay []time.Time
+		for _, md := range r.ByMonthDay {
+			day := md
+			if md < 0 {
+				day = last + 1 + md
+			}
+			if day >= 1 && day <= last {
+				byMonthDay = append(byMonthDay, first.AddDate(0, 0, day-1))
+			}
+		}
+		for _, wd := range r.ByDay {
+			byDay = append(byDay, monthWeekdays(first, last, wd)...)
+		}
+
+		switch {
+		case len(r.ByMonthDay) > 0 && len(r.ByDay) > 0:
+			return sortUnique(intersect(byMonthDay, byDay))
+		case len(r.ByMonthDay) > 0:
+			return sortUnique(byMonthDay)
+		case len(r.ByDay) > 0:
+			return sortUnique(byDay)
+		}
+		if start.Day() > last {
+			return nil
+		}
+		return []time.Time{first.AddDate(0, 0, start.Day()-1)}
+	}
+	return nil
+}
+
+func (r Rule) hasWeekday(wd time.Weekday) bool {
+	for _, d := range r.ByDay {
+		if d.Weekday == wd {
+			return true
+		}
+	}
+	return false
+}
+
+// mondayIndex — номер дня в неделе, начинающейся с понедельника (WKST=MO).
+func mondayIndex(wd time.Weekday) int {
+	return (int(wd) + 6) % 7
+}
+
+// monthWeekdays — дни месяца, подходящие под элемент BYDAY.
+func monthWeekdays(first time.Time, last int, wd WeekdayNum) []time.Time {
+	var all []time.Time
+	offset := (int(wd.Weekday) - int(first.Weekday()) + 7) % 7
+	for day := 1 + offset; day <= last; day += 7 {
+		all = append(all, first.AddDate(0, 0, day-1))
+	}
+	switch {
+	case wd.N == 0:
+		return all
+	case wd.N > 0 && wd.N <= len(all):
+		return all[wd.N-1 : wd.N]
+	case wd.N < 0 && -wd.N <= len(all):
+		return all[len(all)+wd.N : len(all)+wd.N+1]
+	}
+	return nil
+}
+
+func intersect(a, b []time.Time) []time.Time {
+	var out []time.Time
+	for _, x := range a {
+		for _, y := range b {
+			if x.Equal(y) {
+				out = append(out, x)
+				break
+			}
+		}
+	}
+	return out
+}
+
+func sortUnique(ts []time.Time) []time.Time {
+	sort.Slice(ts, func(i, j int) bool { return ts[i].Before(ts[j]) })
+	out := ts[:0]
+	for i, t := range ts {
+		if i == 0 || !t.Equal(ts[i-1]) {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+// ShiftDays сдвигает дни недели в BYDAY на days дней — для переноса серии
+// на другой день. Номерные BYDAY и BYMONTHDAY так не сдвинуть: ok == false.
+func (r Rule) ShiftDays(days int) (Rule, bool) {
+	if days == 0 || (len(r.ByDay) == 0 && len(r.ByMonthDay) == 0) {
+		return r, true
+	}
+	if len(r.ByMonthDay) > 0 {
+		return r, false
+	}
+
+	shifted := r
+	shifted.ByDay = make([]WeekdayNum, 0, len(r.ByDay))
+	for _, d := range r.ByDay {
+		if d.N != 0 {
+			return r, false
+		}
+		wd := time.Weekday(((int(d.Weekday)+days)%7 + 7) % 7)
+		shifted.ByDay = append(shifted.ByDay, WeekdayNum{Weekday: wd})
+	}
+	return shifted, true
+}
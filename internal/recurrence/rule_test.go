@@ -0,0 +1,126 @@
+package recurrence
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseString(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"FREQ=DAILY", "FREQ=DAILY"},
+		{"RRULE:FREQ=WEEKLY;BYDAY=MO,TH;COUNT=10", "FREQ=WEEKLY;COUNT=10;BYDAY=MO,TH"},
+		{"rrule:freq=weekly;interval=2;byday=fr", "FREQ=WEEKLY;INTERVAL=2;BYDAY=FR"},
+		{"FREQ=WEEKLY;INTERVAL=1;WKST=MO", "FREQ=WEEKLY"},
+		{"FREQ=MONTHLY;BYDAY=-1FR", "FREQ=MONTHLY;BYDAY=-1FR"},
+		{"FREQ=MONTHLY;BYMONTHDAY=1,15,-1", "FREQ=MONTHLY;BYMONTHDAY=1,15,-1"},
+		{"FREQ=DAILY;UNTIL=20250131", "FREQ=DAILY;UNTIL=20250131"},
+		{"FREQ=DAILY;UNTIL=20250131T235959Z", "FREQ=DAILY;UNTIL=20250131T235959Z"},
+		{" FREQ = DAILY ; COUNT = 3 ", "FREQ=DAILY;COUNT=3"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			r, err := Parse(tt.in)
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			got := r.String()
+			if got != tt.want {
+				t.Fatalf("String() = %q, want %q", got, tt.want)
+			}
+			again, err := Parse(got)
+			if err != nil {
+				t.Fatalf("Parse(String()): %v", err)
+			}
+			if again.String() != got {
+				t.Fatalf("round trip changed rule: %q -> %q", got, again.String())
+			}
+		})
+	}
+}
+
+func TestParseUntil(t *testing.T) {
+	r, err := Parse("FREQ=DAILY;UNTIL=20250131")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !r.UntilDate || !r.Until.Equal(time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("date UNTIL parsed as %v (date=%v)", r.Until, r.UntilDate)
+	}
+
+	r, err = Parse("FREQ=DAILY;UNTIL=20250131T100000Z")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.UntilDate || !r.Until.Equal(time.Date(2025, 1, 31, 10, 0, 0, 0, time.UTC)) {
+		t.Fatalf("date-time UNTIL parsed as %v (date=%v)", r.Until, r.UntilDate)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []string{
+		"",
+		"RRULE:",
+		"COUNT=3",
+		"FREQ=YEARLY",
+		"FREQ=DAILY;FREQ=WEEKLY",
+		"FREQ=DAILY;COUNT",
+		"FREQ=DAILY;INTERVAL=0",
+		"FREQ=DAILY;INTERVAL=100",
+		"FREQ=DAILY;COUNT=0",
+		"FREQ=DAILY;COUNT=1001",
+		"FREQ=DAILY;COUNT=2;UNTIL=20250101",
+		"FREQ=DAILY;UNTIL=2025-01-01",
+		"FREQ=WEEKLY;BYDAY=XX",
+		"FREQ=WEEKLY;BYDAY=1MO",
+		"FREQ=MONTHLY;BYDAY=6MO",
+		"FREQ=WEEKLY;BYMONTHDAY=1",
+		"FREQ=MONTHLY;BYMONTHDAY=32",
+		"FREQ=MONTHLY;BYMONTHDAY=0",
+		"FREQ=WEEKLY;WKST=SU",
+		"FREQ=DAILY;BYHOUR=10",
+	}
+	for _, in := range tests {
+		t.Run(in, func(t *testing.T) {
+			_, err := Parse(in)
+			if !errors.Is(err, ErrInvalidRule) {
+				t.Fatalf("Parse(%q) error = %v, want ErrInvalidRule", in, err)
+			}
+		})
+	}
+}
+
+func TestShiftDays(t *testing.T) {
+	tests := []struct {
+		rule   string
+		days   int
+		want   string
+		wantOK bool
+	}{
+		{"FREQ=DAILY", 3, "FREQ=DAILY", true},
+		{"FREQ=WEEKLY;BYDAY=MO,TH", 0, "FREQ=WEEKLY;BYDAY=MO,TH", true},
+		{"FREQ=WEEKLY;BYDAY=MO,TH", 1, "FREQ=WEEKLY;BYDAY=TU,FR", true},
+		{"FREQ=WEEKLY;BYDAY=SU", 1, "FREQ=WEEKLY;BYDAY=MO", true},
+		{"FREQ=WEEKLY;BYDAY=MO", -1, "FREQ=WEEKLY;BYDAY=SU", true},
+		{"FREQ=WEEKLY;BYDAY=WE", -9, "FREQ=WEEKLY;BYDAY=MO", true},
+		{"FREQ=MONTHLY;BYDAY=MO", 2, "FREQ=MONTHLY;BYDAY=WE", true},
+		{"FREQ=MONTHLY;BYDAY=1MO", 1, "FREQ=MONTHLY;BYDAY=1MO", false},
+		{"FREQ=MONTHLY;BYMONTHDAY=15", 1, "FREQ=MONTHLY;BYMONTHDAY=15", false},
+		{"FREQ=MONTHLY;BYMONTHDAY=15", 0, "FREQ=MONTHLY;BYMONTHDAY=15", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.rule, func(t *testing.T) {
+			r, err := Parse(tt.rule)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, ok := r.ShiftDays(tt.days)
+			if ok != tt.wantOK || got.String() != tt.want {
+				t.Fatalf("ShiftDays(%d) = %q, %v; want %q, %v", tt.days, got.String(), ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
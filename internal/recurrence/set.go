@@ -0,0 +1,79 @@
+package recurrence
+
+import (
+	"time"
+
+	"traindesk/internal/tz"
+)
+
+// maxPeriods — предел перебора периодов правила: защищает от правил,
+// которые почти никогда не срабатывают (например, 31-е число раз в два месяца).
+const maxPeriods = 10000
+
+// Set — набор повторений: правило, первое повторение (DTSTART) и исключения (EXDATE).
+type Set struct {
+	Rule     Rule
+	Start    time.Time      // момент первого повторения
+	Location *time.Location // пояс, в котором повторения сохраняют время суток
+	ExDates  []time.Time
+}
+
+// Between возвращает повторения в полуинтервале [from, to) по возрастанию.
+// COUNT считается с первого повторения, исключения в него входят (RFC 5545).
+func (s Set) Between(from, to time.Time) []time.Time {
+	loc := s.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+	local := s.Start.In(loc)
+	startDate := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.UTC)
+
+	until := s.Rule.Until
+	if s.Rule.UntilDate {
+		until = tz.StartOfDay(until, loc).AddDate(0, 0, 1).Add(-time.Nanosecond)
+	}
+
+	var out []time.Time
+	count := 0
+	for k := 0; k < maxPeriods; k++ {
+		for _, d := range s.Rule.dates(startDate, k) {
+			wall := time.Date(d.Year(), d.Month(), d.Day(),
+				local.Hour(), local.Minute(), local.Second(), 0, time.UTC)
+			t := tz.ResolveLenient(wall, loc)
+			if t.Before(s.Start) {
+				continue
+			}
+			if !until.IsZero() && t.After(until) {
+				return out
+			}
+			if !t.Before(to) {
+				return out
+			}
+			count++
+			if s.Rule.Count > 0 && count > s.Rule.Count {
+				return out
+			}
+			if !t.Before(from) && !s.excluded(t) {
+				out = append(out, t)
+			}
+		}
+	}
+	return out
+}
+
+// CountBefore — сколько повторений правила (включая исключённые) раньше t.
+// Нужна, чтобы разделить серию с COUNT на две части.
+func (s Set) CountBefore(t time.Time) int {
+	noEx := s
+	noEx.ExDates = nil
+	return len(noEx.Between(s.Start, t))
+}
+
+func (s Set) excluded(t time.Time) bool {
+	for _, ex := range s.ExDates {
+		if ex.Equal(t) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,184 @@
+package recurrence
+
+import (
+	"testing"
+	"time"
+)
+
+func mustParse(t *testing.T, s string) Rule {
+	t.Helper()
+	r, err := Parse(s)
+	if err != nil {
+		t.Fatalf("Parse(%q): %v", s, err)
+	}
+	return r
+}
+
+func utc(year int, month time.Month, day, hour, min int) time.Time {
+	return time.Date(year, month, day, hour, min, 0, 0, time.UTC)
+}
+
+func TestSetBetween(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		rule     string
+		start    time.Time
+		loc      *time.Location
+		exDates  []time.Time
+		from, to time.Time
+		want     []time.Time
+	}{
+		{
+			name:  "weekly by day",
+			rule:  "FREQ=WEEKLY;BYDAY=MO,WE",
+			start: utc(2025, 1, 6, 10, 0),
+			from:  utc(2025, 1, 1, 0, 0), to: utc(2025, 1, 20, 0, 0),
+			want: []time.Time{
+				utc(2025, 1, 6, 10, 0), utc(2025, 1, 8, 10, 0),
+				utc(2025, 1, 13, 10, 0), utc(2025, 1, 15, 10, 0),
+			},
+		},
+		{
+			name:  "every other week",
+			rule:  "FREQ=WEEKLY;INTERVAL=2",
+			start: utc(2025, 1, 6, 10, 0),
+			from:  utc(2025, 1, 1, 0, 0), to: utc(2025, 2, 4, 0, 0),
+			want: []time.Time{utc(2025, 1, 6, 10, 0), utc(2025, 1, 20, 10, 0), utc(2025, 2, 3, 10, 0)},
+		},
+		{
+			name:  "window starts after series",
+			rule:  "FREQ=DAILY",
+			start: utc(2025, 1, 1, 10, 0),
+			from:  utc(2025, 1, 3, 10, 0), to: utc(2025, 1, 5, 10, 0),
+			want: []time.Time{utc(2025, 1, 3, 10, 0), utc(2025, 1, 4, 10, 0)},
+		},
+		{
+			name:  "daily keeps wall clock across spring DST",
+			rule:  "FREQ=DAILY",
+			start: utc(2025, 3, 29, 8, 0), // 09:00 CET
+			loc:   berlin,
+			from:  utc(2025, 3, 29, 0, 0), to: utc(2025, 4, 1, 0, 0),
+			want: []time.Time{utc(2025, 3, 29, 8, 0), utc(2025, 3, 30, 7, 0), utc(2025, 3, 31, 7, 0)},
+		},
+		{
+			name:  "time in the DST gap moves forward",
+			rule:  "FREQ=DAILY",
+			start: utc(2025, 3, 29, 1, 30), // 02:30 CET; 30 марта 02:30 не существует
+			loc:   berlin,
+			from:  utc(2025, 3, 29, 0, 0), to: utc(2025, 4, 1, 0, 0),
+			want: []time.Time{utc(2025, 3, 29, 1, 30), utc(2025, 3, 30, 1, 30), utc(2025, 3, 31, 0, 30)},
+		},
+		{
+			name:  "repeated time at fall DST takes the earlier",
+			rule:  "FREQ=DAILY",
+			start: utc(2025, 10, 25, 0, 30), // 02:30 CEST
+			loc:   berlin,
+			from:  utc(2025, 10, 25, 0, 0), to: utc(2025, 10, 28, 0, 0),
+			want: []time.Time{utc(2025, 10, 25, 0, 30), utc(2025, 10, 26, 0, 30), utc(2025, 10, 27, 1, 30)},
+		},
+		{
+			name:    "count includes excluded occurrences",
+			rule:    "FREQ=DAILY;COUNT=5",
+			start:   utc(2025, 1, 1, 10, 0),
+			exDates: []time.Time{utc(2025, 1, 2, 10, 0)},
+			from:    utc(2025, 1, 1, 0, 0), to: utc(2026, 1, 1, 0, 0),
+			want: []time.Time{
+				utc(2025, 1, 1, 10, 0), utc(2025, 1, 3, 10, 0),
+				utc(2025, 1, 4, 10, 0), utc(2025, 1, 5, 10, 0),
+			},
+		},
+		{
+			name:  "until date is inclusive in the series zone",
+			rule:  "FREQ=DAILY;UNTIL=20250103",
+			start: utc(2025, 1, 1, 22, 30), // 23:30 CET
+			loc:   berlin,
+			from:  utc(2025, 1, 1, 0, 0), to: utc(2026, 1, 1, 0, 0),
+			want: []time.Time{utc(2025, 1, 1, 22, 30), utc(2025, 1, 2, 22, 30), utc(2025, 1, 3, 22, 30)},
+		},
+		{
+			name:  "until date-time is exact",
+			rule:  "FREQ=DAILY;UNTIL=20250103T100000Z",
+			start: utc(2025, 1, 1, 10, 0),
+			from:  utc(2025, 1, 1, 0, 0), to: utc(2026, 1, 1, 0, 0),
+			want: []time.Time{utc(2025, 1, 1, 10, 0), utc(2025, 1, 2, 10, 0), utc(2025, 1, 3, 10, 0)},
+		},
+		{
+			name:  "monthly skips months without the day",
+			rule:  "FREQ=MONTHLY",
+			start: utc(2025, 1, 31, 10, 0),
+			from:  utc(2025, 1, 1, 0, 0), to: utc(2025, 6, 1, 0, 0),
+			want: []time.Time{utc(2025, 1, 31, 10, 0), utc(2025, 3, 31, 10, 0), utc(2025, 5, 31, 10, 0)},
+		},
+		{
+			name:  "monthly last day",
+			rule:  "FREQ=MONTHLY;BYMONTHDAY=-1",
+			start: utc(2025, 1, 31, 10, 0),
+			from:  utc(2025, 1, 1, 0, 0), to: utc(2025, 4, 1, 0, 0),
+			want: []time.Time{utc(2025, 1, 31, 10, 0), utc(2025, 2, 28, 10, 0), utc(2025, 3, 31, 10, 0)},
+		},
+		{
+			name:  "monthly last friday",
+			rule:  "FREQ=MONTHLY;BYDAY=-1FR",
+			start: utc(2025, 1, 31, 10, 0),
+			from:  utc(2025, 1, 1, 0, 0), to: utc(2025, 4, 1, 0, 0),
+			want: []time.Time{utc(2025, 1, 31, 10, 0), utc(2025, 2, 28, 10, 0), utc(2025, 3, 28, 10, 0)},
+		},
+		{
+			name:  "daily restricted by day",
+			rule:  "FREQ=DAILY;BYDAY=SA,SU",
+			start: utc(2025, 1, 4, 10, 0),
+			from:  utc(2025, 1, 1, 0, 0), to: utc(2025, 1, 12, 0, 0),
+			want: []time.Time{utc(2025, 1, 4, 10, 0), utc(2025, 1, 5, 10, 0), utc(2025, 1, 11, 10, 0)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := Set{Rule: mustParse(t, tt.rule), Start: tt.start, Location: tt.loc, ExDates: tt.exDates}
+			got := s.Between(tt.from, tt.to)
+			if len(got) != len(tt.want) {
+				t.Fatalf("Between = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if !got[i].Equal(tt.want[i]) {
+					t.Fatalf("Between[%d] = %v, want %v", i, got[i].UTC(), tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestSetCountBefore(t *testing.T) {
+	s := Set{
+		Rule:    mustParse(t, "FREQ=DAILY;COUNT=10"),
+		Start:   utc(2025, 1, 1, 10, 0),
+		ExDates: []time.Time{utc(2025, 1, 2, 10, 0)},
+	}
+	tests := []struct {
+		at   time.Time
+		want int
+	}{
+		{utc(2025, 1, 1, 10, 0), 0},
+		{utc(2025, 1, 1, 10, 1), 1},
+		{utc(2025, 1, 5, 10, 0), 4}, // исключённое 2 января тоже считается
+		{utc(2026, 1, 1, 0, 0), 10},
+	}
+	for _, tt := range tests {
+		if got := s.CountBefore(tt.at); got != tt.want {
+			t.Errorf("CountBefore(%v) = %d, want %d", tt.at, got, tt.want)
+		}
+	}
+
+	// Разделённая по CountBefore серия вместе даёт столько же повторений.
+	split := utc(2025, 1, 5, 10, 0)
+	tail := s.Rule
+	tail.Count -= s.CountBefore(split)
+	rest := Set{Rule: tail, Start: split}
+	if got := len(rest.Between(split, utc(2026, 1, 1, 0, 0))); got != 6 {
+		t.Fatalf("tail series has %d occurrences, want 6", got)
+	}
+}
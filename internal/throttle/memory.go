@@ -0,0 +1,74 @@
+package throttle
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// memoryStoreMaxKeys — при превышении из памяти вычищаются давно не обновлявшиеся ключи.
+const memoryStoreMaxKeys = 10000
+
+// MemoryStore — хранилище счётчиков в памяти процесса.
+// Подходит для одного инстанса; при рестарте счётчики теряются.
+type MemoryStore struct {
+	mu    sync.Mutex
+	items map[string]State
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{items: make(map[string]State)}
+}
+
+func (s *MemoryStore) Load(_ context.Context, key string) (State, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.items[key], nil
+}
+
+func (s *MemoryStore) Incr(_ context.Context, key string, now, resetBefore time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	st, ok := s.items[key]
+	if !ok && len(s.items) >= memoryStoreMaxKeys {
+		s.prune(now.Add(-24 * time.Hour))
+	}
+	if st.LastFailure.Before(resetBefore) {
+		st.Failures = 0
+	}
+	st.Failures++
+	st.LastFailure = now
+	s.items[key] = st
+	return st.Failures, nil
+}
+
+func (s *MemoryStore) Lock(_ context.Context, key string, until time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if st, ok := s.items[key]; ok && st.LockedUntil.Before(until) {
+		st.LockedUntil = until
+		s.items[key] = st
+	}
+	return nil
+}
+
+func (s *MemoryStore) Delete(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.items, key)
+	return nil
+}
+
+// prune удаляет незаблокированные ключи без неудач после before. Вызывается под mu.
+func (s *MemoryStore) prune(before time.Time) {
+	now := time.Now()
+	for k, st := range s.items {
+		if st.LastFailure.Before(before) && st.LockedUntil.Before(now) {
+			delete(s.items, k)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package throttle
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Attempt — строка счётчика в БД (таблица throttle_attempts).
+type Attempt struct {
+	Key         string `gorm:"size:255;primaryKey"`
+	Failures    int    `gorm:"not null;default:0"`
+	LockedUntil time.Time
+	LastFailure time.Time
+
+	UpdatedAt time.Time
+}
+
+func (Attempt) TableName() string {
+	return "throttle_attempts"
+}
+
+// PostgresStore — хранилище счётчиков в Postgres, общее для всех инстансов.
+type PostgresStore struct {
+	db *gorm.DB
+}
+
+func NewPostgresStore(db *gorm.DB) *PostgresStore {
+	return &PostgresStore{db: db}
+}
+
+func (s *PostgresStore) Load(ctx context.Context, key string) (State, error) {
+	var row Attempt
+	err := s.db.WithContext(ctx).Where("key = ?", key).First(&row).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return State{}, nil
+	}
+	if err != nil {
+		return State{}, err
+	}
+
+	return State{
+		Failures:    row.Failures,
+		LockedUntil: row.LockedUntil,
+		LastFailure: row.LastFailure,
+	}, nil
+}
+
+func (s *PostgresStore) Incr(ctx context.Context, key string, now, resetBefore time.Time) (int, error) {
+	var failures int
+	err := s.db.WithContext(ctx).Raw(`
+		INSERT INTO throttle_attempts (key, failures, last_failure, updated_at)
+		VALUES (?, 1, ?, ?)
+		ON CONFLICT (key) DO UPDATE SET
+			failures = CASE WHEN throttle_attempts.last_failure < ? THEN 1
+			                ELSE throttle_attempts.failures + 1 END,
+			last_failure = excluded.last_failure,
+			updated_at = excluded.updated_at
+		RETURNING failures`,
+		key, now, now, resetBefore,
+	).Scan(&failures).Error
+	return failures, err
+}
+
+func (s *PostgresStore) Lock(ctx context.Context, key string, until time.Time) error {
+	return s.db.WithContext(ctx).Model(&Attempt{}).
+		Where("key = ?", key).
+		UpdateColumn("locked_until", gorm.Expr("GREATEST(locked_until, ?)", until)).Error
+}
+
+func (s *PostgresStore) Delete(ctx context.Context, key string) error {
+	return s.db.WithContext(ctx).Where("key = ?", key).Delete(&Attempt{}).Error
+}
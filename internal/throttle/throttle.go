@@ -0,0 +1,88 @@
+package throttle
+
+// Ограничение числа неудачных попыток (логин, ввод кодов) с
+// экспоненциально растущей временной блокировкой.
+
+import (
+	"context"
+	"time"
+)
+
+// State — состояние счётчика неудачных попыток по одному ключу.
+type State struct {
+	Failures    int
+	LockedUntil time.Time
+	LastFailure time.Time
+}
+
+// Store — хранилище счётчиков. Есть реализации в памяти и в Postgres.
+// Incr и Lock должны быть атомарными: параллельные неудачи по одному
+// ключу не должны теряться.
+type Store interface {
+	Load(ctx context.Context, key string) (State, error)
+	// Incr учитывает неудачу в момент now и возвращает их число подряд.
+	// Если предыдущая неудача была раньше resetBefore, счёт начинается заново.
+	Incr(ctx context.Context, key string, now, resetBefore time.Time) (int, error)
+	// Lock блокирует ключ до until, если он не заблокирован дольше.
+	Lock(ctx context.Context, key string, until time.Time) error
+	Delete(ctx context.Context, key string) error
+}
+
+// Policy — правила блокировки.
+type Policy struct {
+	FreeAttempts int           // сколько неудач подряд допускается без блокировки
+	BaseLockout  time.Duration // блокировка после первой «лишней» неудачи
+	MaxLockout   time.Duration // верхняя граница блокировки
+	ResetAfter   time.Duration // после такого затишья счётчик начинается заново
+}
+
+// Limiter считает неудачные попытки и решает, когда ключ заблокирован.
+type Limiter struct {
+	store  Store
+	policy Policy
+}
+
+func NewLimiter(store Store, policy Policy) *Limiter {
+	return &Limiter{store: store, policy: policy}
+}
+
+// Allow возвращает 0, если попытка разрешена, иначе — сколько осталось ждать.
+func (l *Limiter) Allow(ctx context.Context, key string) (time.Duration, error) {
+	st, err := l.store.Load(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+
+	if wait := time.Until(st.LockedUntil); wait > 0 {
+		return wait, nil
+	}
+	return 0, nil
+}
+
+// Fail учитывает неудачную попытку и возвращает назначенную блокировку (0 — без блокировки).
+func (l *Limiter) Fail(ctx context.Context, key string) (time.Duration, error) {
+	now := time.Now()
+	failures, err := l.store.Incr(ctx, key, now, now.Add(-l.policy.ResetAfter))
+	if err != nil {
+		return 0, err
+	}
+
+	over := failures - l.policy.FreeAttempts
+	if over <= 0 {
+		return 0, nil
+	}
+	lockout := l.policy.BaseLockout
+	for i := 1; i < over && lockout < l.policy.MaxLockout; i++ {
+		lockout *= 2
+	}
+	if lockout > l.policy.MaxLockout {
+		lockout = l.policy.MaxLockout
+	}
+
+	return lockout, l.store.Lock(ctx, key, now.Add(lockout))
+}
+
+// Reset сбрасывает счётчик после успешной попытки.
+func (l *Limiter) Reset(ctx context.Context, key string) error {
+	return l.store.Delete(ctx, key)
+}
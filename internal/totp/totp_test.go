@@ -0,0 +1,115 @@
+package totp
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfcSecret — ключ "12345678901234567890" из тестовых векторов RFC 6238 в base32.
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestCodeAtRFCVectors(t *testing.T) {
+	// Коды RFC 6238 (SHA1) — последние 6 из 8 цифр.
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+	for _, tt := range tests {
+		got, err := CodeAt(rfcSecret, Step(time.Unix(tt.unix, 0)))
+		if err != nil {
+			t.Fatalf("CodeAt(%d): %v", tt.unix, err)
+		}
+		if got != tt.want {
+			t.Errorf("CodeAt(%d) = %s, want %s", tt.unix, got, tt.want)
+		}
+	}
+}
+
+func TestCodeAtSecretFormat(t *testing.T) {
+	want, _ := CodeAt(rfcSecret, 1)
+	got, err := CodeAt(" "+strings.ToLower(rfcSecret)+"\n", 1)
+	if err != nil || got != want {
+		t.Fatalf("lowercase secret: got %q, %v; want %q", got, err, want)
+	}
+
+	if _, err := CodeAt("not base32!", 1); err == nil {
+		t.Fatal("invalid secret accepted")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	step := Step(now)
+	prev, _ := CodeAt(rfcSecret, step-1)
+	next, _ := CodeAt(rfcSecret, step+1)
+	far, _ := CodeAt(rfcSecret, step+2)
+
+	tests := []struct {
+		name     string
+		code     string
+		skew     int64
+		wantStep int64
+		wantOK   bool
+	}{
+		{"current", "050471", 0, step, true},
+		{"surrounding spaces", " 050471 ", 0, step, true},
+		{"previous step within skew", prev, 1, step - 1, true},
+		{"next step within skew", next, 1, step + 1, true},
+		{"previous step without skew", prev, 0, 0, false},
+		{"outside skew", far, 1, 0, false},
+		{"wrong code", "000000", 1, 0, false},
+		{"too short", "05047", 1, 0, false},
+		{"too long", "0504711", 1, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotStep, ok := Validate(rfcSecret, tt.code, now, tt.skew)
+			if ok != tt.wantOK || gotStep != tt.wantStep {
+				t.Fatalf("Validate = %d, %v; want %d, %v", gotStep, ok, tt.wantStep, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestGenerateSecret(t *testing.T) {
+	a, err := GenerateSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := GenerateSecret()
+	if a == b {
+		t.Fatal("two secrets are equal")
+	}
+	key, err := b32.DecodeString(a)
+	if err != nil || len(key) != secretSize {
+		t.Fatalf("secret %q decodes to %d bytes, %v", a, len(key), err)
+	}
+}
+
+func TestURI(t *testing.T) {
+	raw := URI("TrainDesk", "coach@example.com", rfcSecret)
+	u, err := url.Parse(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.Scheme != "otpauth" || u.Host != "totp" || u.Path != "/TrainDesk:coach@example.com" {
+		t.Fatalf("unexpected URI %q", raw)
+	}
+	q := u.Query()
+	for key, want := range map[string]string{
+		"secret": rfcSecret, "issuer": "TrainDesk", "algorithm": "SHA1", "digits": "6", "period": "30",
+	} {
+		if q.Get(key) != want {
+			t.Errorf("%s = %q, want %q", key, q.Get(key), want)
+		}
+	}
+}
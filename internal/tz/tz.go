@@ -0,0 +1,140 @@
+// Package tz — часовые пояса IANA и разбор локального времени с учётом
+// перехода на летнее время.
+package tz
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	// Встроенная база часовых поясов: в контейнере её может не быть.
+	_ "time/tzdata"
+)
+
+// Default — пояс по умолчанию для аккаунтов и тренировок без явного пояса.
+const Default = "UTC"
+
+// LocalLayouts — допустимые форматы локального времени без смещения.
+var LocalLayouts = []string{"2006-01-02T15:04:05", "2006-01-02T15:04"}
+
+var (
+	// ErrInvalidZone — имя пояса не найдено в базе IANA.
+	ErrInvalidZone = errors.New("unknown IANA time zone")
+
+	// ErrNonexistentTime — локальное время попадает в разрыв при переводе часов вперёд.
+	ErrNonexistentTime = errors.New("local time does not exist in this time zone (DST gap)")
+
+	// ErrInvalidTime — строка не похожа ни на RFC 3339, ни на локальное время.
+	ErrInvalidTime = errors.New("invalid time, expected RFC 3339 or YYYY-MM-DDTHH:MM in the time zone")
+)
+
+// Load находит пояс по имени IANA. «Local» и пустое имя не допускаются:
+// пояс сервера не должен влиять на расписание.
+func Load(name string) (*time.Location, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || name == "Local" {
+		return nil, ErrInvalidZone
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, ErrInvalidZone
+	}
+	return loc, nil
+}
+
+// LoadOrUTC — как Load, но для неизвестного пояса возвращает UTC.
+// Нужна для значений из БД, которые проверялись при записи.
+func LoadOrUTC(name string) *time.Location {
+	loc, err := Load(name)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// ParseTime разбирает момент времени. Строка со смещением (RFC 3339)
+// берётся как есть; без смещения — считается локальным временем в loc.
+func ParseTime(s string, loc *time.Location) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+
+	for _, layout := range LocalLayouts {
+		wall, err := time.Parse(layout, s)
+		if err != nil {
+			continue
+		}
+		return Resolve(wall, loc)
+	}
+	return time.Time{}, ErrInvalidTime
+}
+
+// Resolve переводит показания часов wall (поле Location игнорируется) в момент
+// времени в поясе loc. Время в разрыве при переводе часов вперёд — ошибка;
+// при переводе назад, когда показания встречаются дважды, берётся более раннее.
+func Resolve(wall time.Time, loc *time.Location) (time.Time, error) {
+	asUTC := time.Date(wall.Year(), wall.Month(), wall.Day(),
+		wall.Hour(), wall.Minute(), wall.Second(), wall.Nanosecond(), time.UTC)
+
+	// Смещения пояса в окрестности суток вокруг нужного момента: переход
+	// часов может случиться только между ними.
+	offsets := make([]int, 0, 2)
+	for _, probe := range []time.Time{asUTC.Add(-24 * time.Hour), asUTC, asUTC.Add(24 * time.Hour)} {
+		_, off := probe.In(loc).Zone()
+		if !containsInt(offsets, off) {
+			offsets = append(offsets, off)
+		}
+	}
+
+	var best time.Time
+	found := false
+	for _, off := range offsets {
+		t := asUTC.Add(-time.Duration(off) * time.Second).In(loc)
+		if !sameWallClock(t, asUTC) {
+			continue
+		}
+		if !found || t.Before(best) {
+			best, found = t, true
+		}
+	}
+	if !found {
+		return time.Time{}, fmt.Errorf("%s: %w", wall.Format("2006-01-02T15:04"), ErrNonexistentTime)
+	}
+	return best, nil
+}
+
+// ResolveLenient — как Resolve, но время из разрыва не ошибка: оно считается
+// по смещению до перехода и сдвигается вперёд (RFC 5545, 3.3.5). Нужна для
+// повторений серии: одно неудачное число не должно ломать всё расписание.
+func ResolveLenient(wall time.Time, loc *time.Location) time.Time {
+	t, err := Resolve(wall, loc)
+	if err == nil {
+		return t
+	}
+	asUTC := time.Date(wall.Year(), wall.Month(), wall.Day(),
+		wall.Hour(), wall.Minute(), wall.Second(), wall.Nanosecond(), time.UTC)
+	_, before := asUTC.Add(-24 * time.Hour).In(loc).Zone()
+	return asUTC.Add(-time.Duration(before) * time.Second).In(loc)
+}
+
+// StartOfDay — полночь даты d (берутся только год, месяц и день) в поясе loc.
+// Если полночь пропадает из-за перевода часов, берётся первое существующее время.
+func StartOfDay(d time.Time, loc *time.Location) time.Time {
+	return time.Date(d.Year(), d.Month(), d.Day(), 0, 0, 0, 0, loc)
+}
+
+func sameWallClock(a, b time.Time) bool {
+	return a.Year() == b.Year() && a.Month() == b.Month() && a.Day() == b.Day() &&
+		a.Hour() == b.Hour() && a.Minute() == b.Minute() && a.Second() == b.Second()
+}
+
+func containsInt(s []int, v int) bool {
+	for _, x := range s {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
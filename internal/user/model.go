@@ -13,15 +13,111 @@ type User struct {
 	PasswordHash string    `gorm:"not null"`
 	TrainerName  string    `gorm:"not null"`
 
+	// TimeZone — пояс IANA, в котором тренер видит и назначает расписание.
+	TimeZone string `gorm:"type:varchar(64);not null;default:'UTC'"`
+
 	EmailVerified bool `gorm:"not null;default:false"`
 
+	// Двухфакторная аутентификация (TOTP). Секрет появляется при настройке,
+	// но вход по второму фактору включается только после подтверждения кодом.
+	TOTPSecret   string
+	TOTPEnabled  bool  `gorm:"not null;default:false"`
+	TOTPLastStep int64 `gorm:"not null;default:0"` // защита от повторного использования кода
+
+	// DeletionScheduledAt — момент окончательного удаления аккаунта.
+	// До него удаление можно отменить.
+	DeletionScheduledAt *time.Time
+
 	CreatedAt time.Time
 	UpdatedAt time.Time
 }
 
+// EmailVerification — код подтверждения почты. У пользователя может быть
+// несколько неистёкших кодов (после повторной отправки), их число ограничено.
 type EmailVerification struct {
 	ID        uuid.UUID `gorm:"primaryKey"`
 	UserID    uuid.UUID `gorm:"index"`
 	Code      string    `gorm:"size:6;index"`
+	Attempts  int       `gorm:"not null;default:0"`
 	ExpiresAt time.Time
+
+	CreatedAt time.Time
+}
+
+// Session — серверная сессия тренера, хранит хеш refresh-токена.
+// При каждой ротации создаётся новая запись с тем же FamilyID,
+// а старая помечается RotatedAt. Повторное использование уже
+// ротированного токена отзывает всё семейство.
+type Session struct {
+	ID        uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	UserID    uuid.UUID `gorm:"type:uuid;not null;index"`
+	FamilyID  uuid.UUID `gorm:"type:uuid;not null;index"`
+	TokenHash string    `gorm:"size:64;not null;uniqueIndex"`
+
+	UserAgent string
+	IP        string
+
+	ExpiresAt time.Time `gorm:"not null"`
+	RotatedAt *time.Time
+	RevokedAt *time.Time
+
+	CreatedAt time.Time
+}
+
+// PasswordReset — одноразовый код для сброса пароля.
+// Код хранится только в виде хеша, число попыток ввода ограничено.
+type PasswordReset struct {
+	ID        uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	UserID    uuid.UUID `gorm:"type:uuid;not null;index"`
+	CodeHash  string    `gorm:"size:64;not null"`
+	Attempts  int       `gorm:"not null;default:0"`
+	ExpiresAt time.Time `gorm:"not null"`
+	UsedAt    *time.Time
+
+	CreatedAt time.Time
+}
+
+// RecoveryCode — одноразовый резервный код для входа без TOTP-приложения.
+type RecoveryCode struct {
+	ID       uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	UserID   uuid.UUID `gorm:"type:uuid;not null;index"`
+	CodeHash string    `gorm:"size:64;not null"`
+	UsedAt   *time.Time
+
+	CreatedAt time.Time
+}
+
+// EmailChange — незавершённая смена e-mail. Адрес меняется только
+// после ввода кода, отправленного на новый адрес.
+type EmailChange struct {
+	ID        uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	UserID    uuid.UUID `gorm:"type:uuid;not null;index"`
+	NewEmail  string    `gorm:"not null"`
+	CodeHash  string    `gorm:"size:64;not null"`
+	Attempts  int       `gorm:"not null;default:0"`
+	ExpiresAt time.Time `gorm:"not null"`
+
+	CreatedAt time.Time
+}
+
+// Статусы выгрузки данных.
+const (
+	ExportStatusPending = "pending"
+	ExportStatusReady   = "ready"
+	ExportStatusFailed  = "failed"
+)
+
+// DataExport — задача на выгрузку всех данных тренера в ZIP-архив.
+type DataExport struct {
+	ID     uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	UserID uuid.UUID `gorm:"type:uuid;not null;index"`
+
+	Status  string `gorm:"type:varchar(16);not null"`
+	Error   string
+	Archive []byte `gorm:"type:bytea"`
+
+	ExpiresAt   time.Time `gorm:"not null"`
+	CompletedAt *time.Time
+
+	CreatedAt time.Time
 }
@@ -7,6 +7,7 @@ type RegisterRequest struct {
 	Email       string `json:"email"`
 	Password    string `json:"password"`
 	TrainerName string `json:"trainer_name"`
+	TimeZone    string `json:"time_zone"` // IANA, например "Europe/Moscow"; по умолчанию UTC
 }
 
 // RegisterResponse описывает ответ при успешной регистрации.
@@ -24,13 +25,133 @@ type LoginRequest struct {
 
 // LoginResponse описывает ответ при логине.
 type LoginResponse struct {
-	Token       string `json:"token"`
-	ID          string `json:"id"`
-	Email       string `json:"email"`
-	TrainerName string `json:"trainer_name"`
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int    `json:"expires_in"` // время жизни access-токена, секунды
+	ID           string `json:"id"`
+	Email        string `json:"email"`
+	TrainerName  string `json:"trainer_name"`
+}
+
+// RefreshRequest — тело запроса для обновления пары токенов.
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// TokenResponse — новая пара токенов после ротации.
+type TokenResponse struct {
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int    `json:"expires_in"`
 }
 
 type VerifyEmailRequest struct {
 	Email string `json:"email"`
 	Code  string `json:"code"`
 }
+
+// ResendVerificationRequest — запрос повторной отправки кода подтверждения.
+type ResendVerificationRequest struct {
+	Email string `json:"email"`
+}
+
+// ForgotPasswordRequest — запрос кода для сброса пароля.
+type ForgotPasswordRequest struct {
+	Email string `json:"email"`
+}
+
+// ResetPasswordRequest — установка нового пароля по коду из письма.
+type ResetPasswordRequest struct {
+	Email       string `json:"email"`
+	Code        string `json:"code"`
+	NewPassword string `json:"new_password"`
+}
+
+// MFAChallengeResponse — ответ логина, когда требуется второй фактор.
+type MFAChallengeResponse struct {
+	MFARequired bool   `json:"mfa_required"`
+	MFAToken    string `json:"mfa_token"`
+	ExpiresIn   int    `json:"expires_in"`
+}
+
+// MFAVerifyRequest — обмен MFA-токена на полноценную пару токенов.
+// Нужно передать либо code из приложения, либо recovery_code.
+type MFAVerifyRequest struct {
+	MFAToken     string `json:"mfa_token"`
+	Code         string `json:"code"`
+	RecoveryCode string `json:"recovery_code"`
+}
+
+// TOTPSetupResponse — данные для добавления аккаунта в приложение-аутентификатор.
+type TOTPSetupResponse struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauth_uri"`
+}
+
+// TOTPCodeRequest — подтверждение действия кодом из приложения.
+type TOTPCodeRequest struct {
+	Code string `json:"code"`
+}
+
+// TOTPDisableRequest — отключение 2FA: нужен пароль и код (или резервный код).
+type TOTPDisableRequest struct {
+	Password     string `json:"password"`
+	Code         string `json:"code"`
+	RecoveryCode string `json:"recovery_code"`
+}
+
+// RecoveryCodesResponse — резервные коды; показываются один раз.
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// ProfileResponse — профиль текущего тренера.
+type ProfileResponse struct {
+	ID            string `json:"id"`
+	Email         string `json:"email"`
+	TrainerName   string `json:"trainer_name"`
+	EmailVerified bool   `json:"email_verified"`
+	TOTPEnabled   bool   `json:"totp_enabled"`
+	TimeZone      string `json:"time_zone"`
+	CreatedAt     string `json:"created_at"`
+
+	DeletionScheduledAt *string `json:"deletion_scheduled_at"`
+}
+
+// UpdateProfileRequest — частичное обновление профиля (PATCH).
+type UpdateProfileRequest struct {
+	TrainerName *string `json:"trainer_name"`
+	TimeZone    *string `json:"time_zone"`
+}
+
+// ChangePasswordRequest — смена пароля с подтверждением текущего.
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password"`
+}
+
+// ChangeEmailRequest — запрос на смену e-mail.
+type ChangeEmailRequest struct {
+	NewEmail string `json:"new_email"`
+	Password string `json:"password"`
+}
+
+// ConfirmEmailChangeRequest — подтверждение смены e-mail кодом с нового адреса.
+type ConfirmEmailChangeRequest struct {
+	Code string `json:"code"`
+}
+
+// DataExportResponse — состояние задачи выгрузки данных.
+type DataExportResponse struct {
+	ID          string  `json:"id"`
+	Status      string  `json:"status"`
+	Error       string  `json:"error,omitempty"`
+	CreatedAt   string  `json:"created_at"`
+	CompletedAt *string `json:"completed_at"`
+	ExpiresAt   string  `json:"expires_at"`
+}
+
+// DeleteAccountRequest — запрос на удаление аккаунта, подтверждается паролем.
+type DeleteAccountRequest struct {
+	Password string `json:"password"`
+}
@@ -4,32 +4,47 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+
+	"traindesk/internal/recurrence"
+	"traindesk/internal/tz"
 )
 
-// WorkoutType — доменный тип тренировки.
+// WorkoutType — название вида тренировки (см. Type).
 type WorkoutType string
 
+// Общие виды тренировок, с которых начинается справочник.
 const (
 	WorkoutTypeCardio     WorkoutType = "cardio"
 	WorkoutTypeStrength   WorkoutType = "strength"
 	WorkoutTypeStretch    WorkoutType = "stretch"
 	WorkoutTypeFunctional WorkoutType = "functional"
-	// TODO: в будущем вынести виды тренировок в отдельную сущность / таблицу.
 )
 
-// ValidWorkoutTypes — список допустимых типов тренировок.
-var ValidWorkoutTypes = []WorkoutType{
-	WorkoutTypeCardio,
-	WorkoutTypeStrength,
-	WorkoutTypeStretch,
-	WorkoutTypeFunctional,
+// AttendanceStatus — статус участия клиента в тренировке.
+type AttendanceStatus string
+
+const (
+	AttendanceBooked        AttendanceStatus = "booked"         // записан, отметки ещё нет
+	AttendanceAttended      AttendanceStatus = "attended"       // пришёл
+	AttendanceNoShow        AttendanceStatus = "no_show"        // не пришёл без предупреждения
+	AttendanceCancelledLate AttendanceStatus = "cancelled_late" // отменил слишком поздно
+	AttendanceCancelled     AttendanceStatus = "cancelled"      // отменил заранее
+)
+
+// ValidAttendanceStatuses — список допустимых статусов посещения.
+var ValidAttendanceStatuses = []AttendanceStatus{
+	AttendanceBooked,
+	AttendanceAttended,
+	AttendanceNoShow,
+	AttendanceCancelledLate,
+	AttendanceCancelled,
 }
 
-// IsValidType проверяет, что строка — один из известных типов.
-func IsValidType(t string) bool {
-	wt := WorkoutType(t)
-	for _, v := range ValidWorkoutTypes {
-		if wt == v {
+// IsValidAttendance проверяет, что строка — один из известных статусов.
+func IsValidAttendance(s string) bool {
+	as := AttendanceStatus(s)
+	for _, v := range ValidAttendanceStatuses {
+		if as == v {
 			return true
 		}
 	}
@@ -38,20 +53,150 @@ func IsValidType(t string) bool {
 
 // Workout — сущность тренировки в БД.
 type Workout struct {
-	ID     uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
-	UserID uuid.UUID `gorm:"type:uuid;not null;index"`
+	ID             uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	OrganizationID uuid.UUID `gorm:"type:uuid;index"`
+	UserID         uuid.UUID `gorm:"type:uuid;not null;index"` // тренер, ведущий тренировку
+
+	// StartsAt и EndsAt — моменты начала и конца. У тренировки на весь день
+	// (AllDay) это полночь даты в UTC и следующая полночь: дата не зависит от пояса.
+	StartsAt time.Time `gorm:"not null;index"`
+	EndsAt   time.Time `gorm:"not null"`
+	AllDay   bool      `gorm:"not null;default:false"`
+	TimeZone string    `gorm:"type:varchar(64);not null;default:'UTC'"` // пояс, в котором назначена
 
-	Date        time.Time   `gorm:"not null"`
 	DurationMin int         `gorm:"not null"`
-	Type        WorkoutType `gorm:"type:varchar(32);not null"`
+	TypeID      uuid.UUID   `gorm:"type:uuid;not null;index"`  // вид из справочника
+	Type        WorkoutType `gorm:"type:varchar(32);not null"` // его название
 	Notes       string      `gorm:"type:text"`
+	Room        string      `gorm:"type:varchar(100);not null;default:''"` // зал; пусто — не указан
+
+	// Повторение серии: SeriesID и исходное время по правилу (OccurrenceAt).
+	// Detached — повторение изменено отдельно и не меняется вместе с серией.
+	SeriesID     *uuid.UUID `gorm:"type:uuid;uniqueIndex:idx_workouts_series_occurrence"`
+	OccurrenceAt *time.Time `gorm:"uniqueIndex:idx_workouts_series_occurrence"`
+	Detached     bool       `gorm:"not null;default:false"`
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// LocalDate — дата тренировки в её поясе (для тренировки на весь день — сама дата).
+func (w Workout) LocalDate() string {
+	if w.AllDay {
+		return w.StartsAt.UTC().Format("2006-01-02")
+	}
+	return w.StartsAt.In(tz.LoadOrUTC(w.TimeZone)).Format("2006-01-02")
+}
+
+// EditScope — какие повторения серии затрагивает изменение или удаление.
+type EditScope string
+
+const (
+	ScopeThis      EditScope = "this"      // только это повторение
+	ScopeFollowing EditScope = "following" // это и все следующие
+	ScopeAll       EditScope = "all"       // вся серия
+)
+
+// WorkoutSeries — серия повторяющихся тренировок. Повторения хранятся
+// обычными тренировками на горизонт вперёд (MaterializedUntil), а серия —
+// шаблон, по которому создаются следующие.
+type WorkoutSeries struct {
+	ID             uuid.UUID `gorm:"type:uuid;primaryKey"`
+	OrganizationID uuid.UUID `gorm:"type:uuid;not null;index"`
+	UserID         uuid.UUID `gorm:"type:uuid;not null;index"` // тренер
+
+	RRule    string      `gorm:"type:varchar(255);not null"`
+	StartsAt time.Time   `gorm:"not null"`                   // первое повторение (DTSTART)
+	ExDates  []time.Time `gorm:"type:jsonb;serializer:json"` // исключённые повторения (EXDATE)
+	AllDay   bool        `gorm:"not null;default:false"`
+	TimeZone string      `gorm:"type:varchar(64);not null;default:'UTC'"`
+
+	DurationMin int                  `gorm:"not null"`
+	TypeID      uuid.UUID            `gorm:"type:uuid;not null;index"`
+	Type        WorkoutType          `gorm:"type:varchar(32);not null"`
+	Notes       string               `gorm:"type:text"`
+	Room        string               `gorm:"type:varchar(100);not null;default:''"`
+	ClientIDs   []uuid.UUID          `gorm:"type:jsonb;serializer:json"`
+	Exercises   []ExerciseBlockInput `gorm:"type:jsonb;serializer:json"`
+
+	MaterializedUntil time.Time `gorm:"not null;index"`
 
 	CreatedAt time.Time
 	UpdatedAt time.Time
 }
 
+// Location — пояс, в котором повторения сохраняют время суток.
+// Серии на весь день живут в UTC, как и их даты.
+func (s WorkoutSeries) Location() *time.Location {
+	if s.AllDay {
+		return time.UTC
+	}
+	return tz.LoadOrUTC(s.TimeZone)
+}
+
+// Set — набор повторений серии.
+func (s WorkoutSeries) Set() (recurrence.Set, error) {
+	rule, err := recurrence.Parse(s.RRule)
+	if err != nil {
+		return recurrence.Set{}, err
+	}
+	return recurrence.Set{Rule: rule, Start: s.StartsAt, Location: s.Location(), ExDates: s.ExDates}, nil
+}
+
 // WorkoutClient — связь многие-ко-многим между тренировками и клиентами.
+// Заодно хранит личный итог клиента: посещение, оценку нагрузки, комментарий тренера.
 type WorkoutClient struct {
 	WorkoutID uuid.UUID `gorm:"type:uuid;primaryKey"`
 	ClientID  uuid.UUID `gorm:"type:uuid;primaryKey"`
+
+	Attendance         AttendanceStatus `gorm:"type:varchar(16);not null;default:'booked';index"`
+	AttendanceReason   string           `gorm:"type:text"` // причина отмены или неявки
+	BookedAt           *time.Time
+	AttendanceMarkedAt *time.Time
+	AttendanceMarkedBy *uuid.UUID `gorm:"type:uuid"`
+
+	RPE     *float64
+	Comment string `gorm:"type:text"`
+}
+
+// ClientSetResult — что клиент фактически сделал в подходе.
+// Подход адресуется позициями упражнения и подхода, а не ID: при
+// редактировании тренировки подходы пересоздаются, а результаты должны остаться.
+// ExerciseID запоминает упражнение: если на его позиции теперь другое,
+// результат удаляется.
+type ClientSetResult struct {
+	WorkoutID     uuid.UUID `gorm:"type:uuid;primaryKey"`
+	ClientID      uuid.UUID `gorm:"type:uuid;primaryKey;index"`
+	BlockPosition int       `gorm:"primaryKey"`
+	SetPosition   int       `gorm:"primaryKey"`
+	ExerciseID    uuid.UUID `gorm:"type:uuid;not null"`
+
+	Reps        *int
+	WeightKg    *float64
+	DurationSec *int
+	DistanceM   *float64
+}
+
+// WorkoutBlock — упражнение в составе тренировки; Position задаёт порядок.
+type WorkoutBlock struct {
+	ID         uuid.UUID `gorm:"type:uuid;primaryKey"`
+	WorkoutID  uuid.UUID `gorm:"type:uuid;not null;index"`
+	Position   int       `gorm:"not null"`
+	ExerciseID uuid.UUID `gorm:"type:uuid;not null;index"`
+	Notes      string    `gorm:"type:text"`
+}
+
+// WorkoutSet — подход внутри блока. Заполняются только те показатели,
+// которые имеют смысл для упражнения: повторы и вес, время, дистанция.
+type WorkoutSet struct {
+	ID       uuid.UUID `gorm:"type:uuid;primaryKey"`
+	BlockID  uuid.UUID `gorm:"type:uuid;not null;index"`
+	Position int       `gorm:"not null"`
+
+	Reps        *int
+	WeightKg    *float64
+	DurationSec *int
+	DistanceM   *float64
+	RestSec     *int
+	RPE         *float64
 }
@@ -1,20 +1,267 @@
 package workout
 
+import "time"
+
 // CreateWorkoutRequest — тело запроса при создании тренировки.
+// Время задаётся либо starts_at (+ ends_at или duration_min), либо датой
+// для тренировки на весь день. Время без смещения считается локальным
+// в time_zone, а если он не задан — в поясе тренера.
 type CreateWorkoutRequest struct {
-	Date        string   `json:"date"`         // YYYY-MM-DD
+	StartsAt    string   `json:"starts_at"`    // RFC 3339 или YYYY-MM-DDTHH:MM
+	EndsAt      string   `json:"ends_at"`      // необязательно, если есть duration_min
+	AllDay      bool     `json:"all_day"`      // на весь день: нужна только date
+	Date        string   `json:"date"`         // YYYY-MM-DD, для all_day; без starts_at означает all_day
+	TimeZone    string   `json:"time_zone"`    // IANA; по умолчанию — пояс тренера
 	DurationMin int      `json:"duration_min"` // 1–300
-	Type        string   `json:"type"`         // "cardio", "strength", "stretch", "functional"
+	TypeID      string   `json:"type_id"`      // вид из справочника
+	Type        string   `json:"type"`         // или его название, если type_id не задан
 	ClientIDs   []string `json:"client_ids"`   // 0, 1 или несколько клиентов
 	Notes       string   `json:"notes"`
+	Room        string   `json:"room"`       // зал; тренировки в одном зале не должны пересекаться
+	TrainerID   string   `json:"trainer_id"` // необязательно; по умолчанию — текущий тренер
+
+	Exercises []ExerciseBlockInput `json:"exercises"` // по порядку выполнения
+
+	// Recurrence делает тренировку первым повторением серии. При изменении
+	// серии (scope=following|all) задаёт новое правило.
+	Recurrence *RecurrenceInput `json:"recurrence"`
+}
+
+// RecurrenceInput — правило повторения серии.
+type RecurrenceInput struct {
+	RRule   string   `json:"rrule"`   // RFC 5545, например "FREQ=WEEKLY;BYDAY=MO,TH"
+	ExDates []string `json:"exdates"` // исключённые повторения: время начала, как starts_at (или дата для all_day)
+}
+
+// SeriesResponse — серия повторяющихся тренировок.
+type SeriesResponse struct {
+	ID                string   `json:"id"`
+	RRule             string   `json:"rrule"`
+	StartsAt          string   `json:"starts_at"`
+	ExDates           []string `json:"exdates"`
+	AllDay            bool     `json:"all_day"`
+	TimeZone          string   `json:"time_zone"`
+	DurationMin       int      `json:"duration_min"`
+	TypeID            string   `json:"type_id"`
+	Type              string   `json:"type"`
+	Notes             string   `json:"notes"`
+	Room              string   `json:"room"`
+	TrainerID         string   `json:"trainer_id"`
+	ClientIDs         []string `json:"client_ids"`
+	MaterializedUntil string   `json:"materialized_until"`
 }
 
 // WorkoutResponse — то, что отдаём клиенту.
 type WorkoutResponse struct {
 	ID          string   `json:"id"`
-	Date        string   `json:"date"`
+	Date        string   `json:"date"`      // дата начала в поясе тренировки
+	StartsAt    string   `json:"starts_at"` // RFC 3339 со смещением пояса тренировки
+	EndsAt      string   `json:"ends_at"`
+	AllDay      bool     `json:"all_day"`
+	TimeZone    string   `json:"time_zone"`
 	DurationMin int      `json:"duration_min"`
+	TypeID      string   `json:"type_id"`
 	Type        string   `json:"type"`
 	ClientIDs   []string `json:"client_ids"`
 	Notes       string   `json:"notes"`
+	Room        string   `json:"room"`
+	TrainerID   string   `json:"trainer_id"`
+
+	SeriesID *string `json:"series_id,omitempty"`
+	Detached bool    `json:"detached,omitempty"` // повторение изменено отдельно от серии
+
+	// Clients заполняется только при embed=clients.
+	Clients []ClientRef `json:"clients,omitempty"`
+	// Exercises заполняется в карточке тренировки и при embed=exercises.
+	Exercises []ExerciseBlockResponse `json:"exercises,omitempty"`
+}
+
+// ConflictKind — чем тренировка пересекается с уже назначенной.
+type ConflictKind string
+
+const (
+	ConflictTrainer ConflictKind = "trainer" // тренер в это время уже ведёт тренировку
+	ConflictClient  ConflictKind = "client"  // клиент в это время уже записан
+	ConflictRoom    ConflictKind = "room"    // зал в это время уже занят
+)
+
+// ConflictResponse — пересечение по времени с уже назначенной тренировкой.
+type ConflictResponse struct {
+	Kind      ConflictKind `json:"kind"`
+	At        string       `json:"at"`         // начало новой тренировки (повторения серии)
+	WorkoutID string       `json:"workout_id"` // тренировка, с которой пересеклась
+	StartsAt  string       `json:"starts_at"`
+	EndsAt    string       `json:"ends_at"`
+	ClientID  string       `json:"client_id,omitempty"` // для kind=client
+	Room      string       `json:"room,omitempty"`      // для kind=room
+}
+
+// ClientRef — краткие сведения о клиенте тренировки.
+type ClientRef struct {
+	ID        string `json:"id"`
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+	Archived  bool   `json:"archived"`
+}
+
+// SetData — показатели одного подхода; все поля необязательные,
+// но хотя бы один из reps, weight_kg, duration_sec, distance_m должен быть задан.
+type SetData struct {
+	Reps        *int     `json:"reps,omitempty"`
+	WeightKg    *float64 `json:"weight_kg,omitempty"`
+	DurationSec *int     `json:"duration_sec,omitempty"`
+	DistanceM   *float64 `json:"distance_m,omitempty"`
+	RestSec     *int     `json:"rest_sec,omitempty"`
+	RPE         *float64 `json:"rpe,omitempty"` // 1–10
+}
+
+// ExerciseBlockInput — упражнение с подходами в запросе на создание тренировки.
+type ExerciseBlockInput struct {
+	ExerciseID string    `json:"exercise_id"`
+	Notes      string    `json:"notes"`
+	Sets       []SetData `json:"sets"`
+}
+
+// ExerciseBlockResponse — упражнение с подходами в ответе.
+type ExerciseBlockResponse struct {
+	ExerciseID   string    `json:"exercise_id"`
+	ExerciseName string    `json:"exercise_name"`
+	Notes        string    `json:"notes"`
+	Sets         []SetData `json:"sets"`
+}
+
+// SetResult — фактический результат клиента в подходе.
+// exercise_index и set_index — позиции упражнения и подхода в тренировке, с нуля.
+type SetResult struct {
+	ExerciseIndex int      `json:"exercise_index"`
+	SetIndex      int      `json:"set_index"`
+	Reps          *int     `json:"reps,omitempty"`
+	WeightKg      *float64 `json:"weight_kg,omitempty"`
+	DurationSec   *int     `json:"duration_sec,omitempty"`
+	DistanceM     *float64 `json:"distance_m,omitempty"`
+}
+
+// ParticipantRequest — личный итог клиента в тренировке.
+// Пустой attendance оставляет статус посещения без изменений.
+type ParticipantRequest struct {
+	Attendance       string      `json:"attendance"`
+	AttendanceReason string      `json:"attendance_reason"`
+	RPE              *float64    `json:"rpe"` // 1–10
+	Comment          string      `json:"comment"`
+	Results          []SetResult `json:"results"`
+}
+
+// ParticipantResponse — участник тренировки с его итогом.
+type ParticipantResponse struct {
+	ClientID           string      `json:"client_id"`
+	FirstName          string      `json:"first_name"`
+	LastName           string      `json:"last_name"`
+	Attendance         string      `json:"attendance"`
+	AttendanceReason   string      `json:"attendance_reason,omitempty"`
+	BookedAt           *time.Time  `json:"booked_at,omitempty"`
+	AttendanceMarkedAt *time.Time  `json:"attendance_marked_at,omitempty"`
+	RPE                *float64    `json:"rpe"`
+	Comment            string      `json:"comment"`
+	Results            []SetResult `json:"results"`
+}
+
+// AttendanceMark — отметка посещения одного участника.
+type AttendanceMark struct {
+	ClientID string `json:"client_id"`
+	Status   string `json:"status"`
+	Reason   string `json:"reason"`
+}
+
+// MarkAttendanceRequest — отметка посещения после тренировки.
+// Default, если задан, применяется ко всем участникам, не перечисленным в Marks.
+type MarkAttendanceRequest struct {
+	Default string           `json:"default"`
+	Marks   []AttendanceMark `json:"marks"`
+}
+
+// AttendanceStats — посещаемость клиента за период.
+// Rate = attended / (attended + no_show + cancelled_late); заблаговременные
+// отмены и неотмеченные записи в знаменатель не входят.
+type AttendanceStats struct {
+	ClientID      string   `json:"client_id"`
+	FirstName     string   `json:"first_name,omitempty"`
+	LastName      string   `json:"last_name,omitempty"`
+	Total         int      `json:"total"`
+	Booked        int      `json:"booked"`
+	Attended      int      `json:"attended"`
+	NoShow        int      `json:"no_show"`
+	CancelledLate int      `json:"cancelled_late"`
+	Cancelled     int      `json:"cancelled"`
+	Rate          *float64 `json:"attendance_rate"`
+}
+
+// ClientAttendance — посещаемость одного клиента за период.
+type ClientAttendance struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	AttendanceStats
+}
+
+// AttendanceReport — посещаемость клиентов за период.
+type AttendanceReport struct {
+	From    string            `json:"from"`
+	To      string            `json:"to"`
+	Clients []AttendanceStats `json:"clients"`
+}
+
+// TrainingLogEntry — запись дневника тренировок клиента.
+type TrainingLogEntry struct {
+	Workout     WorkoutResponse     `json:"workout"`
+	Participant ParticipantResponse `json:"participant"`
+}
+
+// TypeRequest — тело запроса для создания или изменения вида тренировки.
+type TypeRequest struct {
+	Name               string `json:"name"`
+	Color              string `json:"color"`                // #RRGGBB
+	DefaultDurationMin *int   `json:"default_duration_min"` // 1–300
+	DefaultPriceCents  *int64 `json:"default_price_cents"`  // в копейках
+}
+
+// TypeResponse — вид тренировки из справочника.
+type TypeResponse struct {
+	ID                 string `json:"id"`
+	Name               string `json:"name"`
+	Color              string `json:"color"`
+	DefaultDurationMin *int   `json:"default_duration_min"`
+	DefaultPriceCents  *int64 `json:"default_price_cents"`
+	Global             bool   `json:"global"`
+}
+
+// TemplateRequest — тело запроса для создания или изменения шаблона тренировки.
+type TemplateRequest struct {
+	Name        string               `json:"name"`
+	TypeID      string               `json:"type_id"`
+	Type        string               `json:"type"`         // название вида, если type_id не задан
+	DurationMin int                  `json:"duration_min"` // 0 — по виду тренировки
+	Notes       string               `json:"notes"`
+	Room        string               `json:"room"`
+	ClientIDs   []string             `json:"client_ids"` // постоянные участники
+	Exercises   []ExerciseBlockInput `json:"exercises"`
+}
+
+// SaveTemplateRequest — тело запроса «сохранить тренировку как шаблон».
+type SaveTemplateRequest struct {
+	Name string `json:"name"`
+}
+
+// TemplateResponse — шаблон тренировки.
+type TemplateResponse struct {
+	ID          string                  `json:"id"`
+	Name        string                  `json:"name"`
+	TypeID      string                  `json:"type_id"`
+	Type        string                  `json:"type"`
+	DurationMin int                     `json:"duration_min"`
+	Notes       string                  `json:"notes"`
+	Room        string                  `json:"room"`
+	ClientIDs   []string                `json:"client_ids"`
+	Exercises   []ExerciseBlockResponse `json:"exercises"`
+	TrainerID   string                  `json:"trainer_id"`
+	CreatedAt   string                  `json:"created_at"`
+	UpdatedAt   string                  `json:"updated_at"`
 }
@@ -0,0 +1,146 @@
+package workout
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"traindesk/internal/recurrence"
+	"traindesk/internal/tz"
+)
+
+const (
+	minDurationMin = 1
+	maxDurationMin = 300
+)
+
+// Schedule — проверенное время тренировки.
+type Schedule struct {
+	StartsAt    time.Time
+	EndsAt      time.Time
+	AllDay      bool
+	TimeZone    string
+	DurationMin int
+}
+
+// Apply переносит время в тренировку.
+func (s Schedule) Apply(w *Workout) {
+	w.StartsAt = s.StartsAt
+	w.EndsAt = s.EndsAt
+	w.AllDay = s.AllDay
+	w.TimeZone = s.TimeZone
+	w.DurationMin = s.DurationMin
+}
+
+// ResolveSchedule проверяет время из запроса. defaultZone — пояс тренера,
+// он используется, если в запросе нет time_zone.
+func ResolveSchedule(r CreateWorkoutRequest, defaultZone string) (Schedule, error) {
+	zone := strings.TrimSpace(r.TimeZone)
+	if zone == "" {
+		zone = defaultZone
+	}
+	loc, err := tz.Load(zone)
+	if err != nil {
+		return Schedule{}, fmt.Errorf("time_zone: %w", err)
+	}
+	s := Schedule{TimeZone: zone}
+
+	if r.AllDay || (r.StartsAt == "" && r.Date != "") {
+		if r.Date == "" || r.EndsAt != "" {
+			return Schedule{}, errors.New("all-day workout needs date (YYYY-MM-DD) and no ends_at")
+		}
+		d, err := time.Parse("2006-01-02", r.Date)
+		if err != nil {
+			return Schedule{}, errors.New("invalid date format, expected YYYY-MM-DD")
+		}
+		if r.DurationMin != 0 && (r.DurationMin < minDurationMin || r.DurationMin > maxDurationMin) {
+			return Schedule{}, fmt.Errorf("duration_min must be between %d and %d", minDurationMin, maxDurationMin)
+		}
+		s.StartsAt = d
+		s.EndsAt = d.AddDate(0, 0, 1)
+		s.AllDay = true
+		s.DurationMin = r.DurationMin
+		return s, nil
+	}
+
+	if r.StartsAt == "" {
+		return Schedule{}, errors.New("starts_at or date is required")
+	}
+	if s.StartsAt, err = tz.ParseTime(r.StartsAt, loc); err != nil {
+		return Schedule{}, fmt.Errorf("starts_at: %w", err)
+	}
+
+	switch {
+	case r.EndsAt != "":
+		if s.EndsAt, err = tz.ParseTime(r.EndsAt, loc); err != nil {
+			return Schedule{}, fmt.Errorf("ends_at: %w", err)
+		}
+		s.DurationMin = int(s.EndsAt.Sub(s.StartsAt) / time.Minute)
+		if r.DurationMin != 0 && r.DurationMin != s.DurationMin {
+			return Schedule{}, errors.New("duration_min does not match starts_at and ends_at")
+		}
+	case r.DurationMin != 0:
+		s.DurationMin = r.DurationMin
+		s.EndsAt = s.StartsAt.Add(time.Duration(r.DurationMin) * time.Minute)
+	default:
+		return Schedule{}, errors.New("ends_at or duration_min is required")
+	}
+
+	if !s.EndsAt.After(s.StartsAt) {
+		return Schedule{}, errors.New("ends_at must be after starts_at")
+	}
+	if s.DurationMin < minDurationMin || s.DurationMin > maxDurationMin {
+		return Schedule{}, fmt.Errorf("duration must be between %d and %d minutes", minDurationMin, maxDurationMin)
+	}
+
+	s.StartsAt = s.StartsAt.UTC()
+	s.EndsAt = s.EndsAt.UTC()
+	return s, nil
+}
+
+// maxExDates — предел исключений в одной серии.
+const maxExDates = 500
+
+// ResolveRecurrence проверяет правило повторения серии, первым повторением
+// которой станет тренировка со временем s. Исключения разбираются так же,
+// как starts_at (для серии на весь день — как date).
+func ResolveRecurrence(r RecurrenceInput, s Schedule) (recurrence.Rule, []time.Time, error) {
+	rule, err := recurrence.Parse(r.RRule)
+	if err != nil {
+		return recurrence.Rule{}, nil, fmt.Errorf("recurrence.rrule: %w", err)
+	}
+	if len(r.ExDates) > maxExDates {
+		return recurrence.Rule{}, nil, fmt.Errorf("recurrence.exdates: at most %d exceptions", maxExDates)
+	}
+
+	loc := tz.LoadOrUTC(s.TimeZone)
+	exDates := make([]time.Time, 0, len(r.ExDates))
+	for _, v := range r.ExDates {
+		var t time.Time
+		if s.AllDay {
+			t, err = time.Parse("2006-01-02", v)
+		} else {
+			t, err = tz.ParseTime(v, loc)
+		}
+		if err != nil {
+			return recurrence.Rule{}, nil, fmt.Errorf("recurrence.exdates: invalid value %q", v)
+		}
+		exDates = append(exDates, t.UTC())
+	}
+
+	if !IsSeriesStart(rule, s.StartsAt, s.AllDay, loc) {
+		return recurrence.Rule{}, nil, errors.New("starts_at must match the recurrence rule")
+	}
+	return rule, exDates, nil
+}
+
+// IsSeriesStart проверяет, что start — повторение правила, начинающегося в start.
+func IsSeriesStart(rule recurrence.Rule, start time.Time, allDay bool, loc *time.Location) bool {
+	if allDay {
+		loc = time.UTC
+	}
+	set := recurrence.Set{Rule: rule, Start: start, Location: loc}
+	occ := set.Between(start, start.Add(time.Second))
+	return len(occ) == 1 && occ[0].Equal(start)
+}
@@ -0,0 +1,32 @@
+package workout
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Template — сохранённая структура тренировки: вид, длительность, заметки,
+// упражнения и постоянные участники. Из шаблона тренировка создаётся
+// в один клик — остаётся указать время.
+type Template struct {
+	ID             uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	OrganizationID uuid.UUID `gorm:"type:uuid;not null;index"`
+	UserID         uuid.UUID `gorm:"type:uuid;not null;index"` // тренер, чей это шаблон
+
+	Name        string               `gorm:"type:varchar(100);not null"`
+	TypeID      uuid.UUID            `gorm:"type:uuid;not null;index"`
+	Type        WorkoutType          `gorm:"type:varchar(32);not null"`
+	DurationMin int                  `gorm:"not null;default:0"` // 0 — по виду тренировки
+	Notes       string               `gorm:"type:text"`
+	Room        string               `gorm:"type:varchar(100);not null;default:''"`
+	ClientIDs   []uuid.UUID          `gorm:"type:jsonb;serializer:json"`
+	Exercises   []ExerciseBlockInput `gorm:"type:jsonb;serializer:json"`
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (Template) TableName() string {
+	return "workout_templates"
+}
@@ -0,0 +1,123 @@
+package workout
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Type — вид тренировки из справочника: общий (OrganizationID == nil)
+// или заведённый тренером организации. Тренировки ссылаются на него по
+// TypeID, а в колонке type хранят его название (WorkoutType) — для
+// фильтров и выгрузок; при переименовании вида оно обновляется.
+type Type struct {
+	ID             uuid.UUID  `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	OrganizationID *uuid.UUID `gorm:"type:uuid;index"`
+	CreatedBy      *uuid.UUID `gorm:"type:uuid"`
+
+	Name  string `gorm:"type:varchar(32);not null"`
+	Color string `gorm:"type:varchar(7);not null;default:''"` // #RRGGBB; пусто — цвет по умолчанию
+
+	// Подставляются в новую тренировку, если в запросе их нет.
+	DefaultDurationMin *int
+	DefaultPriceCents  *int64 // в копейках
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (Type) TableName() string {
+	return "workout_types"
+}
+
+// IsGlobal — вид из общего справочника.
+func (t Type) IsGlobal() bool {
+	return t.OrganizationID == nil
+}
+
+// Catalog — виды тренировок, доступные организации.
+type Catalog []Type
+
+// Find ищет вид по названию без учёта регистра.
+func (c Catalog) Find(name string) (Type, bool) {
+	name = strings.TrimSpace(name)
+	for _, t := range c {
+		if strings.EqualFold(t.Name, name) {
+			return t, true
+		}
+	}
+	return Type{}, false
+}
+
+// Get ищет вид по ID.
+func (c Catalog) Get(id uuid.UUID) (Type, bool) {
+	for _, t := range c {
+		if t.ID == id {
+			return t, true
+		}
+	}
+	return Type{}, false
+}
+
+// Names — названия видов, например для подсказки в ошибке.
+func (c Catalog) Names() []string {
+	names := make([]string, 0, len(c))
+	for _, t := range c {
+		names = append(names, t.Name)
+	}
+	return names
+}
+
+// LoadCatalog — общие виды и виды организации orgID, по названию.
+func LoadCatalog(db *gorm.DB, orgID uuid.UUID) (Catalog, error) {
+	var types []Type
+	err := db.Where("organization_id IS NULL OR organization_id = ?", orgID).
+		Order("lower(name), id").
+		Find(&types).Error
+	return Catalog(types), err
+}
+
+// globalTypes — базовый справочник: бывшие фиксированные типы тренировок.
+var globalTypes = []struct {
+	name     WorkoutType
+	color    string
+	duration int
+}{
+	{WorkoutTypeCardio, "#E53935", 45},
+	{WorkoutTypeStrength, "#1E88E5", 60},
+	{WorkoutTypeStretch, "#43A047", 45},
+	{WorkoutTypeFunctional, "#FB8C00", 60},
+}
+
+// SeedGlobalTypes добавляет недостающие виды базового справочника.
+// Безопасно вызывать при каждом старте.
+func SeedGlobalTypes(db *gorm.DB) error {
+	var existing []string
+	if err := db.Model(&Type{}).Where("organization_id IS NULL").Pluck("name", &existing).Error; err != nil {
+		return err
+	}
+	have := make(map[string]bool, len(existing))
+	for _, n := range existing {
+		have[strings.ToLower(n)] = true
+	}
+
+	var missing []Type
+	for _, g := range globalTypes {
+		if have[string(g.name)] {
+			continue
+		}
+		duration := g.duration
+		missing = append(missing, Type{
+			ID:                 uuid.New(),
+			Name:               string(g.name),
+			Color:              g.color,
+			DefaultDurationMin: &duration,
+		})
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	return db.Create(&missing).Error
+}
@@ -0,0 +1,171 @@
+package workout
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	maxBlocks       = 50
+	maxSetsPerBlock = 50
+	maxReasonLen    = 1000
+	maxTypeNameLen  = 32
+	maxTemplateName = 100
+	maxPriceCents   = 100_000_000
+)
+
+var colorRe = regexp.MustCompile(`^#[0-9A-Fa-f]{6}$`)
+
+// Validate проверяет, что показатели подхода в разумных пределах.
+func (s SetData) Validate() error {
+	if s.Reps == nil && s.WeightKg == nil && s.DurationSec == nil && s.DistanceM == nil {
+		return fmt.Errorf("set must have reps, weight_kg, duration_sec or distance_m")
+	}
+	if s.Reps != nil && (*s.Reps < 0 || *s.Reps > 1000) {
+		return fmt.Errorf("reps must be between 0 and 1000")
+	}
+	if s.WeightKg != nil && (*s.WeightKg < 0 || *s.WeightKg > 1000) {
+		return fmt.Errorf("weight_kg must be between 0 and 1000")
+	}
+	if s.DurationSec != nil && (*s.DurationSec < 0 || *s.DurationSec > 24*3600) {
+		return fmt.Errorf("duration_sec must be between 0 and 86400")
+	}
+	if s.DistanceM != nil && (*s.DistanceM < 0 || *s.DistanceM > 1000000) {
+		return fmt.Errorf("distance_m must be between 0 and 1000000")
+	}
+	if s.RestSec != nil && (*s.RestSec < 0 || *s.RestSec > 3600) {
+		return fmt.Errorf("rest_sec must be between 0 and 3600")
+	}
+	if s.RPE != nil && (*s.RPE < 1 || *s.RPE > 10) {
+		return fmt.Errorf("rpe must be between 1 and 10")
+	}
+	return nil
+}
+
+// Normalize обрезает пробелы и приводит цвет к верхнему регистру.
+func (r *TypeRequest) Normalize() {
+	r.Name = strings.TrimSpace(r.Name)
+	r.Color = strings.ToUpper(strings.TrimSpace(r.Color))
+}
+
+// Validate проверяет вид тренировки из запроса (после Normalize).
+func (r TypeRequest) Validate() error {
+	if r.Name == "" || utf8.RuneCountInString(r.Name) > maxTypeNameLen {
+		return fmt.Errorf("name is required (up to %d characters)", maxTypeNameLen)
+	}
+	if r.Color != "" && !colorRe.MatchString(r.Color) {
+		return fmt.Errorf("color must be #RRGGBB")
+	}
+	if d := r.DefaultDurationMin; d != nil && (*d < minDurationMin || *d > maxDurationMin) {
+		return fmt.Errorf("default_duration_min must be between %d and %d", minDurationMin, maxDurationMin)
+	}
+	if p := r.DefaultPriceCents; p != nil && (*p < 0 || *p > maxPriceCents) {
+		return fmt.Errorf("default_price_cents must be between 0 and %d", maxPriceCents)
+	}
+	return nil
+}
+
+// Validate проверяет шаблон из запроса, кроме вида, клиентов и упражнений
+// справочника — их проверяет вызывающий.
+func (r TemplateRequest) Validate() error {
+	if strings.TrimSpace(r.Name) == "" || utf8.RuneCountInString(r.Name) > maxTemplateName {
+		return fmt.Errorf("name is required (up to %d characters)", maxTemplateName)
+	}
+	if r.DurationMin != 0 && (r.DurationMin < minDurationMin || r.DurationMin > maxDurationMin) {
+		return fmt.Errorf("duration_min must be between %d and %d", minDurationMin, maxDurationMin)
+	}
+	return nil
+}
+
+// ValidateExercises проверяет структуру тренировки (без проверки, что упражнения существуют).
+func ValidateExercises(blocks []ExerciseBlockInput) error {
+	if len(blocks) > maxBlocks {
+		return fmt.Errorf("at most %d exercises per workout", maxBlocks)
+	}
+	for i, b := range blocks {
+		if b.ExerciseID == "" {
+			return fmt.Errorf("exercises[%d]: exercise_id is required", i)
+		}
+		if len(b.Sets) > maxSetsPerBlock {
+			return fmt.Errorf("exercises[%d]: at most %d sets", i, maxSetsPerBlock)
+		}
+		for j, s := range b.Sets {
+			if err := s.Validate(); err != nil {
+				return fmt.Errorf("exercises[%d].sets[%d]: %w", i, j, err)
+			}
+		}
+	}
+	return nil
+}
+
+// ValidateParticipant проверяет итог клиента против структуры тренировки:
+// setCounts[i] — число подходов в i-м упражнении.
+func ValidateParticipant(r ParticipantRequest, setCounts []int) error {
+	if r.Attendance != "" {
+		if err := ValidateAttendance(r.Attendance, r.AttendanceReason); err != nil {
+			return err
+		}
+	}
+	if r.RPE != nil && (*r.RPE < 1 || *r.RPE > 10) {
+		return fmt.Errorf("rpe must be between 1 and 10")
+	}
+	if len(r.Comment) > 4000 {
+		return fmt.Errorf("comment is too long")
+	}
+
+	seen := make(map[[2]int]bool, len(r.Results))
+	for i, res := range r.Results {
+		if res.ExerciseIndex < 0 || res.ExerciseIndex >= len(setCounts) ||
+			res.SetIndex < 0 || res.SetIndex >= setCounts[res.ExerciseIndex] {
+			return fmt.Errorf("results[%d]: no such exercise or set in the workout", i)
+		}
+		key := [2]int{res.ExerciseIndex, res.SetIndex}
+		if seen[key] {
+			return fmt.Errorf("results[%d]: duplicate set", i)
+		}
+		seen[key] = true
+
+		set := SetData{Reps: res.Reps, WeightKg: res.WeightKg, DurationSec: res.DurationSec, DistanceM: res.DistanceM}
+		if err := set.Validate(); err != nil {
+			return fmt.Errorf("results[%d]: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// ValidateAttendance проверяет статус посещения и причину отмены или неявки.
+func ValidateAttendance(status, reason string) error {
+	if !IsValidAttendance(status) {
+		return fmt.Errorf("invalid attendance status %q", status)
+	}
+	if len(reason) > maxReasonLen {
+		return fmt.Errorf("attendance reason is too long")
+	}
+	return nil
+}
+
+// ToSetResult переводит результат из БД в DTO.
+func (r ClientSetResult) ToSetResult() SetResult {
+	return SetResult{
+		ExerciseIndex: r.BlockPosition,
+		SetIndex:      r.SetPosition,
+		Reps:          r.Reps,
+		WeightKg:      r.WeightKg,
+		DurationSec:   r.DurationSec,
+		DistanceM:     r.DistanceM,
+	}
+}
+
+// ToSetData переводит подход из БД в DTO.
+func (s WorkoutSet) ToSetData() SetData {
+	return SetData{
+		Reps:        s.Reps,
+		WeightKg:    s.WeightKg,
+		DurationSec: s.DurationSec,
+		DistanceM:   s.DistanceM,
+		RestSec:     s.RestSec,
+		RPE:         s.RPE,
+	}
+}
@@ -0,0 +1,226 @@
+// Package xlsx — потоковая запись простой книги Excel (Office Open XML) с одним
+// листом: строки пишутся сразу в архив, без накопления в памяти. Строки
+// хранятся как inline-строки, поэтому таблица общих строк не нужна.
+package xlsx
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Kind — тип значения ячейки.
+type Kind int
+
+const (
+	KindEmpty Kind = iota
+	KindString
+	KindNumber
+	KindDate     // только дата
+	KindDateTime // дата и время
+)
+
+// Cell — значение ячейки.
+type Cell struct {
+	Kind   Kind
+	String string
+	Number float64
+	Time   time.Time // для дат берутся показания часов, пояс не учитывается
+}
+
+// Str — текстовая ячейка.
+func Str(s string) Cell { return Cell{Kind: KindString, String: s} }
+
+// Int — числовая ячейка с целым.
+func Int(n int) Cell { return Cell{Kind: KindNumber, Number: float64(n)} }
+
+// Float — числовая ячейка.
+func Float(f float64) Cell { return Cell{Kind: KindNumber, Number: f} }
+
+// Date — ячейка с датой.
+func Date(t time.Time) Cell { return Cell{Kind: KindDate, Time: t} }
+
+// DateTime — ячейка с датой и временем.
+func DateTime(t time.Time) Cell { return Cell{Kind: KindDateTime, Time: t} }
+
+// Empty — пустая ячейка.
+var Empty = Cell{}
+
+// Стили из styles.xml: 0 — обычный, 1 — дата, 2 — дата и время.
+const (
+	styleDate     = 1
+	styleDateTime = 2
+)
+
+// maxSheetName — предел длины имени листа в Excel.
+const maxSheetName = 31
+
+// excelEpoch — нулевой день календаря Excel (с учётом ошибки 1900 года
+// для всех дат после 1 марта 1900).
+var excelEpoch = time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+
+// Writer пишет книгу с одним листом.
+type Writer struct {
+	zw    *zip.Writer
+	sheet *bufio.Writer
+	row   int
+	err   error
+}
+
+// NewWriter начинает книгу с листом sheetName. Close обязателен.
+func NewWriter(w io.Writer, sheetName string) (*Writer, error) {
+	zw := zip.NewWriter(w)
+	static := []struct{ name, body string }{
+		{"[Content_Types].xml", contentTypes},
+		{"_rels/.rels", rootRels},
+		{"xl/workbook.xml", strings.Replace(workbook, "{{SHEET}}", escapeAttr(sanitizeSheetName(sheetName)), 1)},
+		{"xl/_rels/workbook.xml.rels", workbookRels},
+		{"xl/styles.xml", styles},
+	}
+	for _, f := range static {
+		fw, err := zw.Create(f.name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := io.WriteString(fw, f.body); err != nil {
+			return nil, err
+		}
+	}
+
+	fw, err := zw.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, err
+	}
+	x := &Writer{zw: zw, sheet: bufio.NewWriter(fw)}
+	x.write(xml.Header + `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	return x, x.err
+}
+
+// WriteRow добавляет строку.
+func (x *Writer) WriteRow(cells []Cell) error {
+	if x.err != nil {
+		return x.err
+	}
+	x.row++
+	r := strconv.Itoa(x.row)
+	x.write(`<row r="` + r + `">`)
+	for i, c := range cells {
+		ref := ColumnName(i) + r
+		switch c.Kind {
+		case KindString:
+			x.write(`<c r="` + ref + `" t="inlineStr"><is><t xml:space="preserve">`)
+			x.escape(c.String)
+			x.write(`</t></is></c>`)
+		case KindNumber:
+			x.write(`<c r="` + ref + `"><v>` + strconv.FormatFloat(c.Number, 'f', -1, 64) + `</v></c>`)
+		case KindDate:
+			x.write(`<c r="` + ref + `" s="` + strconv.Itoa(styleDate) + `"><v>` + serial(c.Time) + `</v></c>`)
+		case KindDateTime:
+			x.write(`<c r="` + ref + `" s="` + strconv.Itoa(styleDateTime) + `"><v>` + serial(c.Time) + `</v></c>`)
+		}
+	}
+	x.write(`</row>`)
+	return x.err
+}
+
+// Close дописывает лист и закрывает архив.
+func (x *Writer) Close() error {
+	x.write(`</sheetData></worksheet>`)
+	if x.err == nil {
+		x.err = x.sheet.Flush()
+	}
+	if err := x.zw.Close(); x.err == nil {
+		x.err = err
+	}
+	return x.err
+}
+
+func (x *Writer) write(s string) {
+	if x.err == nil {
+		_, x.err = x.sheet.WriteString(s)
+	}
+}
+
+func (x *Writer) escape(s string) {
+	if x.err == nil {
+		x.err = xml.EscapeText(x.sheet, []byte(s))
+	}
+}
+
+// ColumnName — буквенное имя колонки по номеру с нуля: A, B, …, Z, AA, …
+func ColumnName(i int) string {
+	name := ""
+	for i++; i > 0; i = (i - 1) / 26 {
+		name = string(rune('A'+(i-1)%26)) + name
+	}
+	return name
+}
+
+// serial — дата в формате Excel: дни от нулевого дня с дробной частью для времени.
+func serial(t time.Time) string {
+	wall := time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), 0, time.UTC)
+	days := wall.Sub(excelEpoch).Hours() / 24
+	return strconv.FormatFloat(days, 'f', -1, 64)
+}
+
+// sanitizeSheetName убирает символы, недопустимые в имени листа, и обрезает его.
+func sanitizeSheetName(s string) string {
+	s = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`[]:*?/\`, r) {
+			return '_'
+		}
+		return r
+	}, s)
+	if r := []rune(s); len(r) > maxSheetName {
+		s = string(r[:maxSheetName])
+	}
+	if s == "" {
+		s = "Sheet1"
+	}
+	return s
+}
+
+func escapeAttr(s string) string {
+	var b strings.Builder
+	_ = xml.EscapeText(&b, []byte(s))
+	return strings.ReplaceAll(b.String(), `"`, "&quot;")
+}
+
+const contentTypes = xml.Header + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+	`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+	`<Default Extension="xml" ContentType="application/xml"/>` +
+	`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+	`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+	`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>` +
+	`</Types>`
+
+const rootRels = xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+	`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+	`</Relationships>`
+
+const workbook = xml.Header + `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" ` +
+	`xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+	`<sheets><sheet name="{{SHEET}}" sheetId="1" r:id="rId1"/></sheets></workbook>`
+
+const workbookRels = xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+	`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+	`<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>` +
+	`</Relationships>`
+
+// styles — минимальная таблица стилей: встроенные форматы 14 (дата) и 22 (дата и время).
+const styles = xml.Header + `<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+	`<fonts count="1"><font><sz val="11"/><name val="Calibri"/></font></fonts>` +
+	`<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>` +
+	`<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>` +
+	`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` +
+	`<cellXfs count="3">` +
+	`<xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>` +
+	`<xf numFmtId="14" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>` +
+	`<xf numFmtId="22" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>` +
+	`</cellXfs>` +
+	`<cellStyles count="1"><cellStyle name="Normal" xfId="0" builtinId="0"/></cellStyles>` +
+	`</styleSheet>`
@@ -0,0 +1,154 @@
+package xlsx
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestColumnName(t *testing.T) {
+	tests := []struct {
+		in   int
+		want string
+	}{
+		{0, "A"},
+		{1, "B"},
+		{25, "Z"},
+		{26, "AA"},
+		{27, "AB"},
+		{51, "AZ"},
+		{52, "BA"},
+		{701, "ZZ"},
+		{702, "AAA"},
+		{16383, "XFD"},
+	}
+	for _, tt := range tests {
+		if got := ColumnName(tt.in); got != tt.want {
+			t.Errorf("ColumnName(%d) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestSerial(t *testing.T) {
+	moscow := time.FixedZone("MSK", 3*60*60)
+	tests := []struct {
+		in   time.Time
+		want string
+	}{
+		{time.Date(1899, 12, 31, 0, 0, 0, 0, time.UTC), "1"},
+		{time.Date(1900, 3, 1, 0, 0, 0, 0, time.UTC), "61"},
+		{time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC), "45726"},
+		{time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC), "45726.5"},
+		{time.Date(2025, 3, 10, 6, 0, 0, 0, time.UTC), "45726.25"},
+		// Берутся показания часов, а не момент в UTC.
+		{time.Date(2025, 3, 10, 1, 0, 0, 0, moscow).Add(-time.Hour), "45726"},
+		{time.Date(2025, 3, 10, 18, 0, 0, 999, moscow), "45726.75"},
+	}
+	for _, tt := range tests {
+		if got := serial(tt.in); got != tt.want {
+			t.Errorf("serial(%v) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestSanitizeSheetName(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"Тренировки", "Тренировки"},
+		{"", "Sheet1"},
+		{"2025/03 [март]: итоги?", "2025_03 _март__ итоги_"},
+		{strings.Repeat("Ж", 40), strings.Repeat("Ж", maxSheetName)},
+	}
+	for _, tt := range tests {
+		if got := sanitizeSheetName(tt.in); got != tt.want {
+			t.Errorf("sanitizeSheetName(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, `Клиенты "VIP" & <друзья>`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows := [][]Cell{
+		{Str("Имя"), Str("Дата"), Str("Начало"), Str("Вес")},
+		{Str("Иван <Петров> & Ко"), Date(time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)),
+			DateTime(time.Date(2025, 3, 10, 18, 0, 0, 0, time.UTC)), Float(82.5)},
+		{Str(" пробелы "), Empty, Empty, Int(-3)},
+	}
+	for _, r := range rows {
+		if err := w.WriteRow(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{}
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[f.Name] = string(b)
+	}
+
+	for _, name := range []string{
+		"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml",
+		"xl/_rels/workbook.xml.rels", "xl/styles.xml", "xl/worksheets/sheet1.xml",
+	} {
+		body, ok := files[name]
+		if !ok {
+			t.Fatalf("archive has no %s", name)
+		}
+		// Каждая часть должна быть корректным XML.
+		d := xml.NewDecoder(strings.NewReader(body))
+		for {
+			if _, err := d.Token(); err == io.EOF {
+				break
+			} else if err != nil {
+				t.Fatalf("%s: %v", name, err)
+			}
+		}
+	}
+
+	if wb := files["xl/workbook.xml"]; !strings.Contains(wb, `name="Клиенты &#34;VIP&#34; &amp; &lt;друзья&gt;"`) &&
+		!strings.Contains(wb, `name="Клиенты &quot;VIP&quot; &amp; &lt;друзья&gt;"`) {
+		t.Errorf("sheet name is not escaped: %s", wb)
+	}
+
+	sheet := files["xl/worksheets/sheet1.xml"]
+	for _, want := range []string{
+		`<row r="1"><c r="A1" t="inlineStr"><is><t xml:space="preserve">Имя</t></is></c>`,
+		`<c r="A2" t="inlineStr"><is><t xml:space="preserve">Иван &lt;Петров&gt; &amp; Ко</t></is></c>`,
+		`<c r="B2" s="1"><v>45726</v></c>`,
+		`<c r="C2" s="2"><v>45726.75</v></c>`,
+		`<c r="D2"><v>82.5</v></c>`,
+		`<t xml:space="preserve"> пробелы </t>`,
+		`<c r="D3"><v>-3</v></c></row>`,
+	} {
+		if !strings.Contains(sheet, want) {
+			t.Errorf("sheet has no %q:\n%s", want, sheet)
+		}
+	}
+	if strings.Contains(sheet, `r="B3"`) || strings.Contains(sheet, `r="C3"`) {
+		t.Errorf("empty cells are written:\n%s", sheet)
+	}
+}